@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+)
+
+const apiKeyUsage = "usage: bifrost apikey [create <owner> <scope,...> [workspace]|list|revoke <id>]"
+
+// runAPIKey runs the apikey subcommand, it is the way to create the first admin key
+func runAPIKey(ctx context.Context, service authSVC.Service, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing apikey action, %s", apiKeyUsage)
+	}
+
+	ctx = logger.SetLoggerToContext(ctx, logger.GetDefaultLogger())
+	// The command runs next to the database, it manages the keys of all the workspaces
+	ctx = domain.SetAPIKeyToContext(ctx, &domain.APIKey{Owner: "cli", Scopes: []domain.Scope{domain.ScopeGlobalAdmin}})
+	switch args[0] {
+	case "create":
+		if len(args) != 3 && len(args) != 4 {
+			return fmt.Errorf("invalid create arguments, %s", apiKeyUsage)
+		}
+
+		workspace := workspaces.Default
+		if len(args) == 4 {
+			workspace = workspaces.Workspace(args[3])
+		}
+
+		scopes, err := domain.ParseScopes(strings.Split(args[2], ","))
+		if err != nil {
+			return err
+		}
+
+		apiKey, key, err := service.CreateAPIKey(ctx, args[1], workspace, scopes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created api key %s for %s in workspace %s with scopes %v\n", apiKey.ID, apiKey.Owner, apiKey.Workspace, apiKey.Scopes)
+		fmt.Printf("Key, it will not be shown again: %s\n", key)
+	case "list":
+		apiKeys, err := service.ListAPIKeys(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range *apiKeys {
+			status := "active"
+			if k.IsRevoked() {
+				status = fmt.Sprintf("revoked at %s", k.RevokedAt)
+			}
+
+			fmt.Printf("%s\t%s\t%s\tbfk_%s_...\t%v\t%s\n", k.ID, k.Owner, k.Workspace, k.Prefix, k.Scopes, status)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("invalid revoke arguments, %s", apiKeyUsage)
+		}
+
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid api key id: %w", err)
+		}
+
+		if _, err := service.RevokeAPIKey(ctx, domain.ID(id)); err != nil {
+			return err
+		}
+
+		fmt.Printf("Revoked api key %s\n", args[1])
+	default:
+		return fmt.Errorf("unknown apikey action %q, %s", args[0], apiKeyUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	domain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+)
+
+const importUsage = "usage: bifrost import <binance|csv> [-pair <pair>] [-interval <interval>] [-columns <field=column,...>] [-date-format <format>] [-delimiter <char>] <file.csv|file.zip>..."
+
+// runImport runs the import subcommand, it loads Binance klines dumps or mapped CSV files, the existing candles are skipped
+func runImport(ctx context.Context, service candlesSVC.Service, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing import format, %s", importUsage)
+	}
+
+	options := domain.ImportOptions{Format: domain.ImportFormat(args[0])}
+	if options.Format != domain.ImportBinance && options.Format != domain.ImportCSV {
+		return fmt.Errorf("unknown import format %q, %s", args[0], importUsage)
+	}
+
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	pair := flags.String("pair", "", "the pair of all the candles, instead of the pair column or the file name, eg. BTCUSDC or BTC/USDC")
+	interval := flags.String("interval", "", "the interval of all the candles, instead of the interval column or the file name, eg. 1m")
+	columns := flags.String("columns", "", "the csv columns of the candle fields not named after them, eg. date=open_time,close=c")
+	dateFormat := flags.String("date-format", "", "rfc3339, unix or a Go layout of a UTC date, defaults to unix for binance and rfc3339 for csv")
+	delimiter := flags.String("delimiter", "", "the csv delimiter, a comma by default")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("invalid import arguments, %s", importUsage)
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("missing files to import, %s", importUsage)
+	}
+
+	var err error
+	if *pair != "" {
+		if options.Pair, err = common.ParseSymbol(*pair); err != nil {
+			return err
+		}
+	}
+
+	if *interval != "" {
+		if options.Interval, err = common.ParseExchangeInterval(*interval); err != nil {
+			return err
+		}
+	}
+
+	if options.Columns, err = domain.ParseColumns(*columns); err != nil {
+		return err
+	}
+
+	options.DateFormat = domain.DateFormat(*dateFormat)
+	if runes := []rune(*delimiter); len(runes) > 1 {
+		return fmt.Errorf("invalid delimiter %q, a single character is expected", *delimiter)
+	} else if len(runes) == 1 {
+		options.Delimiter = runes[0]
+	}
+
+	ctx = logger.SetLoggerToContext(ctx, logger.GetDefaultLogger())
+	total := domain.IngestResult{}
+	for _, name := range flags.Args() {
+		result, err := importFile(ctx, service, name, options)
+		if result != nil {
+			total.Inserted += result.Inserted
+			total.Skipped += result.Skipped
+		}
+
+		if err != nil {
+			return fmt.Errorf("unable to import %s, %d candles inserted and %d skipped before the error: %w", name, total.Inserted, total.Skipped, err)
+		}
+
+		fmt.Printf("Imported %s: %d candles inserted, %d skipped\n", name, result.Inserted, result.Skipped)
+	}
+
+	if flags.NArg() > 1 {
+		fmt.Printf("Imported %d files: %d candles inserted, %d skipped\n", flags.NArg(), total.Inserted, total.Skipped)
+	}
+
+	return nil
+}
+
+// importFile ingests the candles of a CSV file, or of the CSV files of a ZIP archive
+func importFile(ctx context.Context, service candlesSVC.Service, name string, options domain.ImportOptions) (*domain.IngestResult, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader domain.Reader
+	if strings.EqualFold(filepath.Ext(name), ".zip") {
+		info, statErr := file.Stat()
+		if statErr != nil {
+			return nil, statErr
+		}
+
+		reader, err = domain.NewZipImportReader(file, info.Size(), options)
+	} else {
+		reader, err = domain.NewImportReader(file, name, options)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return service.IngestCandles(ctx, domain.All(reader))
+}
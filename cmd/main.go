@@ -14,6 +14,7 @@ import (
 	gommonLog "github.com/labstack/gommon/log"
 
 	persistence "github.com/sopial42/bifrost/pkg/adapters/persistence"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/migrations"
 
 	HTTPHandler "github.com/sopial42/bifrost/internal/adapters/httpserver"
 
@@ -26,42 +27,130 @@ import (
 	positionsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/positions"
 	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
 
+	experimentsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/experiments"
+	experimentsSVC "github.com/sopial42/bifrost/pkg/services/experiments"
+
+	analyticsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/analytics"
+	analyticsSVC "github.com/sopial42/bifrost/pkg/services/analytics"
+
+	authPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/auth"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+
+	idempotencyPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/idempotency"
+	idempotencySVC "github.com/sopial42/bifrost/pkg/services/idempotency"
+
 	"github.com/sopial42/bifrost/pkg/common/config"
 	"github.com/sopial42/bifrost/pkg/common/errors"
 	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/metrics"
 	"github.com/sopial42/bifrost/pkg/common/pinger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+)
+
+const (
+	pingRoute    = "/ping"
+	metricsRoute = "/metrics"
 )
 
-const pingRoute = "/ping"
+// experimentsShutdownTimeout is the delay left to the running experiments once the server is shut down
+const experimentsShutdownTimeout = 30 * time.Second
 
 func main() {
 	config := config.Load()
 
 	// Init external clients
 	pgClient := persistence.NewPGClient(config.DB)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), pgClient.Client, os.Args[2:]); err != nil {
+			fmt.Printf("Unable to migrate the database: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	authPersistence := authPersistence.NewPersistence(pgClient.Client)
+	authService := authSVC.NewAuthService(authPersistence)
+
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKey(context.Background(), authService, os.Args[2:]); err != nil {
+			fmt.Printf("Unable to manage api keys: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		service := candlesSVC.NewCandlesService(candlesPersistence.NewPersistence(pgClient.Client), config.Candles.Validation)
+		if err := runImport(context.Background(), service, os.Args[2:]); err != nil {
+			fmt.Printf("Unable to import candles: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.DB.AutoMigrate {
+		group, err := migrations.NewMigrator(pgClient.Client).Up(context.Background())
+		if err != nil {
+			panic(err)
+		}
+
+		if !group.IsZero() {
+			fmt.Printf("Migrated to %s\n", group)
+		}
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), config.Tracing)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			fmt.Printf("Unable to flush the traces: %v\n", err)
+		}
+	}()
+
 	// Configure echo engine
 	engine := echo.New()
 
-	buySignalsPersistence := buySignalsPersistence.NewPersistence(pgClient.Client)
-	buySignalsService := buySignalsSVC.NewBuySignalsService(buySignalsPersistence)
-
 	candlesPersistence := candlesPersistence.NewPersistence(pgClient.Client)
-	candlesService := candlesSVC.NewCandlesService(candlesPersistence)
+	candlesService := candlesSVC.NewCandlesService(candlesPersistence, config.Candles.Validation)
+
+	buySignalsPersistence := buySignalsPersistence.NewPersistence(pgClient.Client)
+	buySignalsService := buySignalsSVC.NewBuySignalsService(buySignalsPersistence, candlesService)
 
 	positionsPersistence := positionsPersistence.NewPersistence(pgClient.Client)
 	positionsService := positionsSVC.NewPositionsService(positionsPersistence, candlesService, buySignalsService)
 
+	experimentsPersistence := experimentsPersistence.NewPersistence(pgClient.Client)
+	experimentsService := experimentsSVC.NewExperimentsService(experimentsPersistence, buySignalsService, positionsService)
+	if err := experimentsService.FailInterrupted(context.Background()); err != nil {
+		panic(err)
+	}
+
+	analyticsPersistence := analyticsPersistence.NewPersistence(pgClient.Client)
+	analyticsService := analyticsSVC.NewAnalyticsService(analyticsPersistence, candlesService, positionsService)
+
+	idempotencyPersistence := idempotencyPersistence.NewPersistence(pgClient.Client)
+	idempotencyService := idempotencySVC.NewIdempotencyService(idempotencyPersistence, config.Idempotency.TTL, config.Idempotency.LockTimeout)
+
 	// Custom logger
 	log := logger.NewLogger(config.Logger)
 	defer log.Sync() //nolint:errcheck
+	// The request ID and the span are set first, so the logger gets them
+	logger.SetRequestIDMiddlewareEcho(engine)
+	tracing.SetTracingMiddlewareEcho(engine, monitoringSkipper)
 	logger.SetLoggerMiddlewareEcho(engine, log)
 	logger.SetHTTPLoggerMiddlewareEcho(engine, urlSkipper)
+	metrics.SetMetricsMiddlewareEcho(engine, monitoringSkipper)
 	engine.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
 		LogLevel: gommonLog.ERROR,
 	}))
 
 	errors.SetCustomErrorHandler(engine)
 	pinger.SetNewPingers(engine, pingRoute, pgClient /*, mailClient*/)
+	metrics.SetMetricsHandler(engine, metricsRoute)
+	metrics.RegisterDBStats(pgClient.Client.DB, config.DB.DBName)
 	corsConfig := middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{config.Cors.AllowOrigin},
 		AllowCredentials: true,
@@ -69,9 +158,28 @@ func main() {
 	})
 	engine.Use(corsConfig)
 
+	// The API keys are only managed by authenticated requests, the first key is created with the apikey subcommand
+	if config.Auth.Enabled {
+		HTTPHandler.SetAuthMiddleware(engine, authService)
+		HTTPHandler.SetAuthHTTPHandler(engine, authService)
+	}
+
+	openAPI, err := HTTPHandler.LoadOpenAPI()
+	if err != nil {
+		panic(err)
+	}
+
+	// Validated once authenticated, so unauthenticated requests learn nothing about the contract
+	HTTPHandler.SetOpenAPIValidationMiddleware(engine, openAPI)
+	HTTPHandler.SetOpenAPIHTTPHandler(engine, openAPI)
+	// Keys are scoped to the workspace of the authenticated key, only valid requests are stored
+	HTTPHandler.SetIdempotencyMiddleware(engine, idempotencyService)
+
 	HTTPHandler.SetBuySignalsHTTPHandler(engine, buySignalsService)
 	HTTPHandler.SetCandlesHTTPHandler(engine, candlesService)
 	HTTPHandler.SetPositionsHTTPHandler(engine, positionsService)
+	HTTPHandler.SetExperimentsHTTPHandler(engine, experimentsService)
+	HTTPHandler.SetAnalyticsHTTPHandler(engine, analyticsService)
 
 	// Start the server and handle shutdown
 	go func() {
@@ -92,9 +200,21 @@ func main() {
 		fmt.Printf("Unable to shutdown server gracefully: %v\n", err)
 		return
 	}
+
+	// The experiments run in the background get their own delay, then are interrupted and set as failed
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), experimentsShutdownTimeout)
+	defer cancelWait()
+	if err := experimentsService.Wait(waitCtx); err != nil {
+		fmt.Printf("Unable to wait for the experiments: %v\n", err)
+	}
 }
 
 func urlSkipper(c echo.Context) bool {
 	return c.Path() == pingRoute &&
 		c.Response().Status >= http.StatusOK
 }
+
+// monitoringSkipper skips the metrics and ping routes, they are neither traced nor measured
+func monitoringSkipper(c echo.Context) bool {
+	return c.Path() == metricsRoute || c.Path() == pingRoute
+}
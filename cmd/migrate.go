@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/migrations"
+)
+
+const migrateUsage = "usage: bifrost migrate [up|down|status]"
+
+// runMigrate runs the migrate subcommand, up is the default action
+func runMigrate(ctx context.Context, db *bun.DB, args []string) error {
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	migrator := migrations.NewMigrator(db)
+	switch action {
+	case "up":
+		group, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+
+		if group.IsZero() {
+			fmt.Println("No migration to apply, the database is up to date")
+			return nil
+		}
+
+		fmt.Printf("Migrated to %s\n", group)
+	case "down":
+		group, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+
+		if group.IsZero() {
+			fmt.Println("No migration to rollback")
+			return nil
+		}
+
+		fmt.Printf("Rolled back %s\n", group)
+	case "status":
+		ms, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Applied migrations: %s\n", ms.Applied())
+		fmt.Printf("Pending migrations: %s\n", ms.Unapplied())
+	default:
+		return fmt.Errorf("unknown migrate action %q, %s", action, migrateUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	domain "github.com/sopial42/bifrost/pkg/domains/analytics"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	analyticsSVC "github.com/sopial42/bifrost/pkg/services/analytics"
+)
+
+type analyticsHandler struct {
+	analyticsSVC analyticsSVC.Service
+}
+
+func SetAnalyticsHTTPHandler(e *echo.Echo, service analyticsSVC.Service) {
+	p := &analyticsHandler{
+		analyticsSVC: service,
+	}
+
+	apiV1 := e.Group("/api/v1")
+	{
+		apiV1.POST("/analytics/walk_forward", p.runWalkForward)
+		apiV1.GET("/analytics/walk_forward/:id", p.getWalkForward)
+		apiV1.POST("/analytics/monte_carlo", p.runMonteCarlo)
+	}
+}
+
+type RunWalkForwardInput struct {
+	Pair            string     `json:"pair"`
+	Interval        string     `json:"interval"`
+	InSampleDays    int        `json:"in_sample_days"`
+	OutOfSampleDays int        `json:"out_of_sample_days"`
+	StepDays        int        `json:"step_days"`
+	Anchored        bool       `json:"anchored"`
+	Metrics         []string   `json:"metrics"`
+	MinTrades       int        `json:"min_trades"`
+	StartDate       *time.Time `json:"start_date"`
+	EndDate         *time.Time `json:"end_date"`
+}
+
+func (p *analyticsHandler) runWalkForward(context echo.Context) error {
+	input := new(RunWalkForwardInput)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	pair, err := common.ParsePair(input.Pair)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid pair", err)
+	}
+
+	interval, err := common.ParseInterval(input.Interval)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid interval", err)
+	}
+
+	metrics, err := domain.ParseMetrics(input.Metrics)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid metrics", err)
+	}
+
+	if input.StartDate != nil && input.EndDate != nil && input.EndDate.Before(*input.StartDate) {
+		return appErrors.NewInvalidInput("invalid input, end_date should be after start_date", nil)
+	}
+
+	walkForward, err := p.analyticsSVC.RunWalkForward(context.Request().Context(), domain.WalkForwardRequest{
+		Pair:            pair,
+		Interval:        interval,
+		InSampleDays:    input.InSampleDays,
+		OutOfSampleDays: input.OutOfSampleDays,
+		StepDays:        input.StepDays,
+		Anchored:        input.Anchored,
+		Metrics:         metrics,
+		MinTrades:       input.MinTrades,
+		StartDate:       input.StartDate,
+		EndDate:         input.EndDate,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to run walk-forward: %w", err)
+	}
+
+	return context.JSON(http.StatusCreated, map[string]any{
+		"walk_forward": walkForward,
+	})
+}
+
+func (p *analyticsHandler) getWalkForward(context echo.Context) error {
+	id, err := uuid.Parse(context.Param("id"))
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid id", err)
+	}
+
+	walkForward, err := p.analyticsSVC.GetWalkForward(context.Request().Context(), domain.ID(id))
+	if err != nil {
+		return fmt.Errorf("unable to get walk-forward: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, map[string]any{
+		"walk_forward": walkForward,
+	})
+}
+
+type RunMonteCarloInput struct {
+	Pair              string                  `json:"pair"`
+	Interval          string                  `json:"interval"`
+	BuySignalFullname string                  `json:"buy_signal_fullname"`
+	PositionFullname  string                  `json:"position_fullname"`
+	WinlossRatio      *positions.WinLossRatio `json:"winloss_ratio"`
+	Method            string                  `json:"method"`
+	Iterations        int                     `json:"iterations"`
+	Seed              int64                   `json:"seed"`
+	Percentiles       []float64               `json:"percentiles"`
+	StartDate         *time.Time              `json:"start_date"`
+	EndDate           *time.Time              `json:"end_date"`
+}
+
+// runMonteCarlo is not persisted, the same seed always returns the same distributions
+func (p *analyticsHandler) runMonteCarlo(context echo.Context) error {
+	input := new(RunMonteCarloInput)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	pair, err := common.ParsePair(input.Pair)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid pair", err)
+	}
+
+	interval, err := common.ParseInterval(input.Interval)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid interval", err)
+	}
+
+	method, err := domain.ParseResamplingMethod(input.Method)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid method", err)
+	}
+
+	monteCarlo, err := p.analyticsSVC.RunMonteCarlo(context.Request().Context(), domain.MonteCarloRequest{
+		Pair:              pair,
+		Interval:          interval,
+		BuySignalFullname: buySignals.Fullname(input.BuySignalFullname),
+		PositionFullname:  positions.Fullname(input.PositionFullname),
+		WinlossRatio:      input.WinlossRatio,
+		Method:            method,
+		Iterations:        input.Iterations,
+		Seed:              input.Seed,
+		Percentiles:       input.Percentiles,
+		StartDate:         input.StartDate,
+		EndDate:           input.EndDate,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to run monte carlo: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, map[string]any{
+		"monte_carlo": monteCarlo,
+	})
+}
@@ -0,0 +1,193 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+)
+
+const apiV1Prefix = "/api/v1"
+
+// routeScopes is the scope required by each route, routes are echo paths
+// A GET route not listed requires the read scope, any other route not listed requires the admin scope
+var routeScopes = map[string]domain.Scope{
+	"POST /api/v1/candles":                            domain.ScopeCandlesWrite,
+	"POST /api/v1/candles/ingest":                     domain.ScopeCandlesWrite,
+	"POST /api/v1/candles/import":                     domain.ScopeCandlesWrite,
+	"PATCH /api/v1/candles/rsi":                       domain.ScopeCandlesWrite,
+	"PUT /api/v1/candles":                             domain.ScopeCandlesWrite,
+	"POST /api/v1/candles/minute-close-prices":        domain.ScopeRead,
+	"POST /api/v1/buy_signals":                        domain.ScopeSignalsWrite,
+	"POST /api/v1/buy_signals/detect":                 domain.ScopeSignalsWrite,
+	"POST /api/v1/positions":                          domain.ScopePositionsWrite,
+	"POST /api/v1/positions/generate":                 domain.ScopePositionsWrite,
+	"POST /api/v1/positions/compute/with-buy-signals": domain.ScopePositionsCompute,
+	"POST /api/v1/positions/compute/all":              domain.ScopePositionsCompute,
+	"POST /api/v1/positions/compute/:id":              domain.ScopePositionsCompute,
+	"POST /api/v1/experiments":                        domain.ScopeAnalyticsWrite,
+	"POST /api/v1/analytics/walk_forward":             domain.ScopeAnalyticsWrite,
+	"POST /api/v1/analytics/monte_carlo":              domain.ScopeRead,
+	"GET /api/v1/api_keys":                            domain.ScopeAdmin,
+	"POST /api/v1/api_keys":                           domain.ScopeAdmin,
+	"DELETE /api/v1/api_keys/:id":                     domain.ScopeAdmin,
+}
+
+func requiredScope(method string, path string) domain.Scope {
+	if scope, ok := routeScopes[method+" "+path]; ok {
+		return scope
+	}
+
+	if method == http.MethodGet {
+		return domain.ScopeRead
+	}
+
+	return domain.ScopeAdmin
+}
+
+// SetAuthMiddleware authenticates the API requests with their X-API-Key header and checks the route scope
+// The requests are scoped to the workspace of the key, routes out of the API, eg. the ping one, and the OpenAPI document are not authenticated
+func SetAuthMiddleware(e *echo.Echo, service authSVC.Service) {
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !strings.HasPrefix(c.Path(), apiV1Prefix+"/") || c.Path() == OpenAPIRoute {
+				return next(c)
+			}
+
+			key := c.Request().Header.Get(domain.HeaderAPIKey)
+			if key == "" {
+				return appErrors.NewUnauthorized(fmt.Sprintf("missing %s header", domain.HeaderAPIKey), nil)
+			}
+
+			ctx := c.Request().Context()
+			apiKey, err := service.Authenticate(ctx, key)
+			if err != nil {
+				return err
+			}
+
+			logger.SetUserDetailsToLogger(c, apiKey.Owner)
+
+			scope := requiredScope(c.Request().Method, c.Path())
+			if !apiKey.Allows(scope) {
+				return appErrors.NewForbidden(fmt.Sprintf("api key of %s requires the %s scope", apiKey.Owner, scope), nil)
+			}
+
+			ctx = domain.SetAPIKeyToContext(c.Request().Context(), apiKey)
+			ctx = workspaces.SetWorkspaceToContext(ctx, apiKey.Workspace)
+			ctx = logger.SetLoggerToContext(ctx, logger.GetLogger(ctx).WithField(workspaces.LoggerKeyWorkspace, apiKey.Workspace))
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+}
+
+// requireAPIKey refuses the keys management to the unauthenticated requests, eg. if the handler is set without the authentication middleware
+func requireAPIKey(ctx context.Context) error {
+	if domain.GetAPIKeyFromContext(ctx) == nil {
+		return appErrors.NewForbidden("the api keys are managed with an authenticated key, the first one is created with the apikey command", nil)
+	}
+
+	return nil
+}
+
+type authHandler struct {
+	authSVC authSVC.Service
+}
+
+// SetAuthHTTPHandler sets the API keys routes, they require the authentication middleware
+func SetAuthHTTPHandler(e *echo.Echo, service authSVC.Service) {
+	p := &authHandler{
+		authSVC: service,
+	}
+
+	apiV1 := e.Group(apiV1Prefix)
+	{
+		apiV1.POST("/api_keys", p.createAPIKey)
+		apiV1.GET("/api_keys", p.listAPIKeys)
+		apiV1.DELETE("/api_keys/:id", p.revokeAPIKey)
+	}
+}
+
+type CreateAPIKeyInput struct {
+	Owner string `json:"owner"`
+	// Workspace is the one of the request if empty
+	Workspace string   `json:"workspace"`
+	Scopes    []string `json:"scopes"`
+}
+
+// createAPIKey returns the plain key, it is the only time it can be read
+func (p *authHandler) createAPIKey(context echo.Context) error {
+	if err := requireAPIKey(context.Request().Context()); err != nil {
+		return err
+	}
+
+	input := new(CreateAPIKeyInput)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	scopes, err := domain.ParseScopes(input.Scopes)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid scopes", err)
+	}
+
+	ctx := context.Request().Context()
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	if input.Workspace != "" {
+		workspace = workspaces.Workspace(input.Workspace)
+	}
+
+	apiKey, key, err := p.authSVC.CreateAPIKey(ctx, input.Owner, workspace, scopes)
+	if err != nil {
+		return fmt.Errorf("unable to create api key: %w", err)
+	}
+
+	return context.JSON(http.StatusCreated, map[string]any{
+		"api_key": apiKey,
+		"key":     key,
+	})
+}
+
+func (p *authHandler) listAPIKeys(context echo.Context) error {
+	if err := requireAPIKey(context.Request().Context()); err != nil {
+		return err
+	}
+
+	apiKeys, err := p.authSVC.ListAPIKeys(context.Request().Context())
+	if err != nil {
+		return fmt.Errorf("unable to list api keys: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, map[string]any{
+		"api_keys": apiKeys,
+	})
+}
+
+func (p *authHandler) revokeAPIKey(context echo.Context) error {
+	if err := requireAPIKey(context.Request().Context()); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(context.Param("id"))
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid id", err)
+	}
+
+	apiKey, err := p.authSVC.RevokeAPIKey(context.Request().Context(), domain.ID(id))
+	if err != nil {
+		return fmt.Errorf("unable to revoke api key: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, map[string]any{
+		"api_key": apiKey,
+	})
+}
@@ -0,0 +1,151 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+)
+
+// newAuthEngine authenticates its routes with the keys of the service, the handlers answer the workspace of the request
+func newAuthEngine(service authSVC.Service) *echo.Echo {
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetAuthMiddleware(e, service)
+
+	workspace := func(c echo.Context) error {
+		return c.String(http.StatusOK, string(workspaces.GetWorkspaceFromContext(c.Request().Context())))
+	}
+	e.GET("/ping", workspace)
+	e.GET(OpenAPIRoute, workspace)
+	e.GET(apiV1Prefix+"/candles", workspace)
+	e.POST(apiV1Prefix+"/candles", workspace)
+	e.POST(apiV1Prefix+"/unlisted", workspace)
+
+	return e
+}
+
+// createKey creates a key as the apikey command does, it manages all the workspaces
+func createKey(t *testing.T, service authSVC.Service, workspace workspaces.Workspace, scopes ...domain.Scope) (*domain.APIKey, string) {
+	t.Helper()
+
+	ctx := domain.SetAPIKeyToContext(context.Background(), &domain.APIKey{Owner: "cli", Scopes: []domain.Scope{domain.ScopeGlobalAdmin}})
+	apiKey, key, err := service.CreateAPIKey(ctx, "bot", workspace, scopes)
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	return apiKey, key
+}
+
+func serveWithKey(e *echo.Echo, method string, target string, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(domain.HeaderAPIKey, key)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	service := authSVC.NewAuthService(memory.NewAuthPersistence(memory.NewStore()))
+	e := newAuthEngine(service)
+
+	_, readKey := createKey(t, service, "team-a", domain.ScopeRead)
+	_, writeKey := createKey(t, service, "team-a", domain.ScopeRead, domain.ScopeCandlesWrite)
+	_, adminKey := createKey(t, service, "team-b", domain.ScopeAdmin)
+	revoked, revokedKey := createKey(t, service, "team-a", domain.ScopeAdmin)
+	ctx := domain.SetAPIKeyToContext(context.Background(), &domain.APIKey{Scopes: []domain.Scope{domain.ScopeGlobalAdmin}})
+	if _, err := service.RevokeAPIKey(ctx, *revoked.ID); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+
+	prefix, err := domain.ParseKey(readKey)
+	if err != nil {
+		t.Fatalf("ParseKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		key        string
+		wantStatus int
+		// wantWorkspace is the workspace set on the request context
+		wantWorkspace workspaces.Workspace
+	}{
+		{name: "missing header", method: http.MethodGet, target: "/api/v1/candles", wantStatus: http.StatusUnauthorized},
+		{name: "malformed key", method: http.MethodGet, target: "/api/v1/candles", key: "not-a-key", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", method: http.MethodGet, target: "/api/v1/candles", key: "bfk_00000000_secret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong secret", method: http.MethodGet, target: "/api/v1/candles", key: "bfk_" + prefix + "_secret", wantStatus: http.StatusUnauthorized},
+		{name: "revoked key", method: http.MethodGet, target: "/api/v1/candles", key: revokedKey, wantStatus: http.StatusUnauthorized},
+		{name: "read key on a get route", method: http.MethodGet, target: "/api/v1/candles", key: readKey, wantStatus: http.StatusOK, wantWorkspace: "team-a"},
+		{name: "read key on a write route", method: http.MethodPost, target: "/api/v1/candles", key: readKey, wantStatus: http.StatusForbidden},
+		{name: "scoped key on its write route", method: http.MethodPost, target: "/api/v1/candles", key: writeKey, wantStatus: http.StatusOK, wantWorkspace: "team-a"},
+		{name: "unlisted route requires admin", method: http.MethodPost, target: "/api/v1/unlisted", key: writeKey, wantStatus: http.StatusForbidden},
+		{name: "admin key on an unlisted route", method: http.MethodPost, target: "/api/v1/unlisted", key: adminKey, wantStatus: http.StatusOK, wantWorkspace: "team-b"},
+		{name: "openapi document is exempt", method: http.MethodGet, target: OpenAPIRoute, wantStatus: http.StatusOK, wantWorkspace: workspaces.Default},
+		{name: "ping is exempt", method: http.MethodGet, target: "/ping", wantStatus: http.StatusOK, wantWorkspace: workspaces.Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveWithKey(e, tt.method, tt.target, tt.key)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+
+			if tt.wantWorkspace != "" && rec.Body.String() != string(tt.wantWorkspace) {
+				t.Errorf("workspace = %s, want %s", rec.Body.String(), tt.wantWorkspace)
+			}
+		})
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   domain.Scope
+	}{
+		{method: http.MethodPost, path: "/api/v1/candles", want: domain.ScopeCandlesWrite},
+		{method: http.MethodPost, path: "/api/v1/analytics/monte_carlo", want: domain.ScopeRead},
+		{method: http.MethodGet, path: "/api/v1/positions", want: domain.ScopeRead},
+		{method: http.MethodGet, path: "/api/v1/api_keys", want: domain.ScopeAdmin},
+		{method: http.MethodDelete, path: "/api/v1/unlisted/:id", want: domain.ScopeAdmin},
+	}
+
+	for _, tt := range tests {
+		if got := requiredScope(tt.method, tt.path); got != tt.want {
+			t.Errorf("requiredScope(%s, %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeysHandlerRequiresAuthentication(t *testing.T) {
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetAuthHTTPHandler(e, authSVC.NewAuthService(memory.NewAuthPersistence(memory.NewStore())))
+
+	for _, route := range []struct{ method, target string }{
+		{method: http.MethodPost, target: "/api/v1/api_keys"},
+		{method: http.MethodGet, target: "/api/v1/api_keys"},
+		{method: http.MethodDelete, target: "/api/v1/api_keys/8e03978e-40d5-43e8-bc93-6894a57f9324"},
+	} {
+		if rec := serveWithKey(e, route.method, route.target, ""); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s status = %d %s, want 403 without authentication", route.method, route.target, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"net/http"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// batchResults collects the outcome of each item of a batch create, in the order of the request
+// The items with violations are invalid, the other ones are sent to the service
+type batchResults[ID any] struct {
+	collection string
+	results    []common.ItemResult[ID]
+	// indexes are the request indexes of the items sent to the service
+	indexes []int
+}
+
+func newBatchResults[ID any](collection string, size int) *batchResults[ID] {
+	return &batchResults[ID]{
+		collection: collection,
+		results:    make([]common.ItemResult[ID], size),
+		indexes:    make([]int, 0, size),
+	}
+}
+
+// pointer returns the JSON pointer of the item at the request index
+func (b *batchResults[ID]) pointer(index int) string {
+	return appErrors.Pointer(b.collection, index)
+}
+
+// validate records the item as invalid when it has violations, or as sent to the service
+func (b *batchResults[ID]) validate(index int, violations appErrors.FieldViolations) bool {
+	if len(violations) > 0 {
+		b.results[index] = common.ItemResult[ID]{Index: index, Outcome: common.OutcomeInvalid, Violations: violations}
+		return false
+	}
+
+	b.indexes = append(b.indexes, index)
+	return true
+}
+
+// merge sets the results of the service at the request index of their item
+// The violations of the service are relative to the item, they are prefixed by its pointer
+func (b *batchResults[ID]) merge(serviceResults []common.ItemResult[ID]) {
+	for _, res := range serviceResults {
+		res.Index = b.indexes[res.Index]
+		for i := range res.Violations {
+			res.Violations[i].Field = b.pointer(res.Index) + res.Violations[i].Field
+		}
+
+		b.results[res.Index] = res
+	}
+}
+
+// err returns an invalid input error with all the violations when no item is valid
+func (b *batchResults[ID]) err(message string) error {
+	violations := appErrors.FieldViolations{}
+	for _, res := range b.results {
+		if res.Outcome != common.OutcomeInvalid {
+			return nil
+		}
+
+		violations = append(violations, res.Violations...)
+	}
+
+	return violations.Err(message)
+}
+
+// status is 201 when an item is created, 200 when all the items are duplicates or invalid
+func (b *batchResults[ID]) status() int {
+	if common.CountOutcome(b.results, common.OutcomeCreated) > 0 {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
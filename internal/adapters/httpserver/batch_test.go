@@ -0,0 +1,172 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+type batchResponse struct {
+	BuySignals []json.RawMessage              `json:"buy_signals"`
+	Positions  []json.RawMessage              `json:"positions"`
+	Results    []common.ItemResult[uuid.UUID] `json:"results"`
+}
+
+func newBatchEngine() *echo.Echo {
+	store := memory.NewStore()
+	candles := candlesSVC.NewCandlesService(memory.NewCandlesPersistence(store), candlesDomain.ValidationReject)
+	buySignals := buySignalsSVC.NewBuySignalsService(memory.NewBuySignalsPersistence(store), candles)
+
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetBuySignalsHTTPHandler(e, buySignals)
+	SetPositionsHTTPHandler(e, positionsSVC.NewPositionsService(memory.NewPositionsPersistence(store), candles, buySignals))
+
+	return e
+}
+
+func postBatch(t *testing.T, e *echo.Echo, target string, body string, wantStatus int) batchResponse {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != wantStatus {
+		t.Fatalf("POST %s status = %d, want %d, body: %s", target, rec.Code, wantStatus, rec.Body.String())
+	}
+
+	var res batchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("unable to decode the response: %v", err)
+	}
+
+	return res
+}
+
+func checkOutcomes(t *testing.T, results []common.ItemResult[uuid.UUID], want ...common.Outcome) {
+	t.Helper()
+
+	if len(results) != len(want) {
+		t.Fatalf("results = %+v, want %d", results, len(want))
+	}
+
+	for i, res := range results {
+		if res.Index != i || res.Outcome != want[i] {
+			t.Errorf("results[%d] = %+v, want index %d %s", i, res, i, want[i])
+		}
+
+		if (res.ID == nil) != (res.Outcome == common.OutcomeInvalid) {
+			t.Errorf("results[%d] = %+v, only an invalid item has no ID", i, res)
+		}
+
+		if (len(res.Violations) > 0) != (res.Outcome == common.OutcomeInvalid) {
+			t.Errorf("results[%d] = %+v, only an invalid item has violations", i, res)
+		}
+	}
+}
+
+func TestCreateBuySignalsBatchResults(t *testing.T) {
+	e := newBatchEngine()
+	buySignal := `{"name": "morningStar", "business_id": "%s", "fullname": "morningStar", "pair": "SOLUSDC", "interval": "1h", "date": "2024-01-01T00:00:00Z", "price": 100}`
+
+	first := postBatch(t, e, "/api/v1/buy_signals", `{"buy_signals": [`+strings.Replace(buySignal, "%s", "1", 1)+`]}`, http.StatusCreated)
+	checkOutcomes(t, first.Results, common.OutcomeCreated)
+
+	body := `{"buy_signals": [` +
+		strings.Replace(buySignal, "%s", "2", 1) + `,` +
+		`{"name": "morningStar", "business_id": "3", "fullname": "morningStar", "pair": "SOLUSDC", "interval": "7h", "date": "2024-01-01T00:00:00Z", "price": 100},` +
+		strings.Replace(buySignal, "%s", "1", 1) + `,` +
+		strings.Replace(buySignal, "%s", "2", 1) +
+		`]}`
+	res := postBatch(t, e, "/api/v1/buy_signals", body, http.StatusCreated)
+	checkOutcomes(t, res.Results, common.OutcomeCreated, common.OutcomeInvalid, common.OutcomeDuplicate, common.OutcomeDuplicate)
+
+	if len(res.BuySignals) != 1 {
+		t.Errorf("buy_signals = %d, want the created one only", len(res.BuySignals))
+	}
+
+	if res.Results[1].Violations[0].Field != "/buy_signals/1/interval" {
+		t.Errorf("violations = %+v, want /buy_signals/1/interval", res.Results[1].Violations)
+	}
+
+	if *res.Results[2].ID != *first.Results[0].ID || *res.Results[3].ID != *res.Results[0].ID {
+		t.Errorf("results = %+v, want the IDs of the existing buy signals for the duplicates", res.Results)
+	}
+
+	// Nothing is created, the duplicates are not an error
+	res = postBatch(t, e, "/api/v1/buy_signals", `{"buy_signals": [`+strings.Replace(buySignal, "%s", "1", 1)+`]}`, http.StatusOK)
+	checkOutcomes(t, res.Results, common.OutcomeDuplicate)
+}
+
+func TestCreatePositionsBatchResults(t *testing.T) {
+	e := newBatchEngine()
+	bs := postBatch(t, e, "/api/v1/buy_signals", `{"buy_signals": [{"name": "morningStar", "business_id": "1", "fullname": "morningStar", "pair": "SOLUSDC", "interval": "1h", "date": "2024-01-01T00:00:00Z", "price": 100}]}`, http.StatusCreated)
+	position := `{"buy_signal_id": "%s", "name": "percentage", "fullname": "percentage_tp5", "tp": 105, "sl": 97.5, "winloss_ratio": 2}`
+	known := strings.Replace(position, "%s", bs.Results[0].ID.String(), 1)
+
+	body := `{"positions": [` +
+		known + `,` +
+		strings.Replace(position, "%s", uuid.NewString(), 1) + `,` +
+		`{"buy_signal_id": "` + bs.Results[0].ID.String() + `", "fullname": "percentage_tp5", "tp": 105, "sl": 97.5},` +
+		known +
+		`]}`
+	res := postBatch(t, e, "/api/v1/positions", body, http.StatusCreated)
+	checkOutcomes(t, res.Results, common.OutcomeCreated, common.OutcomeInvalid, common.OutcomeInvalid, common.OutcomeDuplicate)
+
+	if res.Results[1].Violations[0].Field != "/positions/1/buy_signal_id" || res.Results[2].Violations[0].Field != "/positions/2/name" {
+		t.Errorf("results = %+v, want the unknown buy signal and the missing name", res.Results)
+	}
+
+	if *res.Results[3].ID != *res.Results[0].ID {
+		t.Errorf("results = %+v, want the ID of the created position for the duplicate", res.Results)
+	}
+
+	// No position is valid
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/positions", strings.NewReader(`{"positions": [`+strings.Replace(position, "%s", uuid.NewString(), 1)+`]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var errRes appErrors.ErrResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errRes); rec.Code != http.StatusBadRequest || err != nil || len(errRes.Error.Violations) != 1 || errRes.Error.Violations[0].Field != "/positions/0/buy_signal_id" {
+		t.Errorf("POST /api/v1/positions = %d %s, want an invalid buy_signal_id", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGeneratePositionsUnknownBuySignals(t *testing.T) {
+	e := newBatchEngine()
+	bs := postBatch(t, e, "/api/v1/buy_signals", `{"buy_signals": [{"name": "morningStar", "business_id": "1", "fullname": "morningStar", "pair": "SOLUSDC", "interval": "1h", "date": "2024-01-01T00:00:00Z", "price": 100}]}`, http.StatusCreated)
+	unknown := uuid.NewString()
+
+	body := `{"name": "percentage", "buy_signal_ids": ["` + unknown + `", "` + bs.Results[0].ID.String() + `", "` + uuid.NewString() + `", "` + unknown + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/positions/generate", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var errRes appErrors.ErrResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errRes); rec.Code != http.StatusBadRequest || err != nil {
+		t.Fatalf("POST /api/v1/positions/generate = %d %s, want invalid buy_signal_ids", rec.Code, rec.Body.String())
+	}
+
+	// A repeated unknown buy signal points to its first index
+	violations := errRes.Error.Violations
+	if len(violations) != 2 || violations[0].Field != "/buy_signal_ids/0" || violations[1].Field != "/buy_signal_ids/2" {
+		t.Errorf("violations = %+v, want /buy_signal_ids/0 and /buy_signal_ids/2", violations)
+	}
+}
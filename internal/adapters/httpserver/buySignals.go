@@ -1,7 +1,6 @@
 package httpserver
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -28,6 +27,7 @@ func SetBuySignalsHTTPHandler(e *echo.Echo, service buySignalsSVC.Service) {
 	{
 		apiV1.POST("/buy_signals", p.createBuySignals)
 		apiV1.GET("/buy_signals", p.getBuySignals)
+		apiV1.POST("/buy_signals/detect", p.detectBuySignals)
 	}
 }
 
@@ -56,9 +56,10 @@ func (p *buySignalsHandler) createBuySignals(context echo.Context) error {
 		return appErrors.NewInvalidInput("invalid input, empty buy signals", nil)
 	}
 
-	newBuySignalsDetails := make([]domain.Details, len(newBuySignalInput.BuySignals))
+	results := newBatchResults[domain.ID]("buy_signals", len(newBuySignalInput.BuySignals))
+	newBuySignalsDetails := make([]domain.Details, 0, len(newBuySignalInput.BuySignals))
 	for i, bs := range newBuySignalInput.BuySignals {
-		newBuySignalsDetails[i] = domain.Details{
+		details := domain.Details{
 			Name:       bs.Name,
 			BusinessID: bs.BusinessID,
 			Fullname:   bs.Fullname,
@@ -68,23 +69,70 @@ func (p *buySignalsHandler) createBuySignals(context echo.Context) error {
 			Price:      bs.Price,
 			Metadata:   bs.Metadata,
 		}
+
+		violations := appErrors.FieldViolations{}
+		validateBuySignal(&violations, results.pointer(i), details)
+		if results.validate(i, violations) {
+			newBuySignalsDetails = append(newBuySignalsDetails, details)
+		}
+	}
+
+	if err := results.err("invalid buy signals"); err != nil {
+		return err
 	}
 
-	buySignals, err := p.buySignalsSVC.CreateBuySignals(context.Request().Context(), &newBuySignalsDetails)
-	if err != nil && !errors.Is(err, appErrors.ErrAlreadyExists) {
+	buySignals, created, err := p.buySignalsSVC.CreateBuySignals(context.Request().Context(), &newBuySignalsDetails)
+	if err != nil {
 		return fmt.Errorf("unable to create buySignals: %w", err)
 	}
+	results.merge(created)
 
-	return context.JSON(http.StatusCreated, map[string]any{
+	return context.JSON(results.status(), map[string]any{
 		"buy_signals": buySignals,
+		"results":     results.results,
 	})
 }
 
+// validateBuySignal adds the violations of the buy signal at the pointer, its ID is not checked
+func validateBuySignal(violations *appErrors.FieldViolations, pointer string, bs domain.Details) {
+	if bs.Name == "" {
+		violations.Add(pointer+"/name", appErrors.ViolationRequired, "name is required")
+	}
+
+	if bs.BusinessID == "" {
+		violations.Add(pointer+"/business_id", appErrors.ViolationRequired, "business_id is required")
+	}
+
+	if bs.Fullname == "" {
+		violations.Add(pointer+"/fullname", appErrors.ViolationRequired, "fullname is required")
+	}
+
+	if bs.Pair == "" {
+		violations.Add(pointer+"/pair", appErrors.ViolationRequired, "pair is required")
+	}
+
+	if bs.Interval == "" {
+		violations.Add(pointer+"/interval", appErrors.ViolationRequired, "interval is required")
+	} else if !bs.Interval.IsValid() {
+		violations.Add(pointer+"/interval", appErrors.ViolationInvalidValue, fmt.Sprintf("interval %q is not allowed", bs.Interval))
+	}
+
+	if bs.Date == (domain.Date{}) {
+		violations.Add(pointer+"/date", appErrors.ViolationRequired, "date is required")
+	}
+
+	if bs.Price == 0 {
+		violations.Add(pointer+"/price", appErrors.ViolationRequired, "price is required")
+	} else if bs.Price < 0 {
+		violations.Add(pointer+"/price", appErrors.ViolationInvalidValue, "price should be greater than 0")
+	}
+}
+
 func (p buySignalsHandler) getBuySignals(context echo.Context) (err error) {
 	pair := context.QueryParam("pair")
 	interval := context.QueryParam("interval")
 	name := context.QueryParam("name")
-	firstDate := context.QueryParam("first_date")
+	firstDate := queryParamWithAlias(context, "start_date", "first_date")
 	limit := context.QueryParam("limit")
 
 	pairParsed := common.Pair(pair)
@@ -101,7 +149,7 @@ func (p buySignalsHandler) getBuySignals(context echo.Context) (err error) {
 	if firstDate != "" {
 		firstDateParsed, err = time.Parse(time.RFC3339, firstDate)
 		if err != nil {
-			return appErrors.NewInvalidInput("invalid first_date", err)
+			return appErrors.NewInvalidInput("invalid start_date", err)
 		}
 	}
 
@@ -126,3 +174,60 @@ func (p buySignalsHandler) getBuySignals(context echo.Context) (err error) {
 		"next_cursor": nextCursor,
 	})
 }
+
+type DetectBuySignalsInput struct {
+	Name      domain.Name     `json:"name"`
+	Pair      common.Pair     `json:"pair"`
+	Interval  common.Interval `json:"interval"`
+	StartDate *time.Time      `json:"start_date"`
+	EndDate   *time.Time      `json:"end_date"`
+	Params    domain.Metadata `json:"params"`
+	Persist   bool            `json:"persist"`
+}
+
+// detectBuySignals runs a built-in detector over the stored candles
+// Detected signals are only stored when persist is true
+func (p *buySignalsHandler) detectBuySignals(context echo.Context) error {
+	input := new(DetectBuySignalsInput)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	if !domain.AllAvailableSignalStrategies[input.Name] {
+		return appErrors.NewInvalidInput("invalid name", nil)
+	}
+
+	if !input.Pair.IsValid() {
+		return appErrors.NewInvalidInput("invalid pair", nil)
+	}
+
+	if !input.Interval.IsValid() {
+		return appErrors.NewInvalidInput("invalid interval", nil)
+	}
+
+	if input.StartDate != nil && input.EndDate != nil && input.EndDate.Before(*input.StartDate) {
+		return appErrors.NewInvalidInput("invalid input, end_date should be after start_date", nil)
+	}
+
+	buySignals, err := p.buySignalsSVC.DetectBuySignals(context.Request().Context(), buySignalsSVC.DetectRequest{
+		Name:      input.Name,
+		Params:    input.Params,
+		Pair:      input.Pair,
+		Interval:  input.Interval,
+		StartDate: input.StartDate,
+		EndDate:   input.EndDate,
+		Persist:   input.Persist,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to detect buySignals: %w", err)
+	}
+
+	status := http.StatusOK
+	if input.Persist {
+		status = http.StatusCreated
+	}
+
+	return context.JSON(status, map[string]any{
+		"buy_signals": buySignals,
+	})
+}
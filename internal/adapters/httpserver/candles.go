@@ -3,10 +3,14 @@ package httpserver
 import (
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
@@ -30,8 +34,14 @@ func SetCandlesHTTPHandler(e *echo.Echo, service candlesSVC.Service) {
 		apiV1.GET("/candles", p.getCandles)
 		apiV1.POST("/candles/minute-close-prices", p.getCandlesMinuteClosePricesByDate)
 		apiV1.GET("/candles/from-last-date", p.getCandlesFromLastDate)
+		apiV1.GET("/candles/export", p.exportCandles)
+		apiV1.GET("/candles/quality", p.getQualityReport)
 		apiV1.POST("/candles", p.createcandles)
+		apiV1.POST("/candles/ingest", p.ingestCandles)
+		apiV1.POST("/candles/import", p.importCandles)
 		apiV1.PATCH("/candles/rsi", p.updateCandlesRSI)
+		apiV1.PUT("/candles", p.correctCandles)
+		apiV1.GET("/candles/:id/revisions", p.getCandleRevisions)
 	}
 }
 
@@ -49,6 +59,15 @@ func (p *candlesHandler) createcandles(context echo.Context) error {
 		return appErrors.NewInvalidInput("invalid input, empty candles", nil)
 	}
 
+	violations := appErrors.FieldViolations{}
+	for i, candle := range input.Candles {
+		candle.Validate(&violations, appErrors.Pointer("candles", i))
+	}
+
+	if err := violations.Err("invalid candles"); err != nil {
+		return err
+	}
+
 	candles, err := p.candlesSVC.CreateCandles(context.Request().Context(), &input.Candles)
 	if err != nil && !errors.Is(err, appErrors.ErrAlreadyExists) {
 		return fmt.Errorf("unable to create candles: %w", err)
@@ -59,14 +78,185 @@ func (p *candlesHandler) createcandles(context echo.Context) error {
 	})
 }
 
+// ingestCandles streams the body, NDJSON or CSV after its Content-Type, to the bulk ingestion
+// The body is not buffered, see streamedBodyRoutes, it stops at the first invalid line
+func (p *candlesHandler) ingestCandles(context echo.Context) error {
+	req := context.Request()
+	format, ok := domain.FormatOfMediaType(req.Header.Get(echo.HeaderContentType))
+	if !ok {
+		violations := appErrors.FieldViolations{}
+		violations.Add(echo.HeaderContentType, appErrors.ViolationInvalidValue, fmt.Sprintf("content type must be %s or %s", domain.MIMEApplicationNDJSON, domain.MIMETextCSV))
+		return violations.Err("invalid request")
+	}
+
+	reader, err := domain.NewReader(format, req.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read candles: %w", err)
+	}
+
+	result, err := p.candlesSVC.IngestCandles(req.Context(), domain.All(reader))
+	if err != nil {
+		return fmt.Errorf("unable to ingest candles, %d inserted and %d skipped before the error: %w", result.Inserted, result.Skipped, err)
+	}
+
+	status := http.StatusOK
+	if result.Inserted > 0 {
+		status = http.StatusCreated
+	}
+
+	return context.JSON(status, result)
+}
+
+// importCandles imports a Binance klines dump or a mapped CSV, a text/csv body is streamed and a ZIP one spooled to a temporary file
+func (p *candlesHandler) importCandles(context echo.Context) error {
+	req := context.Request()
+	violations := appErrors.FieldViolations{}
+	options := domain.ImportOptions{
+		Format:     domain.ImportFormat(context.QueryParam("format")),
+		Pair:       common.Pair(context.QueryParam("pair")),
+		Interval:   common.Interval(context.QueryParam("interval")),
+		DateFormat: domain.DateFormat(context.QueryParam("date_format")),
+	}
+
+	columns, err := domain.ParseColumns(context.QueryParam("columns"))
+	if err != nil {
+		violations.Add("columns", appErrors.ViolationInvalidFormat, err.Error())
+	}
+
+	options.Columns = columns
+	if delimiter := []rune(context.QueryParam("delimiter")); len(delimiter) == 1 {
+		options.Delimiter = delimiter[0]
+	} else if len(delimiter) > 1 {
+		violations.Add("delimiter", appErrors.ViolationInvalidFormat, "delimiter must be a single character")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	if mediaType != domain.MIMETextCSV && mediaType != mimeApplicationZip {
+		violations.Add(echo.HeaderContentType, appErrors.ViolationInvalidValue, fmt.Sprintf("content type must be %s or %s", domain.MIMETextCSV, mimeApplicationZip))
+	}
+
+	if err := violations.Err("invalid request"); err != nil {
+		return err
+	}
+
+	var reader domain.Reader
+	if mediaType == domain.MIMETextCSV {
+		reader, err = domain.NewImportReader(req.Body, "", options)
+	} else {
+		// The ZIP directory is at the end of the archive, it is read from a file
+		file, size, spoolErr := spoolBody(req.Body)
+		if spoolErr != nil {
+			return spoolErr
+		}
+
+		defer file.Close()
+		reader, err = domain.NewZipImportReader(file, size, options)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to read candles: %w", err)
+	}
+
+	result, err := p.candlesSVC.IngestCandles(req.Context(), domain.All(reader))
+	if err != nil {
+		return fmt.Errorf("unable to import candles, %d inserted and %d skipped before the error: %w", result.Inserted, result.Skipped, err)
+	}
+
+	status := http.StatusOK
+	if result.Inserted > 0 {
+		status = http.StatusCreated
+	}
+
+	return context.JSON(status, result)
+}
+
+const mimeApplicationZip = "application/zip"
+
+// spoolBody copies the body to a temporary file, removed at once and released when closed
+func spoolBody(body io.Reader) (*os.File, int64, error) {
+	file, err := os.CreateTemp("", "bifrost-import-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to create the temporary file: %w", err)
+	}
+
+	os.Remove(file.Name())
+	size, err := io.Copy(file, body)
+	if err != nil {
+		file.Close()
+		return nil, 0, appErrors.NewInvalidInput("unable to read the body", err)
+	}
+
+	return file, size, nil
+}
+
+// exportCandles streams the candles of the range in the format of the Accept header, oldest first
+func (p *candlesHandler) exportCandles(context echo.Context) error {
+	pair := common.Pair(context.QueryParam("pair"))
+	interval := common.Interval(context.QueryParam("interval"))
+	violations := appErrors.FieldViolations{}
+	if pair == "" {
+		violations.Add("pair", appErrors.ViolationRequired, "pair is required")
+	}
+
+	if interval == "" {
+		violations.Add("interval", appErrors.ViolationRequired, "interval is required")
+	}
+
+	startDate, endDate := queryDateRange(context, &violations)
+	if err := violations.Err("invalid input"); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("candles-%s-%s", pair, interval)
+	return streamExport(context, name, domain.ExportColumns, func(write func(domain.ExportRow) error) error {
+		return p.candlesSVC.ExportCandles(context.Request().Context(), pair, interval, startDate, endDate, func(candle domain.Candle) error {
+			return write(candle.ExportRow())
+		})
+	})
+}
+
+// defaultQualityReportLimit is the count of candles listed by a quality report, all the candles with issues are counted
+const defaultQualityReportLimit = 1000
+
+// getQualityReport checks the stored candles, pair, interval and dates are optional filters
+func (p *candlesHandler) getQualityReport(context echo.Context) error {
+	filter := domain.QualityFilter{
+		Pair:     common.Pair(context.QueryParam("pair")),
+		Interval: common.Interval(context.QueryParam("interval")),
+	}
+
+	violations := appErrors.FieldViolations{}
+	filter.StartDate, filter.EndDate = queryDateRange(context, &violations)
+	limit := defaultQualityReportLimit
+	if limitParam := context.QueryParam("limit"); limitParam != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit < 0 {
+			violations.Add("limit", appErrors.ViolationInvalidFormat, "limit must be a positive integer")
+		} else if limit == 0 {
+			limit = defaultQualityReportLimit
+		}
+	}
+
+	if err := violations.Err("invalid input"); err != nil {
+		return err
+	}
+
+	report, err := p.candlesSVC.GetQualityReport(context.Request().Context(), filter, limit)
+	if err != nil {
+		return fmt.Errorf("unable to get the candles quality report: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, report)
+}
+
 func (p *candlesHandler) getCandles(context echo.Context) error {
 	ctx := context.Request().Context()
 	pair := common.Pair(context.QueryParam("pair"))
 	interval := common.Interval(context.QueryParam("interval"))
 	var startDate *time.Time
 	startDateArg := context.QueryParam("start_date")
-	var lastDate *time.Time
-	lastDateArg := context.QueryParam("last_date")
+	var endDate *time.Time
+	endDateArg := queryParamWithAlias(context, "end_date", "last_date")
 
 	if pair == "" || interval == "" {
 		return appErrors.NewInvalidInput("invalid input, pair and interval are required", nil)
@@ -94,18 +284,18 @@ func (p *candlesHandler) getCandles(context echo.Context) error {
 		startDate = &startDateParsed
 	}
 
-	if lastDateArg == "" {
-		lastDate = nil
+	if endDateArg == "" {
+		endDate = nil
 	} else {
-		lastDateParsed, err := time.Parse(time.RFC3339, lastDateArg)
+		endDateParsed, err := time.Parse(time.RFC3339, endDateArg)
 		if err != nil {
-			return appErrors.NewInvalidInput("invalid input, last_date is required in RFC3339 format", err)
+			return appErrors.NewInvalidInput("invalid input, end_date is required in RFC3339 format", err)
 		}
 
-		lastDate = &lastDateParsed
+		endDate = &endDateParsed
 	}
 
-	candles, hasMore, nextCursor, err := p.candlesSVC.GetCandles(ctx, pair, interval, startDate, lastDate, limit)
+	candles, hasMore, nextCursor, err := p.candlesSVC.GetCandles(ctx, pair, interval, startDate, endDate, limit)
 	if err != nil {
 		return fmt.Errorf("unable to get candles: %w", err)
 	}
@@ -117,12 +307,12 @@ func (p *candlesHandler) getCandles(context echo.Context) error {
 	})
 }
 
-// GetCandlesByLastDate reverse the cursor, the next_cursor has to be used as last_date argument
+// GetCandlesByLastDate reverse the cursor, the next_cursor has to be used as end_date argument
 func (p *candlesHandler) getCandlesFromLastDate(context echo.Context) error {
 	ctx := context.Request().Context()
 	pair := common.Pair(context.QueryParam("pair"))
 	interval := common.Interval(context.QueryParam("interval"))
-	lastDateArg := context.QueryParam("last_date")
+	lastDateArg := queryParamWithAlias(context, "end_date", "last_date")
 
 	if pair == "" || interval == "" {
 		return appErrors.NewInvalidInput("invalid input, pair and interval are required", nil)
@@ -142,11 +332,11 @@ func (p *candlesHandler) getCandlesFromLastDate(context echo.Context) error {
 	}
 
 	if lastDateArg == "" {
-		return appErrors.NewInvalidInput("invalid input, last_date is required", nil)
+		return appErrors.NewInvalidInput("invalid input, end_date is required", nil)
 	} else {
 		lastDateParsed, err := time.Parse(time.RFC3339, lastDateArg)
 		if err != nil {
-			return appErrors.NewInvalidInput("invalid input, last_date is required in RFC3339 format", err)
+			return appErrors.NewInvalidInput("invalid input, end_date is required in RFC3339 format", err)
 		}
 
 		lastDate = &lastDateParsed
@@ -195,6 +385,22 @@ func (p *candlesHandler) updateCandlesRSI(context echo.Context) error {
 		return appErrors.NewInvalidInput("invalid input, empty candles", nil)
 	}
 
+	// Only the RSI of a candle is updated, its other fields are ignored
+	violations := appErrors.FieldViolations{}
+	for i, candle := range input.Candles {
+		if candle.ID == nil {
+			violations.Add(appErrors.Pointer("candles", i, "id"), appErrors.ViolationRequired, "id is required")
+		}
+
+		if candle.RSI == nil {
+			violations.Add(appErrors.Pointer("candles", i, "rsi"), appErrors.ViolationRequired, "rsi is required")
+		}
+	}
+
+	if err := violations.Err("invalid candles"); err != nil {
+		return err
+	}
+
 	candles, err := p.candlesSVC.UpdateCandlesRSI(ctx, &input.Candles)
 	if err != nil {
 		return fmt.Errorf("unable to update candles: %w", err)
@@ -205,6 +411,45 @@ func (p *candlesHandler) updateCandlesRSI(context echo.Context) error {
 	})
 }
 
+// correctCandles overwrites the prices of the candles found by pair, interval and date, the missing ones are inserted
+// The replaced prices are kept as revisions, and the positions computed with them are marked stale
+func (p *candlesHandler) correctCandles(context echo.Context) error {
+	input := new(domain.Correction)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	violations := appErrors.FieldViolations{}
+	input.Validate(&violations)
+	if err := violations.Err("invalid correction"); err != nil {
+		return err
+	}
+
+	result, err := p.candlesSVC.CorrectCandles(context.Request().Context(), *input)
+	if err != nil {
+		return fmt.Errorf("unable to correct candles: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, result)
+}
+
+func (p *candlesHandler) getCandleRevisions(context echo.Context) error {
+	id := context.Param("id")
+	idParsed, err := uuid.Parse(id)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	revisions, err := p.candlesSVC.GetCandleRevisions(context.Request().Context(), domain.ID(idParsed))
+	if err != nil {
+		return fmt.Errorf("unable to get candle revisions: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, map[string]interface{}{
+		"revisions": revisions,
+	})
+}
+
 // Return the closing price of the candle for a given pair and date using 1 minute interval data
 func (p *candlesHandler) getCandlesMinuteClosePricesByDate(context echo.Context) error {
 	ctx := context.Request().Context()
@@ -0,0 +1,355 @@
+package httpserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	domain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	positions "github.com/sopial42/bifrost/pkg/domains/positions"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+)
+
+func TestIngestCandles(t *testing.T) {
+	spec, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetOpenAPIValidationMiddleware(e, spec)
+	SetCandlesHTTPHandler(e, candlesSVC.NewCandlesService(memory.NewCandlesPersistence(memory.NewStore()), domain.ValidationReject))
+
+	header := "date,pair,interval,open,high,low,close\n"
+	rows := "2024-01-01T00:00:00Z,SOLUSDC,1m,1,3,0.5,2\n2024-01-01T00:01:00Z,SOLUSDC,1m,2,2,2,2\n"
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantResult  domain.IngestResult
+		wantField   string
+	}{
+		{name: "csv", contentType: domain.MIMETextCSV, body: header + rows, wantStatus: http.StatusCreated, wantResult: domain.IngestResult{Inserted: 2}},
+		{name: "existing candles", contentType: domain.MIMETextCSV + "; charset=utf-8", body: header + rows, wantStatus: http.StatusOK, wantResult: domain.IngestResult{Skipped: 2}},
+		{name: "invalid line", contentType: domain.MIMETextCSV, body: header + "2024-01-01T00:02:00Z,SOLUSDC,7h,1,3,0.5,2\n", wantStatus: http.StatusBadRequest, wantField: "/2/interval"},
+		{name: "unknown content type", contentType: echo.MIMEApplicationJSON, body: `{"candles": []}`, wantStatus: http.StatusBadRequest, wantField: echo.HeaderContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/candles/ingest", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+
+			if tt.wantField != "" {
+				var res appErrors.ErrResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || len(res.Error.Violations) == 0 || res.Error.Violations[0].Field != tt.wantField {
+					t.Errorf("body = %s, want a violation of %s", rec.Body.String(), tt.wantField)
+				}
+
+				return
+			}
+
+			var res domain.IngestResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || res != tt.wantResult {
+				t.Errorf("body = %s, want %+v", rec.Body.String(), tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestImportCandles(t *testing.T) {
+	spec, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetOpenAPIValidationMiddleware(e, spec)
+	SetCandlesHTTPHandler(e, candlesSVC.NewCandlesService(memory.NewCandlesPersistence(memory.NewStore()), domain.ValidationReject))
+
+	var dump bytes.Buffer
+	archive := zip.NewWriter(&dump)
+	w, _ := archive.Create("ETHUSDC-1h-2024-01-01.csv")
+	w.Write([]byte("1704067200000,1,3,0.5,2,10,1704070799999\n1704070800000,2,2,2,2,10,1704074399999\n"))
+	archive.Close()
+
+	klines := "1704067200000,1,3,0.5,2,10\n"
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		wantStatus  int
+		wantResult  domain.IngestResult
+		wantField   string
+	}{
+		{name: "binance csv", query: "format=binance&pair=SOLUSDC&interval=1m", contentType: domain.MIMETextCSV, body: klines, wantStatus: http.StatusCreated, wantResult: domain.IngestResult{Inserted: 1}},
+		{name: "existing candles", query: "format=binance&pair=SOLUSDC&interval=1m", contentType: domain.MIMETextCSV, body: klines, wantStatus: http.StatusOK, wantResult: domain.IngestResult{Skipped: 1}},
+		{name: "binance zip", query: "format=binance", contentType: mimeApplicationZip, body: dump.String(), wantStatus: http.StatusCreated, wantResult: domain.IngestResult{Inserted: 2}},
+		{name: "mapped csv", query: "format=csv&interval=5m&columns=date%3Dtime,pair%3Dsymbol&delimiter=%3B", contentType: domain.MIMETextCSV, body: "time;symbol;open;high;low;close\n2024-01-01T00:00:00Z;sol/usdc;1;3;0.5;2\n", wantStatus: http.StatusCreated, wantResult: domain.IngestResult{Inserted: 1}},
+		{name: "binance without pair", query: "format=binance&interval=1m", contentType: domain.MIMETextCSV, body: klines, wantStatus: http.StatusBadRequest, wantField: "pair"},
+		{name: "inconsistent prices", query: "format=binance&pair=SOLUSDC&interval=1m", contentType: domain.MIMETextCSV, body: "1704067260000,1,3,1.5,2,10\n", wantStatus: http.StatusBadRequest, wantField: "/candles/0/low"},
+		{name: "invalid columns", query: "format=csv&columns=volume%3Dv", contentType: domain.MIMETextCSV, body: klines, wantStatus: http.StatusBadRequest, wantField: "columns"},
+		{name: "unknown content type", query: "format=csv", contentType: echo.MIMEApplicationJSON, body: `{}`, wantStatus: http.StatusBadRequest, wantField: echo.HeaderContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/candles/import?"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+
+			if tt.wantField != "" {
+				var res appErrors.ErrResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || len(res.Error.Violations) == 0 || res.Error.Violations[0].Field != tt.wantField {
+					t.Errorf("body = %s, want a violation of %s", rec.Body.String(), tt.wantField)
+				}
+
+				return
+			}
+
+			var res domain.IngestResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || res != tt.wantResult {
+				t.Errorf("body = %s, want %+v", rec.Body.String(), tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestCandlesValidation(t *testing.T) {
+	spec, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+
+	// The candle of the store predates the validation, the quality report checks it again
+	store := memory.NewStore()
+	if _, err := memory.NewCandlesPersistence(store).InsertCandles(context.Background(), &[]domain.Candle{
+		{Date: domain.Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.ETHUSDC, Interval: common.H1, Open: 1, High: 0.5, Low: 1, Close: 1},
+	}); err != nil {
+		t.Fatalf("InsertCandles() error = %v", err)
+	}
+
+	newEngine := func(validation domain.Validation) *echo.Echo {
+		e := echo.New()
+		appErrors.SetCustomErrorHandler(e)
+		SetOpenAPIValidationMiddleware(e, spec)
+		SetCandlesHTTPHandler(e, candlesSVC.NewCandlesService(memory.NewCandlesPersistence(store), validation))
+		return e
+	}
+
+	serve := func(e *echo.Echo, method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"candles": [
+		{"date": "2024-01-01T00:00:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 2, "high": 3, "low": 1, "close": 4},
+		{"date": "2024-01-01T00:01:30Z", "pair": "SOLUSDC", "interval": "1m", "open": 2, "high": 3, "low": 1, "close": 2}
+	]}`
+
+	t.Run("reject", func(t *testing.T) {
+		rec := serve(newEngine(domain.ValidationReject), http.MethodPost, "/api/v1/candles", body)
+		var res appErrors.ErrResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusBadRequest || err != nil || len(res.Error.Violations) != 2 {
+			t.Fatalf("response = %d %s, want the high and date violations", rec.Code, rec.Body.String())
+		}
+
+		if res.Error.Violations[0].Field != "/candles/0/high" || res.Error.Violations[1].Field != "/candles/1/date" {
+			t.Errorf("violations = %+v", res.Error.Violations)
+		}
+	})
+
+	e := newEngine(domain.ValidationFlag)
+	t.Run("flag", func(t *testing.T) {
+		rec := serve(e, http.MethodPost, "/api/v1/candles", body)
+		var res struct {
+			Candles []domain.Candle `json:"candles"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusCreated || err != nil || len(res.Candles) != 2 {
+			t.Fatalf("response = %d %s, want the 2 candles", rec.Code, rec.Body.String())
+		}
+
+		if !slices.Equal(res.Candles[0].Issues, []domain.Issue{domain.IssueOutOfRange}) || !slices.Equal(res.Candles[1].Issues, []domain.Issue{domain.IssueMisalignedDate}) {
+			t.Errorf("candles = %+v, want them flagged", res.Candles)
+		}
+	})
+
+	t.Run("read only issues", func(t *testing.T) {
+		rec := serve(e, http.MethodPost, "/api/v1/candles", `{"candles": [{"date": "2024-01-02T00:00:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 1, "high": 1, "low": 1, "close": 1, "issues": ["out_of_range"]}]}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("response = %d %s, want 400", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("quality report", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/api/v1/candles/quality?limit=2", "")
+		var res domain.QualityReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusOK || err != nil {
+			t.Fatalf("response = %d %s", rec.Code, rec.Body.String())
+		}
+
+		if res.Count != 3 || len(res.Candles) != 2 || !res.Truncated || res.Issues[domain.IssueHighBelowLow] != 1 || res.Candles[0].Pair != common.ETHUSDC {
+			t.Errorf("report = %+v, want the 3 candles with issues and 2 listed", res)
+		}
+
+		rec = serve(e, http.MethodGet, "/api/v1/candles/quality?pair=SOLUSDC&start_date=2024-01-01T00:01:00Z", "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || res.Count != 1 || res.Truncated || res.Candles[0].Issues[0] != domain.IssueMisalignedDate {
+			t.Errorf("response = %d %s, want the misaligned candle", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("flagged import", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/candles/import?format=binance&pair=SOLUSDC&interval=1m", strings.NewReader("1704153600000,1,3,1.5,2,10\n"))
+		req.Header.Set(echo.HeaderContentType, domain.MIMETextCSV)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var res domain.IngestResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusCreated || err != nil || res.Inserted != 1 || res.Flagged != 1 {
+			t.Errorf("response = %d %s, want the inconsistent candle stored flagged", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestCorrectCandles(t *testing.T) {
+	spec, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+
+	// The position of the store hit its ratio on the second candle
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stored, err := memory.NewCandlesPersistence(store).InsertCandles(ctx, &[]domain.Candle{
+		{Date: domain.Date(day), Pair: common.SOLUSDC, Interval: common.M1, Open: 100, High: 101, Low: 99, Close: 100},
+		{Date: domain.Date(day.Add(time.Minute)), Pair: common.SOLUSDC, Interval: common.M1, Open: 100, High: 103, Low: 100, Close: 102},
+	})
+	if err != nil {
+		t.Fatalf("InsertCandles() error = %v", err)
+	}
+
+	buySignals, _, err := memory.NewBuySignalsPersistence(store).InsertBuySignals(ctx, &[]bsDomain.Details{
+		{Name: bsDomain.MorningStarName, BusinessID: "1", Fullname: "morningStar", Pair: common.SOLUSDC, Interval: common.H1, Date: bsDomain.Date(day), Price: 100},
+	})
+	if err != nil {
+		t.Fatalf("InsertBuySignals() error = %v", err)
+	}
+
+	positionsPersistence := memory.NewPositionsPersistence(store)
+	inserted, _, err := positionsPersistence.InsertPositions(ctx, &[]positions.Details{
+		{Name: positions.PercentageName, Fullname: "percentage_tp2", BuySignalID: *(*buySignals)[0].ID, TP: 102, SL: 98, Ratio: &positions.Ratio{Value: 2, Date: domain.Date(day.Add(time.Minute))}},
+	})
+	if err != nil {
+		t.Fatalf("InsertPositions() error = %v", err)
+	}
+
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetOpenAPIValidationMiddleware(e, spec)
+	SetCandlesHTTPHandler(e, candlesSVC.NewCandlesService(memory.NewCandlesPersistence(store), domain.ValidationReject))
+
+	serve := func(method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("correct", func(t *testing.T) {
+		rec := serve(http.MethodPut, "/api/v1/candles", `{"source": "binance-vision", "candles": [
+			{"date": "2024-01-01T00:00:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 100, "high": 101, "low": 99, "close": 100},
+			{"date": "2024-01-01T00:01:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 100, "high": 101, "low": 100, "close": 101},
+			{"date": "2024-01-01T00:02:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 101, "high": 101, "low": 101, "close": 101}
+		]}`)
+		var res domain.CorrectionResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusOK || err != nil {
+			t.Fatalf("response = %d %s", rec.Code, rec.Body.String())
+		}
+
+		if res.Inserted != 1 || res.Corrected != 1 || res.Unchanged != 1 || res.StalePositions != 1 || len(res.Candles) != 2 {
+			t.Fatalf("result = %+v, want 1 inserted, 1 corrected, 1 unchanged and the position stale", res)
+		}
+
+		if *res.Candles[0].ID != *(*stored)[1].ID || res.Candles[0].High != 101 {
+			t.Errorf("corrected candle = %+v, want the stored one with its new prices", res.Candles[0])
+		}
+
+		position, err := positionsPersistence.GetPositionByID(ctx, *(*inserted)[0].ID)
+		if err != nil || !position.Stale || position.Ratio == nil {
+			t.Errorf("position = %+v, error = %v, want it stale with its ratio", position, err)
+		}
+	})
+
+	t.Run("revisions", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/candles/"+uuid.UUID(*(*stored)[1].ID).String()+"/revisions", "")
+		var res struct {
+			Revisions []domain.Revision `json:"revisions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusOK || err != nil || len(res.Revisions) != 1 {
+			t.Fatalf("response = %d %s, want 1 revision", rec.Code, rec.Body.String())
+		}
+
+		if revision := res.Revisions[0]; revision.High != 103 || revision.Close != 102 || revision.Source != "binance-vision" || revision.RevisedAt.IsZero() {
+			t.Errorf("revision = %+v, want the replaced prices", revision)
+		}
+
+		rec = serve(http.MethodGet, "/api/v1/candles/"+uuid.UUID(*(*stored)[0].ID).String()+"/revisions", "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusOK || err != nil || len(res.Revisions) != 0 {
+			t.Errorf("response = %d %s, want no revision for the unchanged candle", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("unknown candle", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/candles/"+uuid.NewString()+"/revisions", "")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("response = %d %s, want 404", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid correction", func(t *testing.T) {
+		rec := serve(http.MethodPut, "/api/v1/candles", `{"candles": [{"date": "2024-01-01T00:00:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 2, "high": 1, "low": 1, "close": 1}]}`)
+		var res appErrors.ErrResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusBadRequest || err != nil || len(res.Error.Violations) != 1 || res.Error.Violations[0].Field != "/source" {
+			t.Fatalf("response = %d %s, want the source violation", rec.Code, rec.Body.String())
+		}
+
+		rec = serve(http.MethodPut, "/api/v1/candles", `{"source": "manual", "candles": [{"date": "2024-01-01T00:00:00Z", "pair": "SOLUSDC", "interval": "1m", "open": 2, "high": 1, "low": 1, "close": 1}]}`)
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusBadRequest || err != nil || len(res.Error.Violations) != 1 || res.Error.Violations[0].Field != "/candles/0/high" {
+			t.Errorf("response = %d %s, want the high violation", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	domain "github.com/sopial42/bifrost/pkg/domains/experiments"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	experimentsSVC "github.com/sopial42/bifrost/pkg/services/experiments"
+)
+
+type experimentsHandler struct {
+	experimentsSVC experimentsSVC.Service
+}
+
+func SetExperimentsHTTPHandler(e *echo.Echo, service experimentsSVC.Service) {
+	p := &experimentsHandler{
+		experimentsSVC: service,
+	}
+
+	apiV1 := e.Group("/api/v1")
+	{
+		apiV1.POST("/experiments", p.runExperiment)
+		apiV1.GET("/experiments/:id", p.getExperiment)
+	}
+}
+
+type RunExperimentInput struct {
+	Pairs           []string                               `json:"pairs"`
+	Intervals       []string                               `json:"intervals"`
+	BuySignalNames  []string                               `json:"buy_signal_names"`
+	PositionNames   []string                               `json:"position_names"`
+	BuySignalParams map[buySignals.Name]domain.ParamRanges `json:"buy_signal_params"`
+	PositionParams  map[positions.Name]domain.ParamRanges  `json:"position_params"`
+	WinlossRatios   []positions.WinLossRatio               `json:"winloss_ratios"`
+	StartDate       *time.Time                             `json:"start_date"`
+	EndDate         *time.Time                             `json:"end_date"`
+}
+
+// runExperiment starts the scenario grid in the background, the experiment is polled until done or failed
+func (p *experimentsHandler) runExperiment(context echo.Context) error {
+	input := new(RunExperimentInput)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
+
+	pairs, err := common.ParsePairs(input.Pairs)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid pairs", err)
+	}
+
+	intervals, err := common.ParseIntervals(input.Intervals)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid intervals", err)
+	}
+
+	buySignalNames, err := buySignals.ParseSignalStrategies(input.BuySignalNames)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid buy_signal_names", err)
+	}
+
+	positionNames, err := positions.ParseSignalStrategies(input.PositionNames)
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid position_names", err)
+	}
+
+	if input.StartDate != nil && input.EndDate != nil && input.EndDate.Before(*input.StartDate) {
+		return appErrors.NewInvalidInput("invalid input, end_date should be after start_date", nil)
+	}
+
+	experiment, err := p.experimentsSVC.RunExperiment(context.Request().Context(), domain.Request{
+		Pairs:           pairs,
+		Intervals:       intervals,
+		BuySignalNames:  buySignalNames,
+		PositionNames:   positionNames,
+		BuySignalParams: input.BuySignalParams,
+		PositionParams:  input.PositionParams,
+		WinLossRatios:   input.WinlossRatios,
+		StartDate:       input.StartDate,
+		EndDate:         input.EndDate,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to run experiment: %w", err)
+	}
+
+	return context.JSON(http.StatusAccepted, map[string]any{
+		"experiment": experiment,
+	})
+}
+
+func (p *experimentsHandler) getExperiment(context echo.Context) error {
+	id, err := uuid.Parse(context.Param("id"))
+	if err != nil {
+		return appErrors.NewInvalidInput("invalid id", err)
+	}
+
+	experiment, err := p.experimentsSVC.GetExperiment(context.Request().Context(), domain.ID(id))
+	if err != nil {
+		return fmt.Errorf("unable to get experiment: %w", err)
+	}
+
+	return context.JSON(http.StatusOK, map[string]any{
+		"experiment": experiment,
+	})
+}
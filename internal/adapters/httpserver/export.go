@@ -0,0 +1,113 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/domains/export"
+)
+
+// exportFlushRows is the count of rows written to the client at once, a Parquet flush ends a row group so its groups are larger
+const (
+	exportFlushRows        = 1000
+	exportParquetFlushRows = 100000
+)
+
+// streamExport streams the rows written by run in the format of the Accept header, flushed to the client as they go
+// An error before the first flush is an error response, after it the connection is aborted so the client does not take a truncated export for a complete one
+func streamExport[T export.Row](c echo.Context, name string, columns []string, run func(write func(T) error) error) error {
+	format, ok := export.Negotiate(c.Request().Header.Get(echo.HeaderAccept))
+	if !ok {
+		mediaTypes := make([]string, len(export.Formats))
+		for i, f := range export.Formats {
+			mediaTypes[i] = f.MediaType
+		}
+
+		return echo.NewHTTPError(http.StatusNotAcceptable, fmt.Sprintf("the export media types are %s", strings.Join(mediaTypes, ", ")))
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.MediaType())
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+"."+string(format)))
+	writer, err := export.NewWriter[T](format, res, columns)
+	if err != nil {
+		return abortExport(c, err)
+	}
+
+	flushRows := exportFlushRows
+	if format == export.FormatParquet {
+		flushRows = exportParquetFlushRows
+	}
+
+	count := 0
+	err = run(func(row T) error {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("unable to write the export: %w", err)
+		}
+
+		if count++; count%flushRows != 0 {
+			return nil
+		}
+
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("unable to write the export: %w", err)
+		}
+
+		res.Flush()
+		return nil
+	})
+	if err != nil {
+		return abortExport(c, err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return abortExport(c, fmt.Errorf("unable to write the export: %w", err))
+	}
+
+	if !res.Committed {
+		res.WriteHeader(http.StatusOK)
+	}
+
+	return nil
+}
+
+// abortExport returns the error while the response is not committed, the export headers are reset for the error body
+// Once committed it logs the error and aborts the connection
+func abortExport(c echo.Context, err error) error {
+	res := c.Response()
+	if !res.Committed {
+		res.Header().Del(echo.HeaderContentType)
+		res.Header().Del(echo.HeaderContentDisposition)
+		return err
+	}
+
+	logger.GetLogger(c.Request().Context()).Errorf("Export aborted after %d bytes: %v", res.Size, err)
+	panic(http.ErrAbortHandler)
+}
+
+// queryDateRange adds the violations of the optional start_date and end_date RFC3339 query parameters
+func queryDateRange(c echo.Context, violations *appErrors.FieldViolations) (*time.Time, *time.Time) {
+	dates := [2]*time.Time{}
+	for i, name := range []string{"start_date", "end_date"} {
+		value := c.QueryParam(name)
+		if value == "" {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			violations.Add(name, appErrors.ViolationInvalidFormat, fmt.Sprintf("%s must be a RFC3339 date", name))
+			continue
+		}
+
+		dates[i] = &date
+	}
+
+	return dates[0], dates[1]
+}
@@ -0,0 +1,179 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	candles "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/export"
+	positions "github.com/sopial42/bifrost/pkg/domains/positions"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+// newExportEngine serves the exports of a store with two candles, and two buy signals of which one has a position
+func newExportEngine(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := memory.NewCandlesPersistence(store).InsertCandles(ctx, &[]candles.Candle{
+		{Date: candles.Date(day), Pair: common.SOLUSDC, Interval: common.M1, Open: 1, High: 3, Low: 0.5, Close: 2},
+		{Date: candles.Date(day.Add(time.Minute)), Pair: common.SOLUSDC, Interval: common.M1, Open: 2, High: 2, Low: 2, Close: 2},
+	}); err != nil {
+		t.Fatalf("InsertCandles() error = %v", err)
+	}
+
+	buySignals, _, err := memory.NewBuySignalsPersistence(store).InsertBuySignals(ctx, &[]bsDomain.Details{
+		{Name: bsDomain.MorningStarName, BusinessID: "1", Fullname: "morningStar", Pair: common.SOLUSDC, Interval: common.H1, Date: bsDomain.Date(day), Price: 100, Metadata: bsDomain.Metadata{"k": 1}},
+		{Name: bsDomain.MorningStarName, BusinessID: "2", Fullname: "morningStar", Pair: common.SOLUSDC, Interval: common.H1, Date: bsDomain.Date(day.Add(time.Hour)), Price: 110},
+	})
+	if err != nil || len(*buySignals) != 2 {
+		t.Fatalf("InsertBuySignals() = %v, error = %v", buySignals, err)
+	}
+
+	positionsPersistence := memory.NewPositionsPersistence(store)
+	if _, _, err := positionsPersistence.InsertPositions(ctx, &[]positions.Details{
+		{Name: positions.PercentageName, Fullname: "percentage_tp2", BuySignalID: *(*buySignals)[0].ID, TP: 102, SL: 98, Ratio: &positions.Ratio{Value: 2, Date: candles.Date(day.Add(2 * time.Hour))}},
+	}); err != nil {
+		t.Fatalf("InsertPositions() error = %v", err)
+	}
+
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetCandlesHTTPHandler(e, candlesSVC.NewCandlesService(memory.NewCandlesPersistence(store), candles.ValidationReject))
+	SetPositionsHTTPHandler(e, positionsSVC.NewPositionsService(positionsPersistence, nil, nil))
+	return e
+}
+
+func getExport(e *echo.Echo, target string, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set(echo.HeaderAccept, accept)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestExportCandles(t *testing.T) {
+	e := newExportEngine(t)
+	target := "/api/v1/candles/export?pair=SOLUSDC&interval=1m"
+
+	t.Run("ndjson by default", func(t *testing.T) {
+		rec := getExport(e, target, "")
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if rec.Code != http.StatusOK || rec.Header().Get(echo.HeaderContentType) != export.MIMEApplicationNDJSON || len(lines) != 2 {
+			t.Fatalf("response = %d %v %q, want 2 NDJSON lines", rec.Code, rec.Header(), rec.Body.String())
+		}
+
+		if disposition := rec.Header().Get(echo.HeaderContentDisposition); disposition != `attachment; filename="candles-SOLUSDC-1m.ndjson"` {
+			t.Errorf("Content-Disposition = %s", disposition)
+		}
+
+		// The NDJSON export is a valid ingestion stream
+		reader, _ := candles.NewReader(candles.FormatNDJSON, strings.NewReader(rec.Body.String()))
+		if candle, err := reader.Read(); err != nil || candle.High != 3 {
+			t.Errorf("first candle = %+v, error = %v", candle, err)
+		}
+	})
+
+	t.Run("csv in range", func(t *testing.T) {
+		rec := getExport(e, target+"&start_date=2024-01-01T00:01:00Z", "text/csv")
+		want := "date,pair,interval,open,high,low,close\n2024-01-01T00:01:00Z,SOLUSDC,1m,2,2,2,2\n"
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("response = %d %q, want %q", rec.Code, rec.Body.String(), want)
+		}
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		rec := getExport(e, target, export.MIMEApplicationParquet)
+		rows, err := parquet.Read[candles.ExportRow](bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if rec.Code != http.StatusOK || err != nil || len(rows) != 2 || rows[0].Low != 0.5 || rows[1].Pair != "SOLUSDC" {
+			t.Errorf("response = %d, rows = %+v, error = %v", rec.Code, rows, err)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		rec := getExport(e, "/api/v1/candles/export?pair=BTCUSDC&interval=1m", "text/csv")
+		if rec.Code != http.StatusOK || rec.Body.String() != "date,pair,interval,open,high,low,close\n" {
+			t.Errorf("response = %d %q, want the header only", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		rec := getExport(e, target, echo.MIMEApplicationJSON)
+		if rec.Code != http.StatusNotAcceptable || rec.Header().Get(echo.HeaderContentDisposition) != "" {
+			t.Errorf("response = %d %v, want 406 without attachment", rec.Code, rec.Header())
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		rec := getExport(e, "/api/v1/candles/export?pair=SOLUSDC&start_date=yesterday", "")
+		var res appErrors.ErrResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); rec.Code != http.StatusBadRequest || err != nil || len(res.Error.Violations) != 2 {
+			t.Fatalf("response = %d %s, want the interval and start_date violations", rec.Code, rec.Body.String())
+		}
+
+		if res.Error.Violations[0].Field != "interval" || res.Error.Violations[1].Field != "start_date" {
+			t.Errorf("violations = %+v", res.Error.Violations)
+		}
+	})
+}
+
+func TestExportBuySignalsPositions(t *testing.T) {
+	e := newExportEngine(t)
+
+	t.Run("parquet", func(t *testing.T) {
+		rec := getExport(e, "/api/v1/positions/export", export.MIMEApplicationParquet)
+		rows, err := parquet.Read[positions.BuySignalPosition](bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if rec.Code != http.StatusOK || err != nil || len(rows) != 2 {
+			t.Fatalf("response = %d, rows = %+v, error = %v", rec.Code, rows, err)
+		}
+
+		first, second := rows[0], rows[1]
+		if first.PositionFullname == nil || *first.PositionFullname != "percentage_tp2" || first.RatioValue == nil || *first.RatioValue != 2 ||
+			first.RatioDate == nil || first.RatioDate.Hour() != 2 || string(first.BuyMetadata) != `{"k":1}` {
+			t.Errorf("first row = %+v, want the buy signal with its position", first)
+		}
+
+		if second.BuyPrice != 110 || second.PositionFullname != nil || second.TP != nil || second.PositionID != nil || string(second.PositionMetadata) != "null" {
+			t.Errorf("second row = %+v, want the buy signal without position", second)
+		}
+	})
+
+	t.Run("csv of a position fullname", func(t *testing.T) {
+		rec := getExport(e, "/api/v1/positions/export?pair=SOLUSDC&position_fullname=percentage_tp2", "text/csv")
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if rec.Code != http.StatusOK || len(lines) != 2 || lines[0] != strings.Join(positions.BuySignalPositionColumns, ",") {
+			t.Fatalf("response = %d %q, want the header and a row", rec.Code, rec.Body.String())
+		}
+
+		if !strings.HasPrefix(lines[1], "SOLUSDC,1h,morningStar,2024-01-01T00:00:00Z,100,percentage_tp2,102,98,2,2024-01-01T02:00:00Z,") {
+			t.Errorf("row = %s", lines[1])
+		}
+	})
+
+	t.Run("ndjson in range", func(t *testing.T) {
+		rec := getExport(e, "/api/v1/positions/export?start_date=2024-01-01T01:00:00Z", "")
+		var row map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &row); rec.Code != http.StatusOK || err != nil || row["buy_price"] != 110.0 || row["tp"] != nil {
+			t.Errorf("response = %d %s, want the buy signal without position", rec.Code, rec.Body.String())
+		}
+	})
+}
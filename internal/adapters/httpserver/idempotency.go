@@ -0,0 +1,110 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+	idempotencySVC "github.com/sopial42/bifrost/pkg/services/idempotency"
+)
+
+// idempotentMethods are the write methods accepting an Idempotency-Key header
+var idempotentMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// idempotencyRecorder copies the response body, to store it for the replays
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// SetIdempotencyMiddleware replays the first response of a write request with an Idempotency-Key header to its retries
+// The key is scoped to the workspace of the request, so the middleware is set after the authentication one
+// Server errors are not stored, the request can be retried with the same key
+// The streamed bodies are not fingerprinted, their routes are idempotent by themselves, eg. the candles ingestion skips the existing ones
+func SetIdempotencyMiddleware(e *echo.Echo, service idempotencySVC.Service) {
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			header := req.Header.Get(domain.HeaderIdempotencyKey)
+			if header == "" || !idempotentMethods[req.Method] || !strings.HasPrefix(c.Path(), apiV1Prefix+"/") || streamedBodyRoutes[c.Path()] {
+				return next(c)
+			}
+
+			key, err := domain.ParseKey(header)
+			if err != nil {
+				violations := appErrors.FieldViolations{}
+				violations.Add(domain.HeaderIdempotencyKey, appErrors.ViolationInvalidFormat, err.Error())
+				return violations.Err("invalid request")
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return appErrors.NewInvalidInput("unable to read the request body", err)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := req.Context()
+			record, reserved, err := service.Begin(ctx, key, domain.Fingerprint(req.Method, req.URL.RequestURI(), body))
+			if err != nil {
+				return err
+			}
+
+			if !reserved {
+				c.Response().Header().Set(domain.HeaderIdempotentReplayed, "true")
+				return c.Blob(record.Status, record.ContentType, record.Body)
+			}
+
+			// The response is stored or the key released even if the client is gone, eg. after a timeout, as it will retry
+			ctx = context.WithoutCancel(ctx)
+			log := logger.GetLogger(ctx).WithField(domain.LoggerKeyIdempotencyKey, key)
+			stored := false
+			defer func() {
+				// Also reached on a panic, the recover middleware then answers a server error
+				if stored {
+					return
+				}
+
+				if err := service.Release(ctx, record); err != nil {
+					log.Warnf("unable to release the idempotency key: %v", err)
+				}
+			}()
+
+			recorder := &idempotencyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			// The error is rendered here, so the stored response is the one sent
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			res := c.Response()
+			if res.Status >= http.StatusInternalServerError {
+				return nil
+			}
+
+			if err := service.Complete(ctx, record, res.Status, res.Header().Get(echo.HeaderContentType), recorder.body.Bytes()); err != nil {
+				log.Warnf("unable to store the response of the idempotency key: %v", err)
+				return nil
+			}
+
+			stored = true
+			return nil
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+	idempotencySVC "github.com/sopial42/bifrost/pkg/services/idempotency"
+)
+
+// newIdempotencyEngine counts the calls of its handlers, the failing one answers a server error until fixed
+// The first call of the outliving one is retried while in progress, ie. once its lock expired for a short lockTimeout
+func newIdempotencyEngine(calls *int, failing *bool, lockTimeout time.Duration) *echo.Echo {
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetIdempotencyMiddleware(e, idempotencySVC.NewIdempotencyService(memory.NewIdempotencyPersistence(memory.NewStore()), time.Hour, lockTimeout))
+
+	e.POST(apiV1Prefix+"/items", func(c echo.Context) error {
+		*calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": *calls})
+	})
+	e.POST(apiV1Prefix+"/failing", func(c echo.Context) error {
+		*calls++
+		if *failing {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failing")
+		}
+
+		return c.NoContent(http.StatusOK)
+	})
+	e.POST(apiV1Prefix+"/outliving", func(c echo.Context) error {
+		*calls++
+		call := *calls
+		if call == 1 {
+			postIdempotent(e, apiV1Prefix+"/outliving", c.Request().Header.Get(domain.HeaderIdempotencyKey), `{}`)
+		}
+
+		return c.JSON(http.StatusCreated, map[string]int{"call": call})
+	})
+
+	return e
+}
+
+func postIdempotent(e *echo.Echo, target string, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(domain.HeaderIdempotencyKey, key)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddlewareReplays(t *testing.T) {
+	calls, failing := 0, false
+	e := newIdempotencyEngine(&calls, &failing, time.Minute)
+
+	first := postIdempotent(e, "/api/v1/items", "key-1", `{"a": 1}`)
+	if first.Code != http.StatusCreated || first.Header().Get(domain.HeaderIdempotentReplayed) != "" {
+		t.Fatalf("first response = %d %v, want a 201 not replayed", first.Code, first.Header())
+	}
+
+	replay := postIdempotent(e, "/api/v1/items", "key-1", `{"a": 1}`)
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() || replay.Header().Get(domain.HeaderIdempotentReplayed) != "true" {
+		t.Errorf("replay = %d %s %v, want the first response replayed", replay.Code, replay.Body.String(), replay.Header())
+	}
+
+	if replay.Header().Get(echo.HeaderContentType) != first.Header().Get(echo.HeaderContentType) {
+		t.Errorf("replay content type = %s, want %s", replay.Header().Get(echo.HeaderContentType), first.Header().Get(echo.HeaderContentType))
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want the handler called once", calls)
+	}
+
+	// Another key, and no key at all, are new requests
+	postIdempotent(e, "/api/v1/items", "key-2", `{"a": 1}`)
+	postIdempotent(e, "/api/v1/items", "", `{"a": 1}`)
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareRejects(t *testing.T) {
+	calls, failing := 0, false
+	e := newIdempotencyEngine(&calls, &failing, time.Minute)
+
+	postIdempotent(e, "/api/v1/items", "key-1", `{"a": 1}`)
+	tests := []struct {
+		name   string
+		target string
+		key    string
+		body   string
+	}{
+		{name: "another body", target: "/api/v1/items", key: "key-1", body: `{"a": 2}`},
+		{name: "another route", target: "/api/v1/failing", key: "key-1", body: `{"a": 1}`},
+		{name: "invalid key", target: "/api/v1/items", key: "key\x01", body: `{"a": 1}`},
+		{name: "too long key", target: "/api/v1/items", key: strings.Repeat("k", 256), body: `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postIdempotent(e, tt.target, tt.key, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d %s, want 400", rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want the rejected requests not handled", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareServerErrorNotStored(t *testing.T) {
+	calls, failing := 0, true
+	e := newIdempotencyEngine(&calls, &failing, time.Minute)
+
+	if rec := postIdempotent(e, "/api/v1/failing", "key-1", `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+
+	// The key is released, the retry is handled
+	failing = false
+	rec := postIdempotent(e, "/api/v1/failing", "key-1", `{}`)
+	if rec.Code != http.StatusOK || rec.Header().Get(domain.HeaderIdempotentReplayed) != "" || calls != 2 {
+		t.Errorf("retry = %d %v after %d calls, want a handled 200", rec.Code, rec.Header(), calls)
+	}
+}
+
+func TestIdempotencyMiddlewareOutlivedLock(t *testing.T) {
+	calls, failing := 0, false
+	e := newIdempotencyEngine(&calls, &failing, time.Nanosecond)
+
+	if rec := postIdempotent(e, "/api/v1/outliving", "key-1", `{}`); rec.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("first = %d after %d calls, want a 201 retried once in progress", rec.Code, calls)
+	}
+
+	// The first request completed after its retry, the retry response is the stored one
+	rec := postIdempotent(e, "/api/v1/outliving", "key-1", `{}`)
+	if rec.Header().Get(domain.HeaderIdempotentReplayed) != "true" || !strings.Contains(rec.Body.String(), `"call":2`) || calls != 2 {
+		t.Errorf("replay = %s %v after %d calls, want the retry response replayed", rec.Body.String(), rec.Header(), calls)
+	}
+}
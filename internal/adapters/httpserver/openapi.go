@@ -0,0 +1,197 @@
+package httpserver
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+)
+
+// OpenAPIRoute serves the OpenAPI document, it is not authenticated
+const OpenAPIRoute = apiV1Prefix + "/openapi.json"
+
+//go:embed openapi.yaml
+var openAPIYAML []byte
+
+// streamedBodyRoutes are echo paths whose handler reads the body as a stream, the middlewares must not buffer it
+var streamedBodyRoutes = map[string]bool{
+	apiV1Prefix + "/candles/ingest": true,
+	apiV1Prefix + "/candles/import": true,
+}
+
+// LoadOpenAPI parses and validates the OpenAPI document of the API
+func LoadOpenAPI() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(openAPIYAML)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the openapi document: %w", err)
+	}
+
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	return spec, nil
+}
+
+// SetOpenAPIHTTPHandler serves the OpenAPI document as JSON
+func SetOpenAPIHTTPHandler(e *echo.Echo, spec *openapi3.T) {
+	e.GET(OpenAPIRoute, func(context echo.Context) error {
+		return context.JSON(http.StatusOK, spec)
+	})
+}
+
+// SetOpenAPIValidationMiddleware validates the API requests parameters and bodies against the OpenAPI document
+// The authentication is left to the auth middleware, the responses are not validated
+func SetOpenAPIValidationMiddleware(e *echo.Echo, spec *openapi3.T) {
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+	streamOptions := *options
+	streamOptions.ExcludeRequestBody = true
+
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !strings.HasPrefix(c.Path(), apiV1Prefix+"/") {
+				return next(c)
+			}
+
+			// Unknown routes are answered by echo, TestOpenAPIRoutes ensures the registered ones are documented
+			route, pathParams, ok := findOpenAPIRoute(spec, c)
+			if !ok {
+				return next(c)
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    c.Request(),
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if streamedBodyRoutes[c.Path()] {
+				input.Options = &streamOptions
+			}
+
+			if err := openapi3filter.ValidateRequest(c.Request().Context(), input); err != nil {
+				return openAPIViolations(err, "").Err("invalid request")
+			}
+
+			return next(c)
+		}
+	})
+}
+
+// findOpenAPIRoute returns the operation of the echo route, echo has already matched the path
+func findOpenAPIRoute(spec *openapi3.T, c echo.Context) (*routers.Route, map[string]string, bool) {
+	path := openAPIPath(c.Path())
+	pathItem := spec.Paths.Find(path)
+	if pathItem == nil {
+		return nil, nil, false
+	}
+
+	method := c.Request().Method
+	operation := pathItem.GetOperation(method)
+	if operation == nil {
+		return nil, nil, false
+	}
+
+	pathParams := make(map[string]string, len(c.ParamNames()))
+	for _, name := range c.ParamNames() {
+		pathParams[name] = c.Param(name)
+	}
+
+	return &routers.Route{
+		Spec:      spec,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    method,
+		Operation: operation,
+	}, pathParams, true
+}
+
+// openAPIPath converts the echo path params, eg. /experiments/:id, to the OpenAPI ones, eg. /experiments/{id}
+func openAPIPath(echoPath string) string {
+	segments := strings.Split(echoPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// openAPIViolations converts the kin-openapi errors, the field defaults to the parameter name or the body root
+func openAPIViolations(err error, field string) appErrors.FieldViolations {
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) && requestErr.Parameter != nil {
+		field = requestErr.Parameter.Name
+	}
+
+	var multiErr openapi3.MultiError
+	if errors.As(err, &multiErr) {
+		violations := appErrors.FieldViolations{}
+		for _, e := range multiErr {
+			violations = append(violations, openAPIViolations(e, field)...)
+		}
+
+		return violations
+	}
+
+	violations := appErrors.FieldViolations{}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			tokens := make([]any, len(pointer))
+			for i, token := range pointer {
+				tokens[i] = token
+			}
+			field = appErrors.Pointer(tokens...)
+		}
+
+		violations.Add(field, schemaViolationCode(schemaErr.SchemaField), schemaErr.Reason)
+		return violations
+	}
+
+	switch {
+	case requestErr != nil && errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired):
+		violations.Add(field, appErrors.ViolationRequired, requestErr.Err.Error())
+	case requestErr != nil && requestErr.Err != nil:
+		violations.Add(field, appErrors.ViolationInvalidFormat, requestErr.Err.Error())
+	case requestErr != nil:
+		violations.Add(field, appErrors.ViolationRequired, requestErr.Reason)
+	default:
+		violations.Add(field, appErrors.ViolationInvalidFormat, err.Error())
+	}
+
+	return violations
+}
+
+func schemaViolationCode(schemaField string) appErrors.ViolationCode {
+	switch schemaField {
+	case "required":
+		return appErrors.ViolationRequired
+	case "type", "format", "pattern", "nullable":
+		return appErrors.ViolationInvalidFormat
+	}
+
+	return appErrors.ViolationInvalidValue
+}
+
+// queryParamWithAlias returns the query param, or its deprecated alias when missing
+func queryParamWithAlias(c echo.Context, name string, alias string) string {
+	if value := c.QueryParam(name); value != "" {
+		return value
+	}
+
+	return c.QueryParam(alias)
+}
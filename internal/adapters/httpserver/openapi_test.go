@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+)
+
+// newOpenAPIEngine registers all the API routes, only the candles ones have a service
+func newOpenAPIEngine(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	spec, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetOpenAPIValidationMiddleware(e, spec)
+	SetOpenAPIHTTPHandler(e, spec)
+	SetCandlesHTTPHandler(e, candlesSVC.NewCandlesService(memory.NewCandlesPersistence(memory.NewStore()), candlesDomain.ValidationReject))
+	SetBuySignalsHTTPHandler(e, nil)
+	SetPositionsHTTPHandler(e, nil)
+	SetExperimentsHTTPHandler(e, nil)
+	SetAnalyticsHTTPHandler(e, nil)
+	SetAuthHTTPHandler(e, nil)
+
+	return e
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	e := newOpenAPIEngine(t)
+	spec, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range e.Routes() {
+		if !strings.HasPrefix(route.Path, apiV1Prefix+"/") {
+			continue
+		}
+
+		key := route.Method + " " + openAPIPath(route.Path)
+		registered[key] = true
+
+		pathItem := spec.Paths.Find(openAPIPath(route.Path))
+		if pathItem == nil || pathItem.GetOperation(route.Method) == nil {
+			t.Errorf("route %s %s is missing from the openapi document", route.Method, route.Path)
+		}
+	}
+
+	for path, pathItem := range spec.Paths.Map() {
+		for method := range pathItem.Operations() {
+			if !registered[method+" "+path] {
+				t.Errorf("operation %s %s of the openapi document has no route", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	e := newOpenAPIEngine(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "valid query", method: http.MethodGet, target: "/api/v1/candles?pair=BTCUSDT&interval=1h&start_date=2024-01-01T00:00:00Z&end_date=2024-01-02T00:00:00Z&limit=10", wantStatus: http.StatusOK},
+		{name: "deprecated alias", method: http.MethodGet, target: "/api/v1/candles?pair=BTCUSDT&interval=1h&last_date=2024-01-02T00:00:00Z", wantStatus: http.StatusOK},
+		{name: "missing pair", method: http.MethodGet, target: "/api/v1/candles?interval=1h", wantStatus: http.StatusBadRequest},
+		{name: "unknown interval", method: http.MethodGet, target: "/api/v1/candles?pair=BTCUSDT&interval=7h", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", method: http.MethodGet, target: "/api/v1/candles?pair=BTCUSDT&interval=1h&limit=ten", wantStatus: http.StatusBadRequest},
+		{name: "invalid date", method: http.MethodGet, target: "/api/v1/candles?pair=BTCUSDT&interval=1h&start_date=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "invalid body type", method: http.MethodPost, target: "/api/v1/candles", body: `{"candles":[{"open":"high"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body enum", method: http.MethodPost, target: "/api/v1/analytics/monte_carlo", body: `{"method":"jackknife"}`, wantStatus: http.StatusBadRequest},
+		{name: "document", method: http.MethodGet, target: OpenAPIRoute, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			if rec.Code == http.StatusBadRequest {
+				var res appErrors.ErrResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || res.Error.AppCode != appErrors.CodeErrInvalidInput {
+					t.Errorf("body = %s, want an invalid input error", rec.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
 	"github.com/sopial42/bifrost/pkg/common/logger"
 	buysignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
 	domain "github.com/sopial42/bifrost/pkg/domains/positions"
 	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
 )
@@ -27,9 +28,11 @@ func SetPositionsHTTPHandler(e *echo.Echo, service positionsSVC.Service) {
 	apiV1 := e.Group("/api/v1")
 	{
 		apiV1.POST("/positions", p.createPositions)
+		apiV1.POST("/positions/generate", p.generatePositions)
 		apiV1.POST("/positions/compute/with-buy-signals", p.createPositionsWithBuySignals)
 		apiV1.POST("/positions/compute/all", p.computeAllPositions)
 		apiV1.POST("/positions/compute/:id", p.computePosition)
+		apiV1.GET("/positions/export", p.exportBuySignalsPositions)
 	}
 }
 
@@ -86,9 +89,10 @@ func (p *positionsHandler) createPositions(context echo.Context) error {
 		return appErrors.NewInvalidInput("invalid input, empty positions", nil)
 	}
 
-	newPositionsDetails := make([]domain.Details, len(newPositionInput.Positions))
+	results := newBatchResults[domain.ID]("positions", len(newPositionInput.Positions))
+	newPositionsDetails := make([]domain.Details, 0, len(newPositionInput.Positions))
 	for i, pos := range newPositionInput.Positions {
-		newPositionsDetails[i] = domain.Details{
+		details := domain.Details{
 			BuySignalID:  buysignals.ID(pos.BuySignalID),
 			Name:         pos.Name,
 			Fullname:     pos.Fullname,
@@ -98,18 +102,50 @@ func (p *positionsHandler) createPositions(context echo.Context) error {
 			Ratio:        pos.Ratio,
 			WinlossRatio: pos.WinlossRatio,
 		}
+
+		violations := appErrors.FieldViolations{}
+		validatePosition(&violations, results.pointer(i), details)
+		if results.validate(i, violations) {
+			newPositionsDetails = append(newPositionsDetails, details)
+		}
 	}
 
-	positions, err := p.positionsSVC.CreatePositions(context.Request().Context(), &newPositionsDetails)
-	if err != nil && !errors.Is(err, appErrors.ErrAlreadyExists) {
+	if err := results.err("invalid positions"); err != nil {
+		return err
+	}
+
+	positions, created, err := p.positionsSVC.CreatePositions(context.Request().Context(), &newPositionsDetails)
+	if err != nil {
 		return appErrors.NewUnexpected("unable to create positions", err)
 	}
+	results.merge(created)
 
-	return context.JSON(http.StatusCreated, map[string]interface{}{
+	// The positions of unknown buy signals are only found invalid by the service
+	if err := results.err("invalid positions"); err != nil {
+		return err
+	}
+
+	return context.JSON(results.status(), map[string]interface{}{
 		"positions": positions,
+		"results":   results.results,
 	})
 }
 
+// validatePosition adds the violations of a position of an existing buy signal at the pointer
+func validatePosition(violations *appErrors.FieldViolations, pointer string, pos domain.Details) {
+	if pos.BuySignalID == (buysignals.ID{}) {
+		violations.Add(pointer+"/buy_signal_id", appErrors.ViolationRequired, "buy_signal_id is required")
+	}
+
+	if pos.Name == "" {
+		violations.Add(pointer+"/name", appErrors.ViolationRequired, "name is required")
+	}
+
+	if pos.Fullname == "" {
+		violations.Add(pointer+"/fullname", appErrors.ViolationRequired, "fullname is required")
+	}
+}
+
 type NewPositionInputWithBuySignals struct {
 	Positions []domain.Details `json:"positions"`
 }
@@ -128,80 +164,119 @@ func (p *positionsHandler) createPositionsWithBuySignals(context echo.Context) e
 		return appErrors.NewInvalidInput("invalid input, empty positions", nil)
 	}
 
-	for _, pos := range input.Positions {
-		var err error
+	violations := appErrors.FieldViolations{}
+	for i, pos := range input.Positions {
+		pointer := appErrors.Pointer("positions", i)
 		if pos.ID != nil {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.id must not be provided", nil))
+			violations.Add(pointer+"/id", appErrors.ViolationReadOnly, "id must not be provided")
 		}
 
 		if pos.Fullname == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.fullname is required", nil))
+			violations.Add(pointer+"/fullname", appErrors.ViolationRequired, "fullname is required")
 		}
 
 		if pos.Name == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.name is required", nil))
+			violations.Add(pointer+"/name", appErrors.ViolationRequired, "name is required")
 		}
 
 		if pos.TP == 0 {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.tp is required", nil))
+			violations.Add(pointer+"/tp", appErrors.ViolationRequired, "tp is required")
 		}
 
 		if pos.SL == 0 {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.sl is required", nil))
+			violations.Add(pointer+"/sl", appErrors.ViolationRequired, "sl is required")
 		}
 
-		if pos.TP <= pos.SL || pos.SL <= 0 || pos.TP <= 0 {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.TP or position.SL is invalid: It should be greater than 0. position.TP should be greater than position.SL.", nil))
+		if pos.TP != 0 && pos.SL != 0 && (pos.TP <= pos.SL || pos.SL < 0) {
+			violations.Add(pointer+"/tp", appErrors.ViolationInvalidValue, "tp and sl should be greater than 0, tp should be greater than sl")
 		}
 
 		if pos.BuySignal == nil {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal is required", nil))
-			return appErrors.NewInvalidInput(fmt.Sprintf("invalid input : %v", err), err)
+			violations.Add(pointer+"/buy_signal", appErrors.ViolationRequired, "buy_signal is required")
+			continue
 		}
 
 		if pos.BuySignal.ID != nil {
-			err = errors.Join(err, appErrors.NewInvalidInput("buy_signal.id must not be provided", nil))
+			violations.Add(pointer+"/buy_signal/id", appErrors.ViolationReadOnly, "id must not be provided")
 		}
 
-		if pos.BuySignal.BusinessID == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("buy_signal.business_id should be provided", nil))
-		}
+		validateBuySignal(&violations, pointer+"/buy_signal", *pos.BuySignal)
+	}
 
-		if pos.BuySignal.Fullname == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal.fullname is required", nil))
-		}
+	if err := violations.Err("invalid positions"); err != nil {
+		log.Debugf("invalid input: %v", err)
+		return err
+	}
 
-		if pos.BuySignal.Name == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal.name is required", nil))
-		}
+	positions, err := p.positionsSVC.CreatePositionsWithBuySignals(context.Request().Context(), &input.Positions)
+	if err != nil && !errors.Is(err, appErrors.ErrAlreadyExists) {
+		return appErrors.NewUnexpected("unable to create positions with buy signals", err)
+	}
 
-		if pos.BuySignal.Pair == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal.pair is required", nil))
-		}
+	return context.JSON(http.StatusCreated, map[string]interface{}{
+		"positions": positions,
+	})
+}
 
-		if pos.BuySignal.Interval == "" {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal.interval is required", nil))
-		}
+type GeneratePositionsInput struct {
+	BuySignalIDs  []uuid.UUID           `json:"buy_signal_ids"`
+	Name          domain.Name           `json:"name"`
+	Params        domain.Metadata       `json:"params"`
+	WinlossRatios []domain.WinLossRatio `json:"winloss_ratios"`
+}
 
-		if pos.BuySignal.Date == (buysignals.Date{}) {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal.date is required", nil))
-		}
+// generatePositions creates the positions of a position strategy for each buy signal and winloss ratio
+func (p *positionsHandler) generatePositions(context echo.Context) error {
+	input := new(GeneratePositionsInput)
+	if err := context.Bind(input); err != nil {
+		return appErrors.NewInvalidInput("invalid input", err)
+	}
 
-		if pos.BuySignal.Price == 0 {
-			err = errors.Join(err, appErrors.NewInvalidInput("position.buy_signal.price is required", nil))
-		}
+	if len(input.BuySignalIDs) == 0 {
+		return appErrors.NewInvalidInput("invalid input, empty buy_signal_ids", nil)
+	}
 
-		if err != nil {
-			return appErrors.NewInvalidInput(fmt.Sprintf("invalid input : %v", err), err)
-		}
+	if !domain.AllAvailablePositionStategies[input.Name] {
+		return appErrors.NewInvalidInput("invalid name", nil)
 	}
 
-	positions, err := p.positionsSVC.CreatePositionsWithBuySignals(context.Request().Context(), &input.Positions)
-	if err != nil && !errors.Is(err, appErrors.ErrAlreadyExists) {
-		return appErrors.NewUnexpected("unable to create positions with buy signals", err)
+	buySignalIDs := make([]buysignals.ID, len(input.BuySignalIDs))
+	for i, id := range input.BuySignalIDs {
+		buySignalIDs[i] = buysignals.ID(id)
+	}
+
+	positions, err := p.positionsSVC.GeneratePositions(context.Request().Context(), positionsSVC.GenerateRequest{
+		BuySignalIDs:  buySignalIDs,
+		Name:          input.Name,
+		Params:        input.Params,
+		WinLossRatios: input.WinlossRatios,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to generate positions: %w", err)
 	}
 
 	return context.JSON(http.StatusCreated, map[string]interface{}{
 		"positions": positions,
 	})
 }
+
+// exportBuySignalsPositions streams the buy signals joined with their positions in the format of the Accept header
+// A buy signal without position is a row without position columns, unless a position fullname is filtered
+func (p *positionsHandler) exportBuySignalsPositions(context echo.Context) error {
+	violations := appErrors.FieldViolations{}
+	filter := domain.ExportFilter{
+		Pair:             common.Pair(context.QueryParam("pair")),
+		Interval:         common.Interval(context.QueryParam("interval")),
+		BuyFullname:      buysignals.Fullname(context.QueryParam("buy_fullname")),
+		PositionFullname: domain.Fullname(context.QueryParam("position_fullname")),
+	}
+
+	filter.StartDate, filter.EndDate = queryDateRange(context, &violations)
+	if err := violations.Err("invalid input"); err != nil {
+		return err
+	}
+
+	return streamExport(context, "buy_signals_positions", domain.BuySignalPositionColumns, func(write func(domain.BuySignalPosition) error) error {
+		return p.positionsSVC.ExportBuySignalsPositions(context.Request().Context(), filter, write)
+	})
+}
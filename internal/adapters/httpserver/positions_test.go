@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+)
+
+func TestCreatePositionsWithBuySignalsViolations(t *testing.T) {
+	e := echo.New()
+	appErrors.SetCustomErrorHandler(e)
+	SetPositionsHTTPHandler(e, nil)
+
+	body := `{"positions": [
+		{"name": "percentage", "fullname": "percentage_tp2", "tp": 110, "sl": 90, "buy_signal": {"name": "morningStar", "business_id": "1", "fullname": "morningStar", "pair": "SOLUSDC", "interval": "1h", "date": "2024-01-01T00:00:00Z", "price": 100}},
+		{"name": "percentage", "fullname": "percentage_tp2", "tp": 90, "sl": 110, "buy_signal": {"name": "morningStar", "business_id": "1", "fullname": "morningStar", "pair": "SOLUSDC", "interval": "7h", "date": "2024-01-01T00:00:00Z"}},
+		{"id": "55554567-e89b-12d3-a456-426614174000", "name": "percentage", "fullname": "percentage_tp2", "sl": 90}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/positions/compute/with-buy-signals", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d, body: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+
+	var res appErrors.ErrResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("unable to decode the response: %v", err)
+	}
+
+	want := []appErrors.FieldViolation{
+		{Field: "/positions/1/tp", Code: appErrors.ViolationInvalidValue},
+		{Field: "/positions/1/buy_signal/interval", Code: appErrors.ViolationInvalidValue},
+		{Field: "/positions/1/buy_signal/price", Code: appErrors.ViolationRequired},
+		{Field: "/positions/2/id", Code: appErrors.ViolationReadOnly},
+		{Field: "/positions/2/tp", Code: appErrors.ViolationRequired},
+		{Field: "/positions/2/buy_signal", Code: appErrors.ViolationRequired},
+	}
+
+	if len(res.Error.Violations) != len(want) {
+		t.Fatalf("violations = %+v, want %d", res.Error.Violations, len(want))
+	}
+
+	for i, v := range res.Error.Violations {
+		if v.Field != want[i].Field || v.Code != want[i].Code || v.Message == "" {
+			t.Errorf("violations[%d] = %+v, want %s %s", i, v, want[i].Field, want[i].Code)
+		}
+	}
+}
@@ -3,8 +3,8 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"iter"
 	"net/url"
 	"strconv"
 	"time"
@@ -13,6 +13,7 @@ import (
 	"github.com/sopial42/bifrost/pkg/common/sdk"
 	domain "github.com/sopial42/bifrost/pkg/domains/buySignals"
 	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/ports"
 	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
 )
 
@@ -44,17 +45,15 @@ func (c *client) CreateBuySignals(ctx context.Context, newBS *[]domain.Details)
 			return nil, appErrors.NewUnexpected("failed to marshal candles", err)
 		}
 
+		// The existing buy signals are answered as duplicates, only the created ones are returned
 		res, err := c.Post(ctx, "/buy_signals", body)
 		if err != nil {
-			if errors.Is(err, appErrors.ErrAlreadyExists) {
-				log.Debugf("buySignals already exists: %+v", err)
-			} else {
-				return nil, fmt.Errorf("failed to post buySignals: %w", err)
-			}
+			return nil, fmt.Errorf("failed to post buySignals: %w", err)
 		}
 
 		postReponse := struct {
-			BuySignals []domain.Details `json:"buy_signals"`
+			BuySignals []domain.Details               `json:"buy_signals"`
+			Results    []common.ItemResult[domain.ID] `json:"results"`
 		}{}
 
 		err = json.Unmarshal(res, &postReponse)
@@ -62,6 +61,10 @@ func (c *client) CreateBuySignals(ctx context.Context, newBS *[]domain.Details)
 			return nil, appErrors.NewUnexpected("create failed to unmarshal buySignals while createChunck", err)
 		}
 
+		if invalid := common.CountOutcome(postReponse.Results, common.OutcomeInvalid); invalid > 0 {
+			log.Warnf("%d buy signals of the chunk are invalid and were not created", invalid)
+		}
+
 		createdBS = append(createdBS, postReponse.BuySignals...)
 	}
 
@@ -69,16 +72,20 @@ func (c *client) CreateBuySignals(ctx context.Context, newBS *[]domain.Details)
 	return &createdBS, nil
 }
 
-func (c *client) GetBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time) (*[]domain.Details, bool, *time.Time, error) {
+func (c *client) GetBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time, limit uint) (*[]domain.Details, bool, *time.Time, error) {
 	queryValues := url.Values{}
 
+	if limit <= 0 {
+		limit = defaultGetBuySignalsLimit
+	}
+
 	queryValues.Add("pair", pair.String())
 	queryValues.Add("interval", interval.String())
 	queryValues.Add("name", string(name))
-	queryValues.Add("limit", strconv.Itoa(defaultGetBuySignalsLimit))
+	queryValues.Add("limit", strconv.Itoa(int(limit)))
 
 	if firstDate != nil {
-		queryValues.Add("first_date", firstDate.Format(time.RFC3339))
+		queryValues.Add("start_date", firstDate.Format(time.RFC3339))
 	}
 
 	res, err := c.Get(ctx, "/buy_signals?"+queryValues.Encode())
@@ -99,3 +106,7 @@ func (c *client) GetBuySignals(ctx context.Context, pair common.Pair, interval c
 
 	return &getResponse.BuySignals, getResponse.HasMore, getResponse.NextCursor, nil
 }
+
+func (c *client) AllBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time, opts ports.IterOptions) iter.Seq2[domain.Details, error] {
+	return ports.BuySignalsSeq(ctx, c, pair, interval, name, firstDate, opts)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
 	"net/url"
 	"strconv"
 	"time"
@@ -86,6 +88,56 @@ func (c *client) CreateCandles(ctx context.Context, newCandles *[]candles.Candle
 	return &createdCandles, nil
 }
 
+func (c *client) IngestCandles(ctx context.Context, newCandles iter.Seq2[candles.Candle, error]) (*candles.IngestResult, error) {
+	reader, writer := io.Pipe()
+	encodeErr := make(chan error, 1)
+	go func() {
+		encodeErr <- encodeCandles(writer, newCandles)
+	}()
+
+	res, err := c.PostStream(ctx, "/candles/ingest", candles.MIMEApplicationNDJSON, reader)
+	// Unblocks the encoding when the request failed before reading the whole body, its error is then the closed pipe
+	reader.Close()
+	if encodeErr := <-encodeErr; encodeErr != nil && encodeErr != io.ErrClosedPipe {
+		return nil, fmt.Errorf("failed to stream candles: %w", encodeErr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest candles: %w", err)
+	}
+
+	result := candles.IngestResult{}
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, errors.NewUnexpected("failed to unmarshal IngestCandles response", err)
+	}
+
+	return &result, nil
+}
+
+// encodeCandles writes the candles as NDJSON, then closes the writer with the error of the sequence if any
+func encodeCandles(writer *io.PipeWriter, newCandles iter.Seq2[candles.Candle, error]) error {
+	encoder, err := candles.NewWriter(candles.FormatNDJSON, writer)
+	if err != nil {
+		writer.CloseWithError(err)
+		return err
+	}
+
+	for candle, err := range newCandles {
+		if err == nil {
+			err = encoder.Write(candle)
+		}
+
+		if err != nil {
+			writer.CloseWithError(err)
+			return err
+		}
+	}
+
+	err = encoder.Flush()
+	writer.CloseWithError(err)
+	return err
+}
+
 func (c *client) UpdateCandleListRSI(ctx context.Context, candlesRSIs *[]candles.Candle) (*[]candles.Candle, error) {
 	if candlesRSIs == nil || len(*candlesRSIs) == 0 {
 		return nil, nil
@@ -198,7 +250,7 @@ func (c *client) GetCandlesByLastDate(ctx context.Context, pair common.Pair, int
 
 	queryValues.Add("pair", string(pair))
 	queryValues.Add("interval", string(interval))
-	queryValues.Add("last_date", lastDate.String())
+	queryValues.Add("end_date", lastDate.String())
 	queryValues.Add("limit", strconv.Itoa(int(limit)))
 
 	res, err := c.Get(ctx, "/candles/from-last-date?"+queryValues.Encode())
@@ -220,6 +272,14 @@ func (c *client) GetCandlesByLastDate(ctx context.Context, pair common.Pair, int
 	return &candlesResponse.Candles, candlesResponse.HasMore, candlesResponse.NextCursor, nil
 }
 
+func (c *client) AllCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, opts ports.IterOptions) iter.Seq2[candles.Candle, error] {
+	return ports.CandlesSeq(ctx, c, pair, interval, startDate, opts)
+}
+
+func (c *client) AllCandlesByLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate candles.Date, opts ports.IterOptions) iter.Seq2[candles.Candle, error] {
+	return ports.CandlesByLastDateSeq(ctx, c, pair, interval, lastDate, opts)
+}
+
 func (c *client) QuerySurroundingDates(ctx context.Context, pair common.Pair, interval common.Interval) (*candles.Date, *candles.Date, error) {
 	res, err := c.Get(ctx, "/candles/surrounding-dates?pair="+string(pair)+"&interval="+string(interval))
 	if err != nil {
@@ -9,8 +9,9 @@ type client struct {
 	*sdk.Client
 }
 
-func NewBifrostHTTPClient(baseURL string) ports.Client {
+// NewBifrostHTTPClient returns a client of the Bifrost API, opts configure the underlying SDK client, eg. its retries
+func NewBifrostHTTPClient(baseURL string, opts ...sdk.ClientOption) ports.Client {
 	return &client{
-		sdk.NewSDKClient(baseURL),
+		sdk.NewSDKClient(baseURL, opts...),
 	}
 }
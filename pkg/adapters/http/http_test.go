@@ -0,0 +1,64 @@
+package http_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	HTTPHandler "github.com/sopial42/bifrost/internal/adapters/httpserver"
+	bifrostHTTP "github.com/sopial42/bifrost/pkg/adapters/http"
+	buySignalsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/buySignals"
+	candlesPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/candles"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/pgtest"
+	positionsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/positions"
+	"github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/ports"
+	"github.com/sopial42/bifrost/pkg/ports/portstest"
+	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+func newHTTPClient(t *testing.T, candlesPers candlesSVC.Persistence, buySignalsPers buySignalsSVC.Persistence, positionsPers positionsSVC.Persistence) ports.Client {
+	candlesService := candlesSVC.NewCandlesService(candlesPers, candlesDomain.ValidationReject)
+	buySignalsService := buySignalsSVC.NewBuySignalsService(buySignalsPers, candlesService)
+	positionsService := positionsSVC.NewPositionsService(positionsPers, candlesService, buySignalsService)
+
+	engine := echo.New()
+	logger.SetLoggerMiddlewareEcho(engine, logger.GetDefaultLogger())
+	errors.SetCustomErrorHandler(engine)
+
+	// The client requests must match the OpenAPI document
+	openAPI, err := HTTPHandler.LoadOpenAPI()
+	if err != nil {
+		t.Fatalf("LoadOpenAPI() error = %v", err)
+	}
+	HTTPHandler.SetOpenAPIValidationMiddleware(engine, openAPI)
+	HTTPHandler.SetBuySignalsHTTPHandler(engine, buySignalsService)
+	HTTPHandler.SetCandlesHTTPHandler(engine, candlesService)
+	HTTPHandler.SetPositionsHTTPHandler(engine, positionsService)
+
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	return bifrostHTTP.NewBifrostHTTPClient(server.URL + "/api/v1")
+}
+
+func TestClientContract_Postgres(t *testing.T) {
+	pgtest.SkipWithoutDB(t)
+	portstest.RunClientSuite(t, func(t *testing.T) ports.Client {
+		db := pgtest.NewDB(t)
+		return newHTTPClient(t, candlesPersistence.NewPersistence(db), buySignalsPersistence.NewPersistence(db), positionsPersistence.NewPersistence(db))
+	})
+}
+
+func TestClientContract_Memory(t *testing.T) {
+	portstest.RunClientSuite(t, func(t *testing.T) ports.Client {
+		store := memory.NewStore()
+		return newHTTPClient(t, memory.NewCandlesPersistence(store), memory.NewBuySignalsPersistence(store), memory.NewPositionsPersistence(store))
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/sopial42/bifrost/pkg/common/errors"
 	"github.com/sopial42/bifrost/pkg/common/logger"
 	"github.com/sopial42/bifrost/pkg/common/sdk"
+	"github.com/sopial42/bifrost/pkg/domains/common"
 	"github.com/sopial42/bifrost/pkg/domains/positions"
 )
 
@@ -46,7 +47,8 @@ func (c *client) CreatePositions(ctx context.Context, newPositions *[]positions.
 		}
 
 		postResponse := struct {
-			Positions []positions.Details `json:"positions"`
+			Positions []positions.Details               `json:"positions"`
+			Results   []common.ItemResult[positions.ID] `json:"results"`
 		}{}
 
 		err = json.Unmarshal(res, &postResponse)
@@ -54,6 +56,10 @@ func (c *client) CreatePositions(ctx context.Context, newPositions *[]positions.
 			return nil, errors.NewUnexpected("create failed to unmarshal positions while createChunck", err)
 		}
 
+		if invalid := common.CountOutcome(postResponse.Results, common.OutcomeInvalid); invalid > 0 {
+			log.Warnf("%d positions of the chunk are invalid and were not created", invalid)
+		}
+
 		createdPositions = append(createdPositions, postResponse.Positions...)
 	}
 
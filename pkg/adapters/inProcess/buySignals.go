@@ -2,16 +2,70 @@ package inProcess
 
 import (
 	"context"
+	"fmt"
+	"iter"
 	"time"
 
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/sdk"
 	domain "github.com/sopial42/bifrost/pkg/domains/buySignals"
 	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/ports"
 )
 
 func (c *inProcessClient) CreateBuySignals(ctx context.Context, newBS *[]domain.Details) (*[]domain.Details, error) {
-	return nil, nil
+	log := logger.GetLogger(ctx)
+
+	if newBS == nil || len(*newBS) == 0 {
+		return nil, nil
+	}
+
+	log.Infof("Creating %d buy signals", len(*newBS))
+	createdBS := []domain.Details{}
+	chuncks := sdk.CreateChunk(newBS, defaultCreateBuySignalsChunckSize)
+	if chuncks == nil {
+		return nil, nil
+	}
+
+	for _, chunk := range *chuncks {
+		// The existing buy signals are ignored, as by the HTTP handler
+		bs, _, err := c.buySignalsSVC.CreateBuySignals(ctx, &chunk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create buySignals: %w", err)
+		}
+
+		if bs != nil {
+			createdBS = append(createdBS, *bs...)
+		}
+	}
+
+	log.Infof("created %d buy signals", len(createdBS))
+	return &createdBS, nil
+}
+
+func (c *inProcessClient) GetBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time, limit uint) (*[]domain.Details, bool, *time.Time, error) {
+	if limit <= 0 {
+		limit = defaultGetBuySignalsLimit
+	}
+
+	// The HTTP handler defaults the first date to the zero time
+	if firstDate == nil {
+		firstDate = &time.Time{}
+	}
+
+	bs, hasMore, nextCursor, err := c.buySignalsSVC.GetBuySignals(ctx, pair, interval, name, firstDate, int(limit))
+	if err != nil {
+		return nil, false, nil, appErrors.NewUnexpected("unable to get buySignals", err)
+	}
+
+	if bs == nil {
+		bs = &[]domain.Details{}
+	}
+
+	return bs, hasMore, nextCursor, nil
 }
 
-func (c *inProcessClient) GetBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time) (*[]domain.Details, bool, *time.Time, error) {
-	return nil, false, nil, nil
+func (c *inProcessClient) AllBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time, opts ports.IterOptions) iter.Seq2[domain.Details, error] {
+	return ports.BuySignalsSeq(ctx, c, pair, interval, name, firstDate, opts)
 }
@@ -2,37 +2,161 @@ package inProcess
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"iter"
 	"time"
 
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/sdk"
 	"github.com/sopial42/bifrost/pkg/domains/candles"
 	"github.com/sopial42/bifrost/pkg/domains/common"
 	"github.com/sopial42/bifrost/pkg/ports"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
 )
 
 func (c *inProcessClient) GetCandlesMinuteClosePriceByDate(ctx context.Context, prices ports.PriceRequest) (*ports.PriceResponse, error) {
-	return nil, nil
+	res, err := c.candlesSVC.GetCandlesMinuteClosePricesByDate(ctx, candlesSVC.PriceRequest(prices))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candles close price: %w", err)
+	}
+
+	response := make(ports.PriceResponse, len(res))
+	for pair, datePrices := range res {
+		response[pair] = make(map[ports.PriceRequestDate]float64, len(datePrices))
+		for date, price := range datePrices {
+			response[pair][ports.PriceRequestDate(date)] = price
+		}
+	}
+
+	return &response, nil
 }
 
 func (c *inProcessClient) CreateCandles(ctx context.Context, newCandles *[]candles.Candle, chunckSize int) (*[]candles.Candle, error) {
-	return nil, nil
+	if newCandles == nil || len(*newCandles) == 0 {
+		return nil, nil
+	}
+
+	createdCandles := []candles.Candle{}
+	if chunckSize <= 0 {
+		chunckSize = defaultCreateCandlesChunckSize
+	}
+
+	chuncks := sdk.CreateChunk(newCandles, chunckSize)
+	if chuncks == nil {
+		return nil, nil
+	}
+
+	for _, chunk := range *chuncks {
+		created, err := c.candlesSVC.CreateCandles(ctx, &chunk)
+		if err != nil && !errors.Is(err, appErrors.ErrAlreadyExists) {
+			return nil, fmt.Errorf("unable to create candles: %w", err)
+		}
+
+		if created != nil {
+			createdCandles = append(createdCandles, *created...)
+		}
+	}
+
+	return &createdCandles, nil
+}
+
+func (c *inProcessClient) IngestCandles(ctx context.Context, newCandles iter.Seq2[candles.Candle, error]) (*candles.IngestResult, error) {
+	result, err := c.candlesSVC.IngestCandles(ctx, newCandles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to ingest candles: %w", err)
+	}
+
+	return result, nil
 }
 
 func (c *inProcessClient) UpdateCandleListRSI(ctx context.Context, candlesRSIs *[]candles.Candle) (*[]candles.Candle, error) {
-	return nil, nil
+	if candlesRSIs == nil || len(*candlesRSIs) == 0 {
+		return nil, nil
+	}
+
+	updatedCandles := []candles.Candle{}
+	chuncks := sdk.CreateChunk(candlesRSIs, defaultCreateCandlesChunckSize)
+	if chuncks == nil {
+		return nil, nil
+	}
+
+	for _, chunk := range *chuncks {
+		updated, err := c.candlesSVC.UpdateCandlesRSI(ctx, &chunk)
+		if err != nil {
+			return nil, fmt.Errorf("unable to update candles: %w", err)
+		}
+
+		if updated != nil {
+			updatedCandles = append(updatedCandles, *updated...)
+		}
+	}
+
+	return &updatedCandles, nil
 }
 
 func (c *inProcessClient) GetCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, limit uint) (*[]candles.Candle, bool, *time.Time, error) {
-	return nil, false, nil, nil
+	if limit <= 0 {
+		limit = defaultGetCandlesLimit
+	}
+
+	res, hasMore, nextCursor, err := c.candlesSVC.GetCandles(ctx, pair, interval, startDate, nil, int(limit))
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to get candles: %w", err)
+	}
+
+	if res == nil {
+		res = &[]candles.Candle{}
+	}
+
+	return res, hasMore, nextCursor, nil
 }
 
 func (c *inProcessClient) GetCandleByDate(ctx context.Context, pair common.Pair, interval common.Interval, date candles.Date) (res *candles.Candle, err error) {
-	return nil, nil
+	startDate := time.Time(date)
+	found, _, _, err := c.candlesSVC.GetCandles(ctx, pair, interval, &startDate, nil, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candle: %w", err)
+	}
+
+	if found == nil || len(*found) == 0 {
+		return nil, nil
+	}
+
+	return &(*found)[0], nil
 }
 
 func (c *inProcessClient) GetCandlesByLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate candles.Date, limit uint) (*[]candles.Candle, bool, *time.Time, error) {
-	return nil, false, nil, nil
+	if limit <= 0 {
+		limit = defaultGetCandlesLimit
+	}
+
+	date := time.Time(lastDate)
+	res, hasMore, nextCursor, err := c.candlesSVC.GetCandlesFromLastDate(ctx, pair, interval, &date, int(limit))
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to get candles: %w", err)
+	}
+
+	if res == nil {
+		res = &[]candles.Candle{}
+	}
+
+	return res, hasMore, nextCursor, nil
+}
+
+func (c *inProcessClient) AllCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, opts ports.IterOptions) iter.Seq2[candles.Candle, error] {
+	return ports.CandlesSeq(ctx, c, pair, interval, startDate, opts)
+}
+
+func (c *inProcessClient) AllCandlesByLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate candles.Date, opts ports.IterOptions) iter.Seq2[candles.Candle, error] {
+	return ports.CandlesByLastDateSeq(ctx, c, pair, interval, lastDate, opts)
 }
 
 func (c *inProcessClient) QuerySurroundingDates(ctx context.Context, pair common.Pair, interval common.Interval) (*candles.Date, *candles.Date, error) {
-	return nil, nil, nil
+	firstDate, lastDate, err := c.candlesSVC.GetSurroundingDates(ctx, pair, interval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query surrounding dates: %w", err)
+	}
+
+	return firstDate, lastDate, nil
 }
@@ -6,12 +6,22 @@ import (
 	candlesPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/candles"
 	positionsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/positions"
 	"github.com/sopial42/bifrost/pkg/common/config"
+	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
 	"github.com/sopial42/bifrost/pkg/ports"
 	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
 	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
 	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
 )
 
+// Chunk sizes and limits mirror the HTTP client ones, so both clients behave the same
+const (
+	defaultCreateBuySignalsChunckSize = 1000
+	defaultGetBuySignalsLimit         = 1000
+	defaultCreateCandlesChunckSize    = 5000
+	defaultGetCandlesLimit            = 5000
+	defaultCreatePositionsChunckSize  = 1
+)
+
 type inProcessClient struct {
 	buySignalsSVC buySignalsSVC.Service
 	candlesSVC    candlesSVC.Service
@@ -24,13 +34,18 @@ func NewBifrostInProcessClient(dbConfig config.DBConfig) ports.Client {
 	candlesPersistence := candlesPersistence.NewPersistence(pgClient.Client)
 	positionsPersistence := positionsPersistence.NewPersistence(pgClient.Client)
 
-	buySignalsSVC := buySignalsSVC.NewBuySignalsService(buySignalsPersistence)
-	candlesSVC := candlesSVC.NewCandlesService(candlesPersistence)
+	candlesSVC := candlesSVC.NewCandlesService(candlesPersistence, candlesDomain.ValidationReject)
+	buySignalsSVC := buySignalsSVC.NewBuySignalsService(buySignalsPersistence, candlesSVC)
 	positionsSVC := positionsSVC.NewPositionsService(positionsPersistence, candlesSVC, buySignalsSVC)
 
+	return NewInProcessClient(buySignalsSVC, candlesSVC, positionsSVC)
+}
+
+// NewInProcessClient builds the client on top of already built services, eg. backed by another persistence
+func NewInProcessClient(buySignals buySignalsSVC.Service, candles candlesSVC.Service, positions positionsSVC.Service) ports.Client {
 	return &inProcessClient{
-		buySignalsSVC: buySignalsSVC,
-		candlesSVC:    candlesSVC,
-		positionsSVC:  positionsSVC,
+		buySignalsSVC: buySignals,
+		candlesSVC:    candles,
+		positionsSVC:  positions,
 	}
 }
@@ -0,0 +1,41 @@
+package inProcess_test
+
+import (
+	"testing"
+
+	"github.com/sopial42/bifrost/pkg/adapters/inProcess"
+	buySignalsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/buySignals"
+	candlesPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/candles"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/pgtest"
+	positionsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/positions"
+	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/ports"
+	"github.com/sopial42/bifrost/pkg/ports/portstest"
+	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+func newInProcessClient(candlesPers candlesSVC.Persistence, buySignalsPers buySignalsSVC.Persistence, positionsPers positionsSVC.Persistence) ports.Client {
+	candlesService := candlesSVC.NewCandlesService(candlesPers, candlesDomain.ValidationReject)
+	buySignalsService := buySignalsSVC.NewBuySignalsService(buySignalsPers, candlesService)
+	positionsService := positionsSVC.NewPositionsService(positionsPers, candlesService, buySignalsService)
+
+	return inProcess.NewInProcessClient(buySignalsService, candlesService, positionsService)
+}
+
+func TestClientContract_Postgres(t *testing.T) {
+	pgtest.SkipWithoutDB(t)
+	portstest.RunClientSuite(t, func(t *testing.T) ports.Client {
+		db := pgtest.NewDB(t)
+		return newInProcessClient(candlesPersistence.NewPersistence(db), buySignalsPersistence.NewPersistence(db), positionsPersistence.NewPersistence(db))
+	})
+}
+
+func TestClientContract_Memory(t *testing.T) {
+	portstest.RunClientSuite(t, func(t *testing.T) ports.Client {
+		store := memory.NewStore()
+		return newInProcessClient(memory.NewCandlesPersistence(store), memory.NewBuySignalsPersistence(store), memory.NewPositionsPersistence(store))
+	})
+}
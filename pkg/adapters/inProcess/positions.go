@@ -3,9 +3,46 @@ package inProcess
 import (
 	"context"
 
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/sdk"
+	"github.com/sopial42/bifrost/pkg/domains/common"
 	"github.com/sopial42/bifrost/pkg/domains/positions"
 )
 
 func (c *inProcessClient) CreatePositions(ctx context.Context, newPositions *[]positions.Details, chunckSize int) (*[]positions.Details, error) {
-	return nil, nil
+	if newPositions == nil || len(*newPositions) == 0 {
+		return nil, nil
+	}
+
+	createdPositions := []positions.Details{}
+	if chunckSize <= 0 {
+		chunckSize = defaultCreatePositionsChunckSize
+	}
+
+	log := logger.GetLogger(ctx)
+	log.Infof("Creating %d positions", len(*newPositions))
+	chuncks := sdk.CreateChunk(newPositions, chunckSize)
+	if chuncks == nil {
+		return nil, nil
+	}
+
+	for _, chunk := range *chuncks {
+		// The existing positions are ignored, as by the HTTP handler
+		created, results, err := c.positionsSVC.CreatePositions(ctx, &chunk)
+		if err != nil {
+			return nil, appErrors.NewUnexpected("unable to create positions", err)
+		}
+
+		if invalid := common.CountOutcome(results, common.OutcomeInvalid); invalid > 0 {
+			log.Warnf("%d positions of the chunk are invalid and were not created", invalid)
+		}
+
+		if created != nil {
+			createdPositions = append(createdPositions, *created...)
+		}
+	}
+
+	log.Infof("Created %d positions", len(createdPositions))
+	return &createdPositions, nil
 }
@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/analytics"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	analyticsSVC "github.com/sopial42/bifrost/pkg/services/analytics"
+)
+
+type pgPersistence struct {
+	clientDB *bun.DB
+}
+
+func NewPersistence(client *bun.DB) analyticsSVC.Persistence {
+	return &pgPersistence{clientDB: client}
+}
+
+func (p *pgPersistence) InsertWalkForward(ctx context.Context, walkForward *domain.WalkForward) (*domain.WalkForward, error) {
+	dao := walkForwardToWalkForwardDAO(walkForward)
+	dao.Workspace = workspaces.GetWorkspaceFromContext(ctx)
+	err := p.clientDB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(dao).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("unable to insert walk-forward: %w", err)
+		}
+
+		if len(dao.Windows) == 0 {
+			return nil
+		}
+
+		if _, err := tx.NewInsert().Model(&dao.Windows).Exec(ctx); err != nil {
+			return fmt.Errorf("unable to insert walk-forward windows: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return walkForwardDAOToWalkForward(dao), nil
+}
+
+func (p *pgPersistence) GetWalkForwardByID(ctx context.Context, id domain.ID) (*domain.WalkForward, error) {
+	dao := WalkForwardDAO{}
+	err := p.clientDB.NewSelect().
+		Model(&dao).
+		Relation("Windows").
+		Where("walk_forward_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("walk_forward_dao.id = ?", uuid.UUID(id)).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	return walkForwardDAOToWalkForward(&dao), nil
+}
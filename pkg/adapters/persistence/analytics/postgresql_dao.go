@@ -0,0 +1,127 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/analytics"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+type WalkForwardDAO struct {
+	bun.BaseModel `bun:"table:walk_forwards"`
+
+	ID        uuid.UUID                 `bun:",pk,type:uuid,default:uuid_generate_v4()"`
+	Workspace workspaces.Workspace      `bun:"workspace"`
+	Request   domain.WalkForwardRequest `bun:"request,type:jsonb"`
+	CreatedAt time.Time                 `bun:"created_at"`
+	Windows   []WalkForwardWindowDAO    `bun:"rel:has-many,join:id=walk_forward_id"`
+}
+
+type WalkForwardWindowDAO struct {
+	bun.BaseModel `bun:"table:walk_forward_windows"`
+
+	ID                uuid.UUID             `bun:",pk,type:uuid,default:uuid_generate_v4()"`
+	WalkForwardID     uuid.UUID             `bun:"type:uuid"`
+	Index             int                   `bun:"window_index"`
+	Metric            string                `bun:"metric"`
+	InSampleStart     time.Time             `bun:"in_sample_start"`
+	InSampleEnd       time.Time             `bun:"in_sample_end"`
+	OutOfSampleStart  time.Time             `bun:"out_of_sample_start"`
+	OutOfSampleEnd    time.Time             `bun:"out_of_sample_end"`
+	BuySignalFullname string                `bun:"buy_signal_fullname,nullzero"`
+	PositionFullname  string                `bun:"position_fullname,nullzero"`
+	WinlossRatio      *float64              `bun:"winloss_ratio,nullzero"`
+	InSample          positions.Performance `bun:"in_sample,type:jsonb"`
+	OutOfSample       positions.Performance `bun:"out_of_sample,type:jsonb"`
+}
+
+func walkForwardToWalkForwardDAO(walkForward *domain.WalkForward) *WalkForwardDAO {
+	dao := &WalkForwardDAO{
+		Request:   walkForward.Request,
+		CreatedAt: walkForward.CreatedAt,
+	}
+
+	if walkForward.ID != nil {
+		dao.ID = uuid.UUID(*walkForward.ID)
+	} else {
+		dao.ID = uuid.New()
+	}
+
+	dao.Windows = make([]WalkForwardWindowDAO, len(walkForward.Windows))
+	for i, w := range walkForward.Windows {
+		dao.Windows[i] = WalkForwardWindowDAO{
+			ID:               uuid.New(),
+			WalkForwardID:    dao.ID,
+			Index:            w.Index,
+			Metric:           string(w.Metric),
+			InSampleStart:    w.InSampleStart,
+			InSampleEnd:      w.InSampleEnd,
+			OutOfSampleStart: w.OutOfSampleStart,
+			OutOfSampleEnd:   w.OutOfSampleEnd,
+			InSample:         w.InSample,
+			OutOfSample:      w.OutOfSample,
+		}
+
+		if w.Strategy != nil {
+			dao.Windows[i].BuySignalFullname = string(w.Strategy.BuySignalFullname)
+			dao.Windows[i].PositionFullname = string(w.Strategy.PositionFullname)
+			dao.Windows[i].WinlossRatio = (*float64)(w.Strategy.WinlossRatio)
+		}
+	}
+
+	return dao
+}
+
+func walkForwardDAOToWalkForward(dao *WalkForwardDAO) *domain.WalkForward {
+	id := domain.ID(dao.ID)
+	walkForward := &domain.WalkForward{
+		ID:        &id,
+		Request:   dao.Request,
+		CreatedAt: dao.CreatedAt,
+		Windows:   make([]domain.Window, len(dao.Windows)),
+	}
+
+	for i, w := range dao.Windows {
+		walkForward.Windows[i] = domain.Window{
+			Index:            w.Index,
+			Metric:           domain.Metric(w.Metric),
+			InSampleStart:    w.InSampleStart.UTC(),
+			InSampleEnd:      w.InSampleEnd.UTC(),
+			OutOfSampleStart: w.OutOfSampleStart.UTC(),
+			OutOfSampleEnd:   w.OutOfSampleEnd.UTC(),
+			InSample:         w.InSample,
+			OutOfSample:      w.OutOfSample,
+		}
+
+		if w.PositionFullname != "" {
+			walkForward.Windows[i].Strategy = &domain.Strategy{
+				BuySignalFullname: buySignals.Fullname(w.BuySignalFullname),
+				PositionFullname:  positions.Fullname(w.PositionFullname),
+				WinlossRatio:      (*positions.WinLossRatio)(w.WinlossRatio),
+			}
+		}
+	}
+
+	// Windows are stored unordered, they are returned by index, then in the requested metrics order
+	metricsOrder := make(map[domain.Metric]int, len(dao.Request.Metrics))
+	for i, m := range dao.Request.Metrics {
+		metricsOrder[m] = i
+	}
+
+	sort.SliceStable(walkForward.Windows, func(i, j int) bool {
+		a, b := walkForward.Windows[i], walkForward.Windows[j]
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+
+		return metricsOrder[a.Metric] < metricsOrder[b.Metric]
+	})
+
+	return walkForward
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+)
+
+type pgPersistence struct {
+	clientDB *bun.DB
+}
+
+func NewPersistence(client *bun.DB) authSVC.Persistence {
+	return &pgPersistence{clientDB: client}
+}
+
+func (p *pgPersistence) InsertAPIKey(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	dao := apiKeyToAPIKeyDAO(key)
+	_, err := p.clientDB.NewInsert().
+		Model(dao).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		if errPg, ok := err.(pgdriver.Error); ok && errPg.Field('C') == pgerrcode.UniqueViolation {
+			return nil, appErrors.NewAlreadyExists("api key prefix already exists")
+		}
+
+		return nil, fmt.Errorf("unable to insert api key: %w", err)
+	}
+
+	return apiKeyDAOToAPIKey(dao), nil
+}
+
+func (p *pgPersistence) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	dao := APIKeyDAO{}
+	err := p.clientDB.NewSelect().
+		Model(&dao).
+		Where("prefix = ?", prefix).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	return apiKeyDAOToAPIKey(&dao), nil
+}
+
+func (p *pgPersistence) ListAPIKeys(ctx context.Context) (*[]domain.APIKey, error) {
+	daos := []APIKeyDAO{}
+	query := p.clientDB.NewSelect().
+		Model(&daos).
+		OrderExpr("created_at ASC")
+
+	if !domain.ManagesAllWorkspaces(ctx) {
+		query = query.Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx))
+	}
+
+	err := query.Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	keys := make([]domain.APIKey, len(daos))
+	for i := range daos {
+		keys[i] = *apiKeyDAOToAPIKey(&daos[i])
+	}
+
+	return &keys, nil
+}
+
+func (p *pgPersistence) RevokeAPIKey(ctx context.Context, id domain.ID) (*domain.APIKey, error) {
+	daos := []APIKeyDAO{}
+	// A key already revoked keeps its first revocation date
+	query := p.clientDB.NewUpdate().
+		Model((*APIKeyDAO)(nil)).
+		Set("revoked_at = COALESCE(revoked_at, ?)", time.Now().UTC()).
+		Where("id = ?", uuid.UUID(id)).
+		Returning("*")
+
+	if !domain.ManagesAllWorkspaces(ctx) {
+		query = query.Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx))
+	}
+
+	_, err := query.Exec(ctx, &daos)
+	if err != nil {
+		return nil, fmt.Errorf("unable to revoke api key: %w", err)
+	}
+
+	if len(daos) == 0 {
+		return nil, nil
+	}
+
+	return apiKeyDAOToAPIKey(&daos[0]), nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+type APIKeyDAO struct {
+	bun.BaseModel `bun:"table:api_keys"`
+
+	ID        uuid.UUID            `bun:",pk,type:uuid,default:uuid_generate_v4()"`
+	Owner     string               `bun:"owner"`
+	Workspace workspaces.Workspace `bun:"workspace"`
+	Prefix    string               `bun:"prefix"`
+	Hash      []byte               `bun:"hash,type:bytea"`
+	Scopes    []domain.Scope       `bun:"scopes,type:jsonb"`
+	CreatedAt time.Time            `bun:"created_at"`
+	RevokedAt *time.Time           `bun:"revoked_at,nullzero"`
+}
+
+func apiKeyToAPIKeyDAO(key *domain.APIKey) *APIKeyDAO {
+	dao := &APIKeyDAO{
+		Owner:     key.Owner,
+		Workspace: key.Workspace,
+		Prefix:    key.Prefix,
+		Hash:      key.Hash,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+
+	if key.ID != nil {
+		dao.ID = uuid.UUID(*key.ID)
+	} else {
+		dao.ID = uuid.New()
+	}
+
+	return dao
+}
+
+func apiKeyDAOToAPIKey(dao *APIKeyDAO) *domain.APIKey {
+	id := domain.ID(dao.ID)
+	return &domain.APIKey{
+		ID:        &id,
+		Owner:     dao.Owner,
+		Workspace: dao.Workspace,
+		Prefix:    dao.Prefix,
+		Hash:      dao.Hash,
+		Scopes:    dao.Scopes,
+		CreatedAt: dao.CreatedAt,
+		RevokedAt: dao.RevokedAt,
+	}
+}
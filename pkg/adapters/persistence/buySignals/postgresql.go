@@ -5,14 +5,13 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/jackc/pgerrcode"
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
-	"github.com/uptrace/bun/driver/pgdriver"
 
-	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
 	"github.com/sopial42/bifrost/pkg/common/logger"
 	domain "github.com/sopial42/bifrost/pkg/domains/buySignals"
 	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
 	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
 )
 
@@ -24,27 +23,104 @@ func NewPersistence(client *bun.DB) buySignalsSVC.Persistence {
 	return &pgPersistence{clientDB: client}
 }
 
-func (c *pgPersistence) InsertBuySignals(ctx context.Context, bsReports *[]domain.Details) (*[]domain.Details, error) {
+// InsertBuySignals inserts the buy signals, the existing ones are ignored
+// Returns the newly inserted buy signals, and the outcome of each buy signal in input order
+func (c *pgPersistence) InsertBuySignals(ctx context.Context, bsReports *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error) {
 	log := logger.GetLogger(ctx)
 	if bsReports == nil || len(*bsReports) == 0 {
-		return &[]domain.Details{}, fmt.Errorf("unable to insert buy signal, nil or empty")
+		return &[]domain.Details{}, nil, fmt.Errorf("unable to insert buy signal, nil or empty")
 	}
 
 	buySignalsDAO := buySignalDetailsToBuySignalDAOs(ctx, bsReports, false)
-	_, err := c.clientDB.
+	insertedDAO := []BuySignalDAO{}
+	err := c.clientDB.
 		NewInsert().
 		Model(&buySignalsDAO).
-		Exec(ctx)
+		On("CONFLICT (workspace, business_id, pair, interval, fullname) DO NOTHING").
+		Returning("*").
+		Scan(ctx, &insertedDAO)
 	if err != nil {
-		if errPg, ok := err.(pgdriver.Error); ok && errPg.Field('C') == pgerrcode.UniqueViolation {
-			return &[]domain.Details{}, appErrors.NewAlreadyExists("buy signal already exists, unique constraint violation")
+		return &[]domain.Details{}, nil, fmt.Errorf("unable to insert buy signals: %w", err)
+	}
+
+	inserted := make(map[uuid.UUID]bool, len(insertedDAO))
+	for _, bs := range insertedDAO {
+		inserted[bs.ID] = true
+	}
+
+	// The IDs are generated before the insert, the ones not returned are duplicates
+	duplicates := make([]BuySignalDAO, 0, len(buySignalsDAO)-len(insertedDAO))
+	for _, bs := range buySignalsDAO {
+		if !inserted[bs.ID] {
+			duplicates = append(duplicates, bs)
 		}
+	}
 
-		return &[]domain.Details{}, fmt.Errorf("unable to insert buy signals: %w", err)
+	existingIDs, err := c.queryExistingIDs(ctx, duplicates)
+	if err != nil {
+		return &[]domain.Details{}, nil, err
+	}
+
+	results := make([]common.ItemResult[domain.ID], len(buySignalsDAO))
+	for i, bs := range buySignalsDAO {
+		if inserted[bs.ID] {
+			id := domain.ID(bs.ID)
+			results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeCreated, ID: &id}
+			continue
+		}
+
+		existingID, found := existingIDs[keyOfBuySignalDAO(bs)]
+		if !found {
+			return &[]domain.Details{}, nil, fmt.Errorf("unable to find the existing buy signal of %s %s %s %s", bs.BusinessID, bs.Pair, bs.Interval, bs.Fullname)
+		}
+
+		id := domain.ID(existingID)
+		results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeDuplicate, ID: &id}
+	}
+
+	res := buySignalDAOsToBuySignalDetails(ctx, &insertedDAO)
+	log.Debugf("Insert buySignals done (%d inserted, %d duplicates)", len(insertedDAO), len(duplicates))
+	return res, results, nil
+}
+
+type buySignalKey struct {
+	businessID domain.BusinessID
+	pair       common.Pair
+	interval   common.Interval
+	fullname   domain.Fullname
+}
+
+func keyOfBuySignalDAO(bs BuySignalDAO) buySignalKey {
+	return buySignalKey{businessID: bs.BusinessID, pair: bs.Pair, interval: bs.Interval, fullname: bs.Fullname}
+}
+
+// queryExistingIDs returns the IDs of the stored buy signals of the workspace with the unique key of the given ones
+func (c *pgPersistence) queryExistingIDs(ctx context.Context, buySignalsDAO []BuySignalDAO) (map[buySignalKey]uuid.UUID, error) {
+	res := make(map[buySignalKey]uuid.UUID, len(buySignalsDAO))
+	if len(buySignalsDAO) == 0 {
+		return res, nil
+	}
+
+	keys := make([][]any, len(buySignalsDAO))
+	for i, bs := range buySignalsDAO {
+		keys[i] = []any{bs.BusinessID, bs.Pair, bs.Interval, bs.Fullname}
+	}
+
+	existingDAO := []BuySignalDAO{}
+	err := c.clientDB.NewSelect().
+		Model(&existingDAO).
+		Column("id", "business_id", "pair", "interval", "fullname").
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("(business_id, pair, interval, fullname) IN (?)", bun.In(keys)).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query existing buy signals: %w", err)
+	}
+
+	for _, bs := range existingDAO {
+		res[keyOfBuySignalDAO(bs)] = bs.ID
 	}
 
-	res := buySignalDAOsToBuySignalDetails(ctx, &buySignalsDAO)
-	log.Debugf("Insert buySignals done")
 	return res, nil
 }
 
@@ -52,6 +128,7 @@ func (c *pgPersistence) QueryBuySignals(ctx context.Context, pair common.Pair, i
 	buySignalsDAO := []BuySignalDAO{}
 	request := c.clientDB.NewSelect().
 		Model(&buySignalsDAO).
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
 		Where("pair = ?", pair).
 		Where("interval = ?", interval).
 		Where("name = ?", name).
@@ -88,6 +165,30 @@ func (c *pgPersistence) QueryBuySignals(ctx context.Context, pair common.Pair, i
 	return buySignalDAOsToBuySignalDetails(ctx, &buySignalsDAO), hasMore, nextCursor, nil
 }
 
+func (c *pgPersistence) QueryBuySignalsByIDs(ctx context.Context, ids []domain.ID) (*[]domain.Details, error) {
+	if len(ids) == 0 {
+		return &[]domain.Details{}, nil
+	}
+
+	uuids := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		uuids[i] = uuid.UUID(id)
+	}
+
+	buySignalsDAO := []BuySignalDAO{}
+	err := c.clientDB.NewSelect().
+		Model(&buySignalsDAO).
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("id IN (?)", bun.In(uuids)).
+		OrderExpr("date ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	return buySignalDAOsToBuySignalDetails(ctx, &buySignalsDAO), nil
+}
+
 func (c *pgPersistence) UpsertBuySignals(ctx context.Context, bs domain.Details) (*[]domain.Details, error) {
 	bsDAO := buySignalDetailsToBuySignalDAOs(ctx, &[]domain.Details{bs}, true)
 	if len(bsDAO) == 0 {
@@ -97,7 +198,7 @@ func (c *pgPersistence) UpsertBuySignals(ctx context.Context, bs domain.Details)
 	_, err := c.clientDB.
 		NewInsert().
 		Model(&bsDAO).
-		On("CONFLICT (pair, interval, fullname, business_id) DO UPDATE").
+		On("CONFLICT (workspace, pair, interval, fullname, business_id) DO UPDATE").
 		Set("price = EXCLUDED.price").
 		Set("metadata = EXCLUDED.metadata").
 		Returning("*").
@@ -10,12 +10,14 @@ import (
 	"github.com/sopial42/bifrost/pkg/common/logger"
 	domain "github.com/sopial42/bifrost/pkg/domains/buySignals"
 	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
 )
 
 type BuySignalDAO struct {
 	bun.BaseModel `bun:"table:buy_signals"`
 
 	ID         uuid.UUID `bun:",pk,type:uuid,default:uuid_generate_v4()"`
+	Workspace  workspaces.Workspace
 	BusinessID domain.BusinessID
 	Name       domain.Name
 	Fullname   domain.Fullname
@@ -30,9 +32,11 @@ func buySignalDetailsToBuySignalDAOs(ctx context.Context, buySignals *[]domain.D
 	log := logger.GetLogger(ctx)
 
 	buySignalsDAO := make([]BuySignalDAO, len(*buySignals))
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
 
 	for i, bs := range *buySignals {
 		buySignalsDAO[i] = BuySignalDAO{
+			Workspace:  workspace,
 			BusinessID: domain.BusinessID(bs.BusinessID),
 			Name:       domain.Name(bs.Name),
 			Fullname:   domain.Fullname(bs.Fullname),
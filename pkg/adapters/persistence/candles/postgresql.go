@@ -1,12 +1,17 @@
 package candles
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
 
 	"github.com/sopial42/bifrost/pkg/common/logger"
 	domain "github.com/sopial42/bifrost/pkg/domains/candles"
@@ -48,6 +53,211 @@ func (c *pgPersistence) InsertCandles(ctx context.Context, candles *[]domain.Can
 	return candlesDAOsToCandlesDetails(ctx, candlesDAO), nil
 }
 
+// CopyCandles loads the candles with COPY into a temporary staging table, then merges them into the candles table
+// COPY is much faster than the multi-row INSERT of InsertCandles, it fits the bulk loads of years of candles
+func (c *pgPersistence) CopyCandles(ctx context.Context, candles *[]domain.Candle) (int, error) {
+	if candles == nil || len(*candles) == 0 {
+		return 0, nil
+	}
+
+	rows, err := candlesDAOToCopyCSV(candlesToCandlesDAO(ctx, candles, false))
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode candles: %w", err)
+	}
+
+	// COPY runs on the raw connection, so the staging table and the merge share it within a transaction
+	conn, err := c.clientDB.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get a db connection: %w", err)
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.GetLogger(ctx).Warnf("unable to rollback the candles copy: %v", err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "CREATE TEMP TABLE candles_staging (LIKE candles) ON COMMIT DROP"); err != nil {
+		return 0, fmt.Errorf("unable to create the staging table: %w", err)
+	}
+
+	query := fmt.Sprintf("COPY candles_staging (%s) FROM STDIN WITH (FORMAT csv)", strings.Join(copyColumns, ", "))
+	if _, err := pgdriver.CopyFrom(ctx, conn, bytes.NewReader(rows), query); err != nil {
+		return 0, fmt.Errorf("unable to copy candles: %w", err)
+	}
+
+	// DO NOTHING also skips the duplicates within the staging table
+	res, err := tx.NewInsert().
+		Table("candles", "candles_staging").
+		Column(copyColumns...).
+		On("CONFLICT (date, interval, pair) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to merge the staging table: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to count the inserted candles: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("unable to commit the candles copy: %w", err)
+	}
+
+	return int(inserted), nil
+}
+
+// CorrectCandles upserts the candles at once within a transaction: they are loaded in a staging table, then merged by a few set-based statements
+// The existing candles whose prices change are locked, and their replaced version inserted in candle_revisions before their prices are overwritten
+// The positions of all the workspaces are marked stale, as the candles are shared
+func (c *pgPersistence) CorrectCandles(ctx context.Context, source string, candles *[]domain.Candle) (*domain.CorrectionResult, error) {
+	result := &domain.CorrectionResult{Candles: []domain.Candle{}}
+	if candles == nil || len(*candles) == 0 {
+		return result, nil
+	}
+
+	candlesDAO := candlesToCandlesDAO(ctx, candles, false)
+	err := c.clientDB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "CREATE TEMP TABLE candles_corrections (LIKE candles) ON COMMIT DROP"); err != nil {
+			return fmt.Errorf("unable to create the staging table: %w", err)
+		}
+
+		if _, err := tx.NewInsert().Model(candlesDAO).ModelTableExpr("candles_corrections").Exec(ctx); err != nil {
+			return fmt.Errorf("unable to stage candles: %w", err)
+		}
+
+		// The prices compared with IS DISTINCT FROM are the ones of domain.Candle.SamePrices
+		_, err := tx.NewRaw(`
+			INSERT INTO candle_revisions (candle_id, open, close, high, low, issues, source, revised_at)
+			SELECT c.id, c.open, c.close, c.high, c.low, c.issues, ?, ?
+			FROM candles c
+			JOIN candles_corrections s ON s.date = c.date AND s.interval = c.interval AND s.pair = c.pair
+			WHERE (c.open, c.close, c.high, c.low) IS DISTINCT FROM (s.open, s.close, s.high, s.low)
+			FOR UPDATE OF c
+		`, source, time.Now().UTC()).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to insert candle revisions: %w", err)
+		}
+
+		corrected := []CandleDAO{}
+		err = tx.NewRaw(`
+			UPDATE candles c SET open = s.open, close = s.close, high = s.high, low = s.low, issues = s.issues
+			FROM candles_corrections s
+			WHERE s.date = c.date AND s.interval = c.interval AND s.pair = c.pair
+			  AND (c.open, c.close, c.high, c.low) IS DISTINCT FROM (s.open, s.close, s.high, s.low)
+			RETURNING c.*
+		`).Scan(ctx, &corrected)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("unable to correct candles: %w", err)
+		}
+
+		inserted := []CandleDAO{}
+		err = tx.NewInsert().
+			Table("candles", "candles_corrections").
+			Column(copyColumns...).
+			On("CONFLICT (date, interval, pair) DO NOTHING").
+			Returning("*").
+			Scan(ctx, &inserted)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("unable to insert candles: %w", err)
+		}
+
+		result.Corrected, result.Inserted = len(corrected), len(inserted)
+		result.Unchanged = len(*candlesDAO) - result.Corrected - result.Inserted
+
+		// The result lists the revised candles in the order of the correction
+		revised := make(map[candleKey]CandleDAO, len(corrected)+len(inserted))
+		for _, dao := range append(corrected, inserted...) {
+			revised[keyOfCandleDAO(dao)] = dao
+		}
+
+		for _, candleDAO := range *candlesDAO {
+			if dao, found := revised[keyOfCandleDAO(candleDAO)]; found {
+				result.Candles = append(result.Candles, *candleDAOToCandleDetails(ctx, &dao))
+			}
+		}
+
+		for pair, dates := range domain.RangesByPair(result.Candles, backtestInterval) {
+			stale, err := markStalePositions(ctx, tx, pair, dates)
+			if err != nil {
+				return err
+			}
+
+			result.StalePositions += stale
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// candleKey is the unique (date, interval, pair) of a candle
+type candleKey struct {
+	date     int64
+	pair     string
+	interval string
+}
+
+func keyOfCandleDAO(dao CandleDAO) candleKey {
+	return candleKey{date: dao.Date.UnixNano(), pair: dao.Pair, interval: dao.Interval}
+}
+
+// markStalePositions marks the computed positions of the pair whose ratio may depend on the backtest candles of the dates
+// The TP and SL hit searches read the candles from the buy date, so a candle matters from the buy date up to the ratio date
+// The positions are marked once per pair, those bought before the last date and closed after the first one
+func markStalePositions(ctx context.Context, tx bun.Tx, pair common.Pair, dates domain.DateRange) (int, error) {
+	res, err := tx.NewRaw(`
+		UPDATE positions p SET stale = true
+		FROM buy_signals bs
+		WHERE bs.id = p.buy_signal_id AND bs.workspace = p.workspace
+		  AND bs.pair = ? AND bs.date <= ?
+		  AND p.ratio_value IS NOT NULL AND p.ratio_date >= ? AND NOT p.stale
+	`, pair.String(), dates.Last, dates.First).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to mark the positions stale: %w", err)
+	}
+
+	stale, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to count the stale positions: %w", err)
+	}
+
+	return int(stale), nil
+}
+
+// QueryRevisions returns the revisions of the candle newest first, nil when the candle does not exist
+func (c *pgPersistence) QueryRevisions(ctx context.Context, id domain.ID) (*[]domain.Revision, error) {
+	exists, err := c.clientDB.NewSelect().Model((*CandleDAO)(nil)).Where("id = ?", uuid.UUID(id)).Exists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	revisionDAOs := []CandleRevisionDAO{}
+	err = c.clientDB.NewSelect().Model(&revisionDAOs).
+		Where("candle_id = ?", uuid.UUID(id)).
+		OrderExpr("revised_at DESC, id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	return revisionDAOsToRevisions(revisionDAOs), nil
+}
+
 func (c *pgPersistence) QueryCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, lastDate *time.Time, limit int) (*[]domain.Candle, bool, *time.Time, error) {
 	result := []CandleDAO{}
 	request := c.clientDB.NewSelect().Model(&result).
@@ -86,6 +296,94 @@ func (c *pgPersistence) QueryCandles(ctx context.Context, pair common.Pair, inte
 	return candlesDAOsToCandlesDetails(ctx, &result), hasMore, nextCursor, nil
 }
 
+// StreamCandles scans the candles oldest first one row at a time, so an export does not hold them in memory
+func (c *pgPersistence) StreamCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time, fn func(domain.Candle) error) error {
+	request := c.clientDB.NewSelect().Model((*CandleDAO)(nil)).
+		Where("pair = ?", pair).
+		Where("interval = ?", interval).
+		OrderExpr("date ASC")
+
+	if startDate != nil && !startDate.IsZero() {
+		request.Where("date >= ?", startDate)
+	}
+
+	if endDate != nil && !endDate.IsZero() {
+		request.Where("date <= ?", endDate)
+	}
+
+	return c.streamRows(ctx, request, fn)
+}
+
+// StreamSuspiciousCandles selects the flagged candles, and the ones the SQL conditions of domain.Candle.Check match
+// The alignment of a date is checked on the seconds since the beginning of an interval, so a sub-second date is matched too
+func (c *pgPersistence) StreamSuspiciousCandles(ctx context.Context, filter domain.QualityFilter, fn func(domain.Candle) error) error {
+	request := c.clientDB.NewSelect().Model((*CandleDAO)(nil)).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			q = q.Where("issues <> '{}'").
+				WhereOr("LEAST(open, high, low, close) <= 0").
+				WhereOr("low > LEAST(open, close) OR high < GREATEST(open, close)").
+				WhereOr("interval NOT IN (?)", bun.In(common.Intervals))
+
+			epoch := time.Unix(0, 0)
+			for _, interval := range common.Intervals {
+				origin := interval.RoundDateToBeginingOfInterval(epoch)
+				if origin == nil {
+					continue
+				}
+
+				seconds := int64(common.AddOneInterval(*origin, interval).Sub(*origin).Seconds())
+				q = q.WhereOr("interval = ? AND mod(extract(epoch FROM date)::numeric - ?, ?) <> 0", interval, origin.Unix(), seconds)
+			}
+
+			return q
+		}).
+		OrderExpr("pair ASC, interval ASC, date ASC")
+
+	if filter.Pair != "" {
+		request.Where("pair = ?", filter.Pair)
+	}
+
+	if filter.Interval != "" {
+		request.Where("interval = ?", filter.Interval)
+	}
+
+	if filter.StartDate != nil && !filter.StartDate.IsZero() {
+		request.Where("date >= ?", filter.StartDate)
+	}
+
+	if filter.EndDate != nil && !filter.EndDate.IsZero() {
+		request.Where("date <= ?", filter.EndDate)
+	}
+
+	return c.streamRows(ctx, request, fn)
+}
+
+// streamRows calls fn on the candles of the request one row at a time
+func (c *pgPersistence) streamRows(ctx context.Context, request *bun.SelectQuery, fn func(domain.Candle) error) error {
+	rows, err := request.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to perform db query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		candleDAO := CandleDAO{}
+		if err := c.clientDB.ScanRow(ctx, rows, &candleDAO); err != nil {
+			return fmt.Errorf("unable to scan candle: %w", err)
+		}
+
+		if err := fn(*candleDAOToCandleDetails(ctx, &candleDAO)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to read candles: %w", err)
+	}
+
+	return nil
+}
+
 func (c *pgPersistence) QueryCandlesFromLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate *time.Time, limit int) (*[]domain.Candle, bool, *time.Time, error) {
 	result := []CandleDAO{}
 	request := c.clientDB.NewSelect().Model(&result).
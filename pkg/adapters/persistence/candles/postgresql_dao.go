@@ -1,8 +1,12 @@
 package candles
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +30,8 @@ type CandleDAO struct {
 	High     float64
 	Low      float64
 	RSI      *json.RawMessage `bun:"type:jsonb"`
+	// Issues is never NULL, a candle without issue has an empty array
+	Issues []string `bun:",array"`
 }
 
 func candlesToCandlesDAO(ctx context.Context, candles *[]domain.Candle, isUpdate bool) *[]CandleDAO {
@@ -44,6 +50,7 @@ func candlesToCandlesDAO(ctx context.Context, candles *[]domain.Candle, isUpdate
 			Close:    c.Close,
 			High:     c.High,
 			Low:      c.Low,
+			Issues:   issuesToStrings(c.Issues),
 		}
 
 		if isUpdate {
@@ -111,6 +118,10 @@ func candleDAOToCandleDetails(ctx context.Context, candleDAO *CandleDAO) *domain
 		Low:      candleDAO.Low,
 	}
 
+	for _, issue := range candleDAO.Issues {
+		candle.Issues = append(candle.Issues, domain.Issue(issue))
+	}
+
 	if candleDAO.RSI != nil {
 		rsi := domain.RSI{}
 		err := json.Unmarshal(*candleDAO.RSI, &rsi)
@@ -121,3 +132,99 @@ func candleDAOToCandleDetails(ctx context.Context, candleDAO *CandleDAO) *domain
 
 	return &candle
 }
+
+type CandleRevisionDAO struct {
+	bun.BaseModel `bun:"table:candle_revisions"`
+
+	ID        int64     `bun:",pk,autoincrement"`
+	CandleID  uuid.UUID `bun:"type:uuid"`
+	Open      float64
+	Close     float64
+	High      float64
+	Low       float64
+	Issues    []string `bun:",array"`
+	Source    string
+	RevisedAt time.Time
+}
+
+func revisionToRevisionDAO(revision domain.Revision) CandleRevisionDAO {
+	return CandleRevisionDAO{
+		CandleID:  uuid.UUID(revision.CandleID),
+		Open:      revision.Open,
+		Close:     revision.Close,
+		High:      revision.High,
+		Low:       revision.Low,
+		Issues:    issuesToStrings(revision.Issues),
+		Source:    revision.Source,
+		RevisedAt: revision.RevisedAt,
+	}
+}
+
+func revisionDAOsToRevisions(revisionDAOs []CandleRevisionDAO) *[]domain.Revision {
+	res := make([]domain.Revision, len(revisionDAOs))
+	for i, r := range revisionDAOs {
+		res[i] = domain.Revision{
+			CandleID:  domain.ID(r.CandleID),
+			Open:      r.Open,
+			Close:     r.Close,
+			High:      r.High,
+			Low:       r.Low,
+			Source:    r.Source,
+			RevisedAt: r.RevisedAt,
+		}
+
+		for _, issue := range r.Issues {
+			res[i].Issues = append(res[i].Issues, domain.Issue(issue))
+		}
+	}
+
+	return &res
+}
+
+func issuesToStrings(issues []domain.Issue) []string {
+	res := make([]string, len(issues))
+	for i, issue := range issues {
+		res[i] = string(issue)
+	}
+
+	return res
+}
+
+// copyColumns are the columns of the COPY CSV rows, a NULL RSI is an empty field
+var copyColumns = []string{"id", "date", "pair", "interval", "open", "close", "high", "low", "rsi", "issues"}
+
+// candlesDAOToCopyCSV encodes the candles as the CSV rows of a COPY FROM STDIN
+func candlesDAOToCopyCSV(candlesDAO *[]CandleDAO) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, c := range *candlesDAO {
+		rsi := ""
+		if c.RSI != nil {
+			rsi = string(*c.RSI)
+		}
+
+		err := writer.Write([]string{
+			c.ID.String(),
+			c.Date.UTC().Format(time.RFC3339Nano),
+			c.Pair,
+			c.Interval,
+			strconv.FormatFloat(c.Open, 'g', -1, 64),
+			strconv.FormatFloat(c.Close, 'g', -1, 64),
+			strconv.FormatFloat(c.High, 'g', -1, 64),
+			strconv.FormatFloat(c.Low, 'g', -1, 64),
+			rsi,
+			// The issues are lower case words, the array literal does not quote them
+			"{" + strings.Join(c.Issues, ",") + "}",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
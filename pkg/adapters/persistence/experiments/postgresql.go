@@ -0,0 +1,114 @@
+package experiments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/experiments"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	experimentsSVC "github.com/sopial42/bifrost/pkg/services/experiments"
+)
+
+type pgPersistence struct {
+	clientDB *bun.DB
+}
+
+func NewPersistence(client *bun.DB) experimentsSVC.Persistence {
+	return &pgPersistence{clientDB: client}
+}
+
+func (p *pgPersistence) InsertExperiment(ctx context.Context, experiment *domain.Experiment) (*domain.Experiment, error) {
+	dao := experimentToExperimentDAO(experiment)
+	dao.Workspace = workspaces.GetWorkspaceFromContext(ctx)
+	_, err := p.clientDB.NewInsert().
+		Model(dao).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to insert experiment: %w", err)
+	}
+
+	return experimentDAOToExperiment(dao), nil
+}
+
+func (p *pgPersistence) UpdateExperiment(ctx context.Context, experiment *domain.Experiment) error {
+	if experiment.ID == nil {
+		return fmt.Errorf("unable to update experiment as ID is nil")
+	}
+
+	dao := experimentToExperimentDAO(experiment)
+	_, err := p.clientDB.NewUpdate().
+		Model(dao).
+		Column("status", "error", "finished_at").
+		WherePK().
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to update experiment: %w", err)
+	}
+
+	return nil
+}
+
+func (p *pgPersistence) InsertResults(ctx context.Context, experimentID domain.ID, results *[]domain.Result) error {
+	if results == nil || len(*results) == 0 {
+		return nil
+	}
+
+	daos := resultsToResultDAOs(experimentID, results)
+	_, err := p.clientDB.NewInsert().
+		Model(&daos).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to insert experiment results: %w", err)
+	}
+
+	return nil
+}
+
+func (p *pgPersistence) GetExperimentByID(ctx context.Context, id domain.ID) (*domain.Experiment, error) {
+	dao := ExperimentDAO{}
+	err := p.clientDB.NewSelect().
+		Model(&dao).
+		Relation("Results", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("rank ASC")
+		}).
+		Where("experiment_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("experiment_dao.id = ?", uuid.UUID(id)).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	return experimentDAOToExperiment(&dao), nil
+}
+
+func (p *pgPersistence) FailRunningExperiments(ctx context.Context, reason string, finishedAt time.Time) (int, error) {
+	res, err := p.clientDB.NewUpdate().
+		Model((*ExperimentDAO)(nil)).
+		Set("status = ?", string(domain.StatusFailed)).
+		Set("error = ?", reason).
+		Set("finished_at = ?", finishedAt).
+		Where("status = ?", string(domain.StatusRunning)).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to fail the running experiments: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to count the failed experiments: %w", err)
+	}
+
+	return int(count), nil
+}
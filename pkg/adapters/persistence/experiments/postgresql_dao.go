@@ -0,0 +1,134 @@
+package experiments
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	domain "github.com/sopial42/bifrost/pkg/domains/experiments"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+type ExperimentDAO struct {
+	bun.BaseModel `bun:"table:experiments"`
+
+	ID         uuid.UUID             `bun:",pk,type:uuid,default:uuid_generate_v4()"`
+	Workspace  workspaces.Workspace  `bun:"workspace"`
+	Status     string                `bun:"status"`
+	Request    domain.Request        `bun:"request,type:jsonb"`
+	Error      string                `bun:"error,nullzero"`
+	CreatedAt  time.Time             `bun:"created_at"`
+	FinishedAt *time.Time            `bun:"finished_at,nullzero"`
+	Results    []ExperimentResultDAO `bun:"rel:has-many,join:id=experiment_id"`
+}
+
+type ExperimentResultDAO struct {
+	bun.BaseModel `bun:"table:experiment_results"`
+
+	ID                uuid.UUID `bun:",pk,type:uuid,default:uuid_generate_v4()"`
+	ExperimentID      uuid.UUID `bun:"type:uuid"`
+	Rank              int       `bun:"rank"`
+	Pair              string    `bun:"pair"`
+	Interval          string    `bun:"interval"`
+	BuySignalFullname string    `bun:"buy_signal_fullname"`
+	PositionFullname  string    `bun:"position_fullname"`
+	WinlossRatio      *float64  `bun:"winloss_ratio,nullzero"`
+	Trades            int       `bun:"trades"`
+	Open              int       `bun:"open"`
+	Wins              int       `bun:"wins"`
+	Losses            int       `bun:"losses"`
+	WinRate           float64   `bun:"win_rate"`
+	AvgWin            float64   `bun:"avg_win"`
+	AvgLoss           float64   `bun:"avg_loss"`
+	Expectancy        float64   `bun:"expectancy"`
+	TotalReturn       float64   `bun:"total_return"`
+	ProfitFactor      float64   `bun:"profit_factor"`
+}
+
+func experimentToExperimentDAO(experiment *domain.Experiment) *ExperimentDAO {
+	dao := &ExperimentDAO{
+		Status:     string(experiment.Status),
+		Request:    experiment.Request,
+		Error:      experiment.Error,
+		CreatedAt:  experiment.CreatedAt,
+		FinishedAt: experiment.FinishedAt,
+	}
+
+	if experiment.ID != nil {
+		dao.ID = uuid.UUID(*experiment.ID)
+	} else {
+		dao.ID = uuid.New()
+	}
+
+	return dao
+}
+
+func experimentDAOToExperiment(dao *ExperimentDAO) *domain.Experiment {
+	id := domain.ID(dao.ID)
+	experiment := &domain.Experiment{
+		ID:         &id,
+		Status:     domain.Status(dao.Status),
+		Request:    dao.Request,
+		Error:      dao.Error,
+		CreatedAt:  dao.CreatedAt,
+		FinishedAt: dao.FinishedAt,
+		Results:    make([]domain.Result, len(dao.Results)),
+	}
+
+	for i, r := range dao.Results {
+		experiment.Results[i] = domain.Result{
+			Rank:              r.Rank,
+			Pair:              common.Pair(r.Pair),
+			Interval:          common.Interval(r.Interval),
+			BuySignalFullname: buysignals.Fullname(r.BuySignalFullname),
+			PositionFullname:  positions.Fullname(r.PositionFullname),
+			WinlossRatio:      (*positions.WinLossRatio)(r.WinlossRatio),
+			Performance: positions.Performance{
+				Trades:       r.Trades,
+				Open:         r.Open,
+				Wins:         r.Wins,
+				Losses:       r.Losses,
+				WinRate:      r.WinRate,
+				AvgWin:       r.AvgWin,
+				AvgLoss:      r.AvgLoss,
+				Expectancy:   r.Expectancy,
+				TotalReturn:  r.TotalReturn,
+				ProfitFactor: r.ProfitFactor,
+			},
+		}
+	}
+
+	return experiment
+}
+
+func resultsToResultDAOs(experimentID domain.ID, results *[]domain.Result) []ExperimentResultDAO {
+	daos := make([]ExperimentResultDAO, len(*results))
+	for i, r := range *results {
+		daos[i] = ExperimentResultDAO{
+			ID:                uuid.New(),
+			ExperimentID:      uuid.UUID(experimentID),
+			Rank:              r.Rank,
+			Pair:              r.Pair.String(),
+			Interval:          r.Interval.String(),
+			BuySignalFullname: string(r.BuySignalFullname),
+			PositionFullname:  string(r.PositionFullname),
+			WinlossRatio:      (*float64)(r.WinlossRatio),
+			Trades:            r.Trades,
+			Open:              r.Open,
+			Wins:              r.Wins,
+			Losses:            r.Losses,
+			WinRate:           r.WinRate,
+			AvgWin:            r.AvgWin,
+			AvgLoss:           r.AvgLoss,
+			Expectancy:        r.Expectancy,
+			TotalReturn:       r.TotalReturn,
+			ProfitFactor:      r.ProfitFactor,
+		}
+	}
+
+	return daos
+}
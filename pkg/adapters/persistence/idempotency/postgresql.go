@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+	idempotencySVC "github.com/sopial42/bifrost/pkg/services/idempotency"
+)
+
+type pgPersistence struct {
+	clientDB *bun.DB
+}
+
+func NewPersistence(client *bun.DB) idempotencySVC.Persistence {
+	return &pgPersistence{clientDB: client}
+}
+
+// ReserveKey inserts the record, or replaces an expired one, the primary key serializes the concurrent reservations
+func (p *pgPersistence) ReserveKey(ctx context.Context, record *domain.Record) (*domain.Record, bool, error) {
+	dao := recordToIdempotencyKeyDAO(record)
+	reserved := []IdempotencyKeyDAO{}
+	err := p.clientDB.NewInsert().
+		Model(dao).
+		On("CONFLICT (workspace, idempotency_key) DO UPDATE").
+		Set("fingerprint = EXCLUDED.fingerprint").
+		Set("status = EXCLUDED.status").
+		Set("content_type = EXCLUDED.content_type").
+		Set("body = EXCLUDED.body").
+		Set("created_at = EXCLUDED.created_at").
+		Set("token = EXCLUDED.token").
+		Set("expires_at = EXCLUDED.expires_at").
+		Where("idempotency_key_dao.expires_at <= EXCLUDED.created_at").
+		Returning("*").
+		Scan(ctx, &reserved)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to insert idempotency key: %w", err)
+	}
+
+	if len(reserved) > 0 {
+		return idempotencyKeyDAOToRecord(&reserved[0]), true, nil
+	}
+
+	existing := IdempotencyKeyDAO{}
+	err = p.clientDB.NewSelect().
+		Model(&existing).
+		Where("workspace = ?", record.Workspace).
+		Where("idempotency_key = ?", record.Key).
+		Scan(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	return idempotencyKeyDAOToRecord(&existing), false, nil
+}
+
+// CompleteKey and DeleteKey match the token, a request that outlived its lock leaves the record of the next reservation untouched
+func (p *pgPersistence) CompleteKey(ctx context.Context, record *domain.Record) (bool, error) {
+	res, err := p.clientDB.NewUpdate().
+		Model((*IdempotencyKeyDAO)(nil)).
+		Set("status = ?", record.Status).
+		Set("content_type = ?", record.ContentType).
+		Set("body = ?", record.Body).
+		Set("expires_at = ?", record.ExpiresAt).
+		Where("workspace = ?", record.Workspace).
+		Where("idempotency_key = ?", record.Key).
+		Where("token = ?", record.Token).
+		Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to update idempotency key: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("unable to count the updated idempotency keys: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (p *pgPersistence) DeleteKey(ctx context.Context, record *domain.Record) error {
+	_, err := p.clientDB.NewDelete().
+		Model((*IdempotencyKeyDAO)(nil)).
+		Where("workspace = ?", record.Workspace).
+		Where("idempotency_key = ?", record.Key).
+		Where("token = ?", record.Token).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (p *pgPersistence) DeleteExpiredKeys(ctx context.Context, date time.Time) (int, error) {
+	res, err := p.clientDB.NewDelete().
+		Model((*IdempotencyKeyDAO)(nil)).
+		Where("expires_at <= ?", date).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete expired idempotency keys: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("unable to count the deleted idempotency keys: %w", err)
+	}
+
+	return int(count), nil
+}
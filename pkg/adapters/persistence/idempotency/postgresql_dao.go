@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+type IdempotencyKeyDAO struct {
+	bun.BaseModel `bun:"table:idempotency_keys,alias:idempotency_key_dao"`
+
+	Workspace      workspaces.Workspace `bun:"workspace,pk"`
+	IdempotencyKey domain.Key           `bun:"idempotency_key,pk"`
+	Fingerprint    string               `bun:"fingerprint"`
+	Status         int                  `bun:"status"`
+	ContentType    string               `bun:"content_type"`
+	Body           []byte               `bun:"body,type:bytea"`
+	CreatedAt      time.Time            `bun:"created_at"`
+	Token          uuid.UUID            `bun:"token,type:uuid"`
+	ExpiresAt      time.Time            `bun:"expires_at"`
+}
+
+func recordToIdempotencyKeyDAO(record *domain.Record) *IdempotencyKeyDAO {
+	return &IdempotencyKeyDAO{
+		Workspace:      record.Workspace,
+		IdempotencyKey: record.Key,
+		Fingerprint:    record.Fingerprint,
+		Status:         record.Status,
+		ContentType:    record.ContentType,
+		Body:           record.Body,
+		CreatedAt:      record.CreatedAt,
+		Token:          record.Token,
+		ExpiresAt:      record.ExpiresAt,
+	}
+}
+
+func idempotencyKeyDAOToRecord(dao *IdempotencyKeyDAO) *domain.Record {
+	return &domain.Record{
+		Workspace:   dao.Workspace,
+		Key:         dao.IdempotencyKey,
+		Fingerprint: dao.Fingerprint,
+		Status:      dao.Status,
+		ContentType: dao.ContentType,
+		Body:        dao.Body,
+		CreatedAt:   dao.CreatedAt.UTC(),
+		Token:       dao.Token,
+		ExpiresAt:   dao.ExpiresAt.UTC(),
+	}
+}
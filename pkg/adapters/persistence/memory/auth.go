@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+)
+
+type authPersistence struct {
+	store *Store
+}
+
+func NewAuthPersistence(store *Store) authSVC.Persistence {
+	return &authPersistence{store: store}
+}
+
+// InsertAPIKey refuses a prefix already used, as the unique prefix constraint does
+func (p *authPersistence) InsertAPIKey(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	for _, stored := range p.store.apiKeys {
+		if stored.Prefix == key.Prefix {
+			return nil, appErrors.NewAlreadyExists("api key prefix already exists")
+		}
+	}
+
+	id := domain.ID(uuid.New())
+	stored := *key
+	stored.ID = &id
+	stored.Scopes = append([]domain.Scope(nil), key.Scopes...)
+	p.store.apiKeys[uuid.UUID(id)] = stored
+	return &stored, nil
+}
+
+func (p *authPersistence) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	for _, stored := range p.store.apiKeys {
+		if stored.Prefix == prefix {
+			return &stored, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ListAPIKeys and RevokeAPIKey are limited to the request workspace unless the request key is a global admin one, as the Postgres queries
+func (p *authPersistence) ListAPIKeys(ctx context.Context) (*[]domain.APIKey, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	keys := []domain.APIKey{}
+	for _, stored := range p.store.apiKeys {
+		if p.visible(ctx, stored) {
+			keys = append(keys, stored)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return &keys, nil
+}
+
+func (p *authPersistence) RevokeAPIKey(ctx context.Context, id domain.ID) (*domain.APIKey, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	stored, found := p.store.apiKeys[uuid.UUID(id)]
+	if !found || !p.visible(ctx, stored) {
+		return nil, nil
+	}
+
+	// A key already revoked keeps its first revocation date
+	if stored.RevokedAt == nil {
+		revokedAt := time.Now().UTC()
+		stored.RevokedAt = &revokedAt
+		p.store.apiKeys[uuid.UUID(id)] = stored
+	}
+
+	return &stored, nil
+}
+
+func (p *authPersistence) visible(ctx context.Context, key domain.APIKey) bool {
+	return domain.ManagesAllWorkspaces(ctx) || key.Workspace == workspaces.GetWorkspaceFromContext(ctx)
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
+)
+
+type buySignalsPersistence struct {
+	store *Store
+}
+
+func NewBuySignalsPersistence(store *Store) buySignalsSVC.Persistence {
+	return &buySignalsPersistence{store: store}
+}
+
+type buySignalKey struct {
+	workspace  workspaces.Workspace
+	businessID domain.BusinessID
+	pair       common.Pair
+	interval   common.Interval
+	fullname   domain.Fullname
+}
+
+func keyOfBuySignal(workspace workspaces.Workspace, bs domain.Details) buySignalKey {
+	return buySignalKey{workspace: workspace, businessID: bs.BusinessID, pair: bs.Pair, interval: bs.Interval, fullname: bs.Fullname}
+}
+
+// findBuySignal returns the stored buy signal with the same unique key, the caller holds the lock
+func (b *buySignalsPersistence) findBuySignal(key buySignalKey) (domain.Details, bool) {
+	id, found := b.store.buySignalKeys[key]
+	if !found {
+		return domain.Details{}, false
+	}
+
+	return b.store.buySignals[id], true
+}
+
+// storeBuySignal stores a new buy signal with its workspace and unique key, the caller holds the lock
+func (b *buySignalsPersistence) storeBuySignal(workspace workspaces.Workspace, bs domain.Details) {
+	id := uuid.UUID(*bs.ID)
+	b.store.buySignals[id] = bs
+	b.store.buySignalWorkspaces[id] = workspace
+	b.store.buySignalKeys[keyOfBuySignal(workspace, bs)] = id
+}
+
+// InsertBuySignals inserts the buy signals, the existing ones are ignored as ON CONFLICT DO NOTHING does
+func (b *buySignalsPersistence) InsertBuySignals(ctx context.Context, bsReports *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error) {
+	if bsReports == nil || len(*bsReports) == 0 {
+		return &[]domain.Details{}, nil, fmt.Errorf("unable to insert buy signal, nil or empty")
+	}
+
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	inserted := make([]domain.Details, 0, len(*bsReports))
+	results := make([]common.ItemResult[domain.ID], len(*bsReports))
+	for i, bs := range *bsReports {
+		// A buy signal inserted earlier in the batch is found too, as in a single insert statement
+		if stored, found := b.findBuySignal(keyOfBuySignal(workspace, bs)); found {
+			existingID := *stored.ID
+			results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeDuplicate, ID: &existingID}
+			continue
+		}
+
+		id := domain.ID(uuid.New())
+		newBuySignal := bs
+		newBuySignal.ID = &id
+		newBuySignal.Date = domain.Date(time.Time(bs.Date).UTC())
+		b.storeBuySignal(workspace, newBuySignal)
+		inserted = append(inserted, newBuySignal)
+		results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeCreated, ID: &id}
+	}
+
+	return &inserted, results, nil
+}
+
+// UpsertBuySignals updates the price and the metadata of the buy signal with the same unique key, or inserts it
+func (b *buySignalsPersistence) UpsertBuySignals(ctx context.Context, bs domain.Details) (*[]domain.Details, error) {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	if stored, found := b.findBuySignal(keyOfBuySignal(workspace, bs)); found {
+		stored.Price = bs.Price
+		stored.Metadata = bs.Metadata
+		b.store.buySignals[uuid.UUID(*stored.ID)] = stored
+		return &[]domain.Details{stored}, nil
+	}
+
+	id := domain.ID(uuid.New())
+	if bs.ID != nil && uuid.UUID(*bs.ID) != uuid.Nil {
+		id = *bs.ID
+	}
+
+	if _, found := b.store.buySignals[uuid.UUID(id)]; found {
+		return &[]domain.Details{}, fmt.Errorf("unable to upsert buy signals: id %s already exists", id)
+	}
+
+	newBuySignal := bs
+	newBuySignal.ID = &id
+	newBuySignal.Date = domain.Date(time.Time(bs.Date).UTC())
+	b.storeBuySignal(workspace, newBuySignal)
+	return &[]domain.Details{newBuySignal}, nil
+}
+
+func (b *buySignalsPersistence) QueryBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, firstDate *time.Time, limit int) (*[]domain.Details, bool, *time.Time, error) {
+	b.store.mu.RLock()
+	defer b.store.mu.RUnlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	res := make([]domain.Details, 0)
+	for id, bs := range b.store.buySignals {
+		if b.store.buySignalWorkspaces[id] != workspace || bs.Pair != pair || bs.Interval != interval || bs.Name != name {
+			continue
+		}
+
+		if firstDate != nil && !firstDate.IsZero() && time.Time(bs.Date).Before(*firstDate) {
+			continue
+		}
+
+		res = append(res, bs)
+	}
+	sortBuySignals(res)
+
+	if limit <= 0 {
+		return &res, false, nil, nil
+	}
+
+	hasMore := len(res) > limit
+	var nextCursor *time.Time
+	if hasMore {
+		next := time.Time(res[limit].Date)
+		nextCursor = &next
+		res = res[:limit]
+	}
+
+	return &res, hasMore, nextCursor, nil
+}
+
+func (b *buySignalsPersistence) QueryBuySignalsByIDs(ctx context.Context, ids []domain.ID) (*[]domain.Details, error) {
+	b.store.mu.RLock()
+	defer b.store.mu.RUnlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	res := make([]domain.Details, 0, len(ids))
+	seen := make(map[domain.ID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if bs, found := b.store.buySignals[uuid.UUID(id)]; found && b.store.buySignalWorkspaces[uuid.UUID(id)] == workspace {
+			res = append(res, bs)
+		}
+	}
+	sortBuySignals(res)
+
+	return &res, nil
+}
+
+// sortBuySignals sorts by date, the ID breaks ties so the order is stable between calls
+func sortBuySignals(buySignals []domain.Details) {
+	sort.Slice(buySignals, func(i, j int) bool {
+		di, dj := time.Time(buySignals[i].Date), time.Time(buySignals[j].Date)
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+
+		return buySignals[i].ID.String() < buySignals[j].ID.String()
+	})
+}
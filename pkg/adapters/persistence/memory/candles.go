@@ -0,0 +1,415 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+)
+
+const backtestInterval = common.M1
+
+type candlesPersistence struct {
+	store *Store
+}
+
+func NewCandlesPersistence(store *Store) candlesSVC.Persistence {
+	return &candlesPersistence{store: store}
+}
+
+type candleKey struct {
+	date     int64
+	pair     common.Pair
+	interval common.Interval
+}
+
+func keyOfCandle(c domain.Candle) candleKey {
+	return candleKey{date: time.Time(c.Date).UnixNano(), pair: c.Pair, interval: c.Interval}
+}
+
+// InsertCandles ignores the candles that already exist, as the unique (date, interval, pair) constraint does
+// Returns only the newly inserted candles
+func (c *candlesPersistence) InsertCandles(ctx context.Context, candles *[]domain.Candle) (*[]domain.Candle, error) {
+	if candles == nil {
+		return &[]domain.Candle{}, nil
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	existing := make(map[candleKey]bool, len(c.store.candles))
+	for _, candle := range c.store.candles {
+		existing[keyOfCandle(candle)] = true
+	}
+
+	inserted := make([]domain.Candle, 0, len(*candles))
+	for _, candle := range *candles {
+		key := keyOfCandle(candle)
+		if existing[key] {
+			continue
+		}
+
+		id := uuid.New()
+		newCandle := candle
+		newCandle.ID = (*domain.ID)(&id)
+		newCandle.Date = domain.Date(time.Time(candle.Date).UTC())
+		newCandle.RSI = copyRSI(candle.RSI)
+		newCandle.Issues = append([]domain.Issue(nil), candle.Issues...)
+
+		c.store.candles[id] = newCandle
+		existing[key] = true
+		inserted = append(inserted, newCandle)
+	}
+
+	return &inserted, nil
+}
+
+// UpdateCandlesRSI updates the RSI of the candles found by ID, other candles are ignored
+func (c *candlesPersistence) UpdateCandlesRSI(ctx context.Context, candles *[]domain.Candle) (*[]domain.Candle, error) {
+	if candles == nil {
+		return &[]domain.Candle{}, nil
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	updated := make([]domain.Candle, 0, len(*candles))
+	for _, candle := range *candles {
+		if candle.ID == nil {
+			continue
+		}
+
+		stored, ok := c.store.candles[uuid.UUID(*candle.ID)]
+		if !ok {
+			continue
+		}
+
+		stored.RSI = copyRSI(candle.RSI)
+		c.store.candles[uuid.UUID(*candle.ID)] = stored
+		updated = append(updated, stored)
+	}
+
+	return &updated, nil
+}
+
+// CorrectCandles upserts the candles under the store lock, as the Postgres transaction does
+func (c *candlesPersistence) CorrectCandles(ctx context.Context, source string, candles *[]domain.Candle) (*domain.CorrectionResult, error) {
+	result := &domain.CorrectionResult{Candles: []domain.Candle{}}
+	if candles == nil {
+		return result, nil
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	existing := make(map[candleKey]uuid.UUID, len(c.store.candles))
+	for id, candle := range c.store.candles {
+		existing[keyOfCandle(candle)] = id
+	}
+
+	revisedAt := time.Now().UTC()
+	for _, candle := range *candles {
+		key := keyOfCandle(candle)
+		corrected := candle
+		corrected.Date = domain.Date(time.Time(candle.Date).UTC())
+		corrected.Issues = append([]domain.Issue(nil), candle.Issues...)
+		id, found := existing[key]
+		if found {
+			previous := c.store.candles[id]
+			if previous.SamePrices(corrected) {
+				result.Unchanged++
+				continue
+			}
+
+			c.store.candleRevisions[id] = append(c.store.candleRevisions[id], domain.RevisionOf(previous, source, revisedAt))
+			corrected.RSI = previous.RSI
+			result.Corrected++
+		} else {
+			id = uuid.New()
+			corrected.RSI = copyRSI(candle.RSI)
+			existing[key] = id
+			result.Inserted++
+		}
+
+		corrected.ID = (*domain.ID)(&id)
+		c.store.candles[id] = corrected
+		result.Candles = append(result.Candles, corrected)
+	}
+
+	for pair, dates := range domain.RangesByPair(result.Candles, backtestInterval) {
+		result.StalePositions += c.markStalePositions(pair, dates)
+	}
+
+	return result, nil
+}
+
+// markStalePositions marks the computed positions of all the workspaces whose ratio may depend on the backtest candles of the pair, the caller holds the lock
+// As the Postgres update, the positions bought before the last date and closed after the first one are marked
+func (c *candlesPersistence) markStalePositions(pair common.Pair, dates domain.DateRange) int {
+	stale := 0
+	for id, pos := range c.store.positions {
+		bs, found := c.store.buySignals[uuid.UUID(pos.BuySignalID)]
+		if !found || bs.Pair != pair || pos.Ratio == nil || pos.Stale {
+			continue
+		}
+
+		if time.Time(bs.Date).After(dates.Last) || time.Time(pos.Ratio.Date).Before(dates.First) {
+			continue
+		}
+
+		pos.Stale = true
+		c.store.positions[id] = pos
+		stale++
+	}
+
+	return stale
+}
+
+func (c *candlesPersistence) QueryRevisions(ctx context.Context, id domain.ID) (*[]domain.Revision, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	if _, found := c.store.candles[uuid.UUID(id)]; !found {
+		return nil, nil
+	}
+
+	stored := c.store.candleRevisions[uuid.UUID(id)]
+	revisions := make([]domain.Revision, len(stored))
+	for i, revision := range stored {
+		revisions[len(stored)-1-i] = revision
+	}
+
+	return &revisions, nil
+}
+
+// sortedCandles returns the candles of the pair and interval sorted by date, the caller holds the lock
+func (c *candlesPersistence) sortedCandles(pair common.Pair, interval common.Interval) []domain.Candle {
+	res := make([]domain.Candle, 0)
+	for _, candle := range c.store.candles {
+		if candle.Pair == pair && candle.Interval == interval {
+			res = append(res, candle)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return time.Time(res[i].Date).Before(time.Time(res[j].Date))
+	})
+
+	return res
+}
+
+// CopyCandles is InsertCandles, the staging table of the bulk load only matters to PostgreSQL
+func (c *candlesPersistence) CopyCandles(ctx context.Context, candles *[]domain.Candle) (int, error) {
+	inserted, err := c.InsertCandles(ctx, candles)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(*inserted), nil
+}
+
+func (c *candlesPersistence) QueryCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, lastDate *time.Time, limit int) (*[]domain.Candle, bool, *time.Time, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	res := make([]domain.Candle, 0)
+	for _, candle := range c.sortedCandles(pair, interval) {
+		date := time.Time(candle.Date)
+		if startDate != nil && !startDate.IsZero() && date.Before(*startDate) {
+			continue
+		}
+
+		if lastDate != nil && !lastDate.IsZero() && date.After(*lastDate) {
+			continue
+		}
+
+		res = append(res, candle)
+	}
+
+	if limit <= 0 {
+		return &res, false, nil, nil
+	}
+
+	// The cursor is the first candle of the next page
+	hasMore := len(res) > limit
+	var nextCursor *time.Time
+	if hasMore {
+		next := time.Time(res[limit].Date)
+		nextCursor = &next
+		res = res[:limit]
+	}
+
+	return &res, hasMore, nextCursor, nil
+}
+
+// StreamCandles calls fn on a copy of the candles, the lock is not held by fn
+func (c *candlesPersistence) StreamCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time, fn func(domain.Candle) error) error {
+	candles, _, _, err := c.QueryCandles(ctx, pair, interval, startDate, endDate, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, candle := range *candles {
+		if err := fn(candle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StreamSuspiciousCandles calls fn on a copy of all the candles of the filter, the service checks them
+func (c *candlesPersistence) StreamSuspiciousCandles(ctx context.Context, filter domain.QualityFilter, fn func(domain.Candle) error) error {
+	c.store.mu.RLock()
+	candles := make([]domain.Candle, 0)
+	for _, candle := range c.store.candles {
+		date := time.Time(candle.Date)
+		if (filter.Pair != "" && candle.Pair != filter.Pair) || (filter.Interval != "" && candle.Interval != filter.Interval) ||
+			(filter.StartDate != nil && date.Before(*filter.StartDate)) || (filter.EndDate != nil && date.After(*filter.EndDate)) {
+			continue
+		}
+
+		candles = append(candles, candle)
+	}
+	c.store.mu.RUnlock()
+
+	sort.Slice(candles, func(i, j int) bool {
+		if candles[i].Pair != candles[j].Pair {
+			return candles[i].Pair < candles[j].Pair
+		}
+
+		if candles[i].Interval != candles[j].Interval {
+			return candles[i].Interval < candles[j].Interval
+		}
+
+		return time.Time(candles[i].Date).Before(time.Time(candles[j].Date))
+	})
+
+	for _, candle := range candles {
+		if err := fn(candle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *candlesPersistence) QueryCandlesFromLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate *time.Time, limit int) (*[]domain.Candle, bool, *time.Time, error) {
+	if lastDate == nil || lastDate.IsZero() {
+		return nil, false, nil, fmt.Errorf("last_date is required")
+	}
+
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	res := make([]domain.Candle, 0)
+	for _, candle := range c.sortedCandles(pair, interval) {
+		if !time.Time(candle.Date).After(*lastDate) {
+			res = append(res, candle)
+		}
+	}
+
+	if limit <= 0 {
+		return &res, false, nil, nil
+	}
+
+	// The cursor is the last candle of the previous page
+	hasMore := len(res) > limit
+	var nextCursor *time.Time
+	if hasMore {
+		res = res[len(res)-limit-1:]
+		next := time.Time(res[0].Date)
+		nextCursor = &next
+		res = res[1:]
+	}
+
+	return &res, hasMore, nextCursor, nil
+}
+
+func (c *candlesPersistence) QueryCandlesPriceByDate(ctx context.Context, pair common.Pair, date domain.Date) (float64, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	for _, candle := range c.store.candles {
+		if candle.Pair == pair && candle.Interval == backtestInterval && time.Time(candle.Date).Equal(time.Time(date)) {
+			return candle.Close, nil
+		}
+	}
+
+	return 0.0, nil
+}
+
+func (c *candlesPersistence) QueryCandlesThatHitTPOrSL(ctx context.Context, pair common.Pair, buyDate domain.Date, tp float64, sl float64) (*domain.Candle, *domain.Candle, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	backtestCandles := make([]domain.Candle, 0)
+	for _, candle := range c.sortedCandles(pair, backtestInterval) {
+		if !time.Time(candle.Date).Before(time.Time(buyDate)) {
+			backtestCandles = append(backtestCandles, candle)
+		}
+	}
+
+	if len(backtestCandles) == 0 {
+		now := time.Now()
+		buyDateTime := time.Time(buyDate)
+
+		// As the Postgres persistence, missing data of today or yesterday is not an error
+		if buyDateTime.Year() == now.Year() && buyDateTime.Month() == now.Month() && now.Day()-buyDateTime.Day() <= 1 {
+			return nil, nil, nil
+		}
+
+		return nil, nil, fmt.Errorf("no candles found for the backtest. Pair: %s, interval: %s, buyDate: %s", pair, backtestInterval, buyDate.String())
+	}
+
+	var tpCandle, slCandle *domain.Candle
+	for i := range backtestCandles {
+		candle := backtestCandles[i]
+		if tpCandle == nil && candle.High >= tp {
+			tpCandle = &candle
+		}
+
+		if slCandle == nil && candle.Low <= sl {
+			slCandle = &candle
+		}
+
+		if tpCandle != nil && slCandle != nil {
+			break
+		}
+	}
+
+	return tpCandle, slCandle, nil
+}
+
+func (c *candlesPersistence) QuerySurroundingDates(ctx context.Context, pair common.Pair, interval common.Interval) (*domain.Date, *domain.Date, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	sorted := c.sortedCandles(pair, interval)
+	if len(sorted) == 0 {
+		return nil, nil, nil
+	}
+
+	first := sorted[0].Date
+	last := sorted[len(sorted)-1].Date
+	return &first, &last, nil
+}
+
+func copyRSI(rsi *domain.RSI) *domain.RSI {
+	if rsi == nil {
+		return nil
+	}
+
+	res := make(domain.RSI, len(*rsi))
+	for k, v := range *rsi {
+		res[k] = v
+	}
+
+	return &res
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	idempotencySVC "github.com/sopial42/bifrost/pkg/services/idempotency"
+)
+
+type idempotencyPersistence struct {
+	store *Store
+}
+
+func NewIdempotencyPersistence(store *Store) idempotencySVC.Persistence {
+	return &idempotencyPersistence{store: store}
+}
+
+// idempotencyKey is the (workspace, idempotency_key) primary key
+type idempotencyKey struct {
+	workspace workspaces.Workspace
+	key       domain.Key
+}
+
+// ReserveKey inserts the record, or replaces an expired one
+func (p *idempotencyPersistence) ReserveKey(ctx context.Context, record *domain.Record) (*domain.Record, bool, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	key := idempotencyKey{workspace: record.Workspace, key: record.Key}
+	if stored, found := p.store.idempotencyKeys[key]; found && !stored.IsExpired(record.CreatedAt) {
+		return &stored, false, nil
+	}
+
+	stored := *record
+	stored.Body = append([]byte(nil), record.Body...)
+	p.store.idempotencyKeys[key] = stored
+	return &stored, true, nil
+}
+
+// CompleteKey and DeleteKey match the token, a request that outlived its lock leaves the record of the next reservation untouched
+func (p *idempotencyPersistence) CompleteKey(ctx context.Context, record *domain.Record) (bool, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	key := idempotencyKey{workspace: record.Workspace, key: record.Key}
+	stored, found := p.store.idempotencyKeys[key]
+	if !found || stored.Token != record.Token {
+		return false, nil
+	}
+
+	stored.Status = record.Status
+	stored.ContentType = record.ContentType
+	stored.Body = append([]byte(nil), record.Body...)
+	stored.ExpiresAt = record.ExpiresAt
+	p.store.idempotencyKeys[key] = stored
+	return true, nil
+}
+
+func (p *idempotencyPersistence) DeleteKey(ctx context.Context, record *domain.Record) error {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	key := idempotencyKey{workspace: record.Workspace, key: record.Key}
+	if stored, found := p.store.idempotencyKeys[key]; found && stored.Token == record.Token {
+		delete(p.store.idempotencyKeys, key)
+	}
+
+	return nil
+}
+
+func (p *idempotencyPersistence) DeleteExpiredKeys(ctx context.Context, date time.Time) (int, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	count := 0
+	for key, stored := range p.store.idempotencyKeys {
+		if stored.IsExpired(date) {
+			delete(p.store.idempotencyKeys, key)
+			count++
+		}
+	}
+
+	return count, nil
+}
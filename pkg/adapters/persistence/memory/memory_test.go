@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+var buyDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestQueryCandlesThatHitTPOrSL(t *testing.T) {
+	ctx := context.Background()
+	persistence := NewCandlesPersistence(NewStore())
+
+	minuteCandles := []candles.Candle{}
+	for i, hl := range [][2]float64{{101, 99}, {106, 98}, {104, 94}} {
+		minuteCandles = append(minuteCandles, candles.Candle{
+			Pair:     "SOLUSDC",
+			Interval: common.M1,
+			Date:     candles.Date(buyDate.Add(time.Duration(i) * time.Minute)),
+			High:     hl[0],
+			Low:      hl[1],
+		})
+	}
+
+	if _, err := persistence.InsertCandles(ctx, &minuteCandles); err != nil {
+		t.Fatalf("InsertCandles() error = %v", err)
+	}
+
+	tp, sl, err := persistence.QueryCandlesThatHitTPOrSL(ctx, "SOLUSDC", candles.Date(buyDate), 105, 95)
+	if err != nil {
+		t.Fatalf("QueryCandlesThatHitTPOrSL() error = %v", err)
+	}
+
+	if tp == nil || !time.Time(tp.Date).Equal(buyDate.Add(time.Minute)) {
+		t.Errorf("QueryCandlesThatHitTPOrSL() tp candle = %+v, want the second candle", tp)
+	}
+
+	if sl == nil || !time.Time(sl.Date).Equal(buyDate.Add(2*time.Minute)) {
+		t.Errorf("QueryCandlesThatHitTPOrSL() sl candle = %+v, want the third candle", sl)
+	}
+
+	if _, _, err := persistence.QueryCandlesThatHitTPOrSL(ctx, "SOLUSDC", candles.Date(buyDate.Add(time.Hour)), 105, 95); err == nil {
+		t.Errorf("QueryCandlesThatHitTPOrSL() without backtest candles should fail")
+	}
+}
+
+func TestInsertPositions(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	buySignals, _, err := NewBuySignalsPersistence(store).InsertBuySignals(ctx, &[]bsDomain.Details{{
+		BusinessID: "1",
+		Name:       "morningStar",
+		Fullname:   "morningStar_b0.6_s0.3_p0.5_t3",
+		Pair:       "SOLUSDC",
+		Interval:   common.H1,
+		Date:       bsDomain.Date(buyDate),
+	}})
+	if err != nil {
+		t.Fatalf("InsertBuySignals() error = %v", err)
+	}
+
+	persistence := NewPositionsPersistence(store)
+	winloss := positions.WinLossRatio(2)
+	newPosition := func(winloss *positions.WinLossRatio) positions.Details {
+		return positions.Details{
+			BuySignalID:  *(*buySignals)[0].ID,
+			Name:         positions.PercentageName,
+			Fullname:     "percentage_tp5",
+			TP:           105.12345,
+			SL:           97.5678,
+			WinlossRatio: winloss,
+		}
+	}
+
+	inserted, _, err := persistence.InsertPositions(ctx, &[]positions.Details{newPosition(&winloss), newPosition(nil), newPosition(nil)})
+	if err != nil {
+		t.Fatalf("InsertPositions() error = %v", err)
+	}
+
+	if (*inserted)[0].TP != 105.12 || (*inserted)[0].SL != 97.56 {
+		t.Errorf("InsertPositions() tp, sl = %v, %v, want truncated values", (*inserted)[0].TP, (*inserted)[0].SL)
+	}
+
+	// The duplicate is ignored, the position without winloss ratio never conflicts
+	_, results, err := persistence.InsertPositions(ctx, &[]positions.Details{newPosition(nil), newPosition(&winloss)})
+	if err != nil {
+		t.Fatalf("InsertPositions() error = %v", err)
+	}
+
+	if len(results) != 2 || results[0].Outcome != common.OutcomeCreated || results[1].Outcome != common.OutcomeDuplicate || results[1].Index != 1 {
+		t.Fatalf("InsertPositions() results = %+v, want created then duplicate", results)
+	}
+
+	if *results[1].ID != *(*inserted)[0].ID {
+		t.Errorf("InsertPositions() duplicate ID = %s, want the existing %s", results[1].ID, (*inserted)[0].ID)
+	}
+
+	count, err := persistence.GetPositionsWithNoRatioCount(ctx)
+	if err != nil || count != 4 {
+		t.Fatalf("GetPositionsWithNoRatioCount() = %d, %v, want 4", count, err)
+	}
+
+	page, hasMore, nextCursor, err := persistence.GetPositionsWithNoRatio(ctx, new(int64), 2)
+	if err != nil || len(*page) != 2 || !hasMore || nextCursor == nil || *nextCursor != int64((*inserted)[2].SerialID) {
+		t.Fatalf("GetPositionsWithNoRatio() = %v, %v, %v, %v", page, hasMore, nextCursor, err)
+	}
+
+	if (*page)[0].BuySignal == nil {
+		t.Errorf("GetPositionsWithNoRatio() positions should have their buy signal")
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	store := NewStore()
+	buySignalsPersistence := NewBuySignalsPersistence(store)
+	positionsPersistence := NewPositionsPersistence(store)
+
+	teamA := workspaces.SetWorkspaceToContext(context.Background(), "team-a")
+	teamB := workspaces.SetWorkspaceToContext(context.Background(), "team-b")
+	buySignal := bsDomain.Details{
+		BusinessID: "1",
+		Name:       "morningStar",
+		Fullname:   "morningStar_b0.6_s0.3_p0.5_t3",
+		Pair:       "SOLUSDC",
+		Interval:   common.H1,
+		Date:       bsDomain.Date(buyDate),
+	}
+
+	// The same buy signal does not collide between workspaces
+	insertedA, _, err := buySignalsPersistence.InsertBuySignals(teamA, &[]bsDomain.Details{buySignal})
+	if err != nil {
+		t.Fatalf("InsertBuySignals() team-a error = %v", err)
+	}
+
+	if _, _, err := buySignalsPersistence.InsertBuySignals(teamB, &[]bsDomain.Details{buySignal}); err != nil {
+		t.Fatalf("InsertBuySignals() team-b error = %v", err)
+	}
+
+	twice, results, err := buySignalsPersistence.InsertBuySignals(teamA, &[]bsDomain.Details{buySignal})
+	if err != nil || len(*twice) != 0 || len(results) != 1 || results[0].Outcome != common.OutcomeDuplicate || *results[0].ID != *(*insertedA)[0].ID {
+		t.Fatalf("InsertBuySignals() team-a twice = %+v, %+v, %v, want a duplicate of the existing buy signal", twice, results, err)
+	}
+
+	res, _, _, err := buySignalsPersistence.QueryBuySignals(teamB, "SOLUSDC", common.H1, "morningStar", nil, 0)
+	if err != nil || len(*res) != 1 || *(*res)[0].ID == *(*insertedA)[0].ID {
+		t.Fatalf("QueryBuySignals() team-b = %+v, %v, want its own buy signal only", res, err)
+	}
+
+	byIDs, err := buySignalsPersistence.QueryBuySignalsByIDs(teamB, []bsDomain.ID{*(*insertedA)[0].ID})
+	if err != nil || len(*byIDs) != 0 {
+		t.Fatalf("QueryBuySignalsByIDs() team-b = %+v, %v, want none", byIDs, err)
+	}
+
+	// A position can not reference the buy signal of another workspace
+	position := positions.Details{
+		BuySignalID: *(*insertedA)[0].ID,
+		Name:        positions.PercentageName,
+		Fullname:    "percentage_tp5",
+		TP:          105,
+		SL:          97,
+	}
+	if _, _, err := positionsPersistence.InsertPositions(teamB, &[]positions.Details{position}); err == nil {
+		t.Fatalf("InsertPositions() team-b on a team-a buy signal error = nil, want foreign key violation")
+	}
+
+	insertedPositions, _, err := positionsPersistence.InsertPositions(teamA, &[]positions.Details{position})
+	if err != nil {
+		t.Fatalf("InsertPositions() team-a error = %v", err)
+	}
+
+	if _, err := positionsPersistence.GetPositionByID(teamB, *(*insertedPositions)[0].ID); !errors.Is(err, appErrors.ErrNotFound) {
+		t.Errorf("GetPositionByID() team-b error = %v, want not found", err)
+	}
+
+	if count, err := positionsPersistence.GetPositionsWithNoRatioCount(teamB); err != nil || count != 0 {
+		t.Errorf("GetPositionsWithNoRatioCount() team-b = %d, %v, want 0", count, err)
+	}
+}
+
+func TestUpsertPosition(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	buySignals, _, err := NewBuySignalsPersistence(store).InsertBuySignals(ctx, &[]bsDomain.Details{{
+		BusinessID: "1",
+		Name:       "morningStar",
+		Fullname:   "morningStar_b0.6_s0.3_p0.5_t3",
+		Pair:       "SOLUSDC",
+		Interval:   common.H1,
+		Date:       bsDomain.Date(buyDate),
+	}})
+	if err != nil {
+		t.Fatalf("InsertBuySignals() error = %v", err)
+	}
+
+	persistence := NewPositionsPersistence(store)
+	winloss := positions.WinLossRatio(1)
+	position := positions.Details{
+		BuySignalID:  *(*buySignals)[0].ID,
+		Name:         positions.PercentageName,
+		Fullname:     "percentage_tp5",
+		TP:           105,
+		SL:           97,
+		WinlossRatio: &winloss,
+	}
+
+	first, err := persistence.UpsertPosition(ctx, &position)
+	if err != nil {
+		t.Fatalf("UpsertPosition() error = %v", err)
+	}
+
+	position.TP = 110
+	second, err := persistence.UpsertPosition(ctx, &position)
+	if err != nil {
+		t.Fatalf("UpsertPosition() error = %v", err)
+	}
+
+	if *second.ID != *first.ID || second.TP != 110 {
+		t.Errorf("UpsertPosition() = %+v, want the first position with the new TP", second)
+	}
+
+	// Without winloss ratio the unique constraint never conflicts
+	position.WinlossRatio = nil
+	third, err := persistence.UpsertPosition(ctx, &position)
+	if err != nil {
+		t.Fatalf("UpsertPosition() error = %v", err)
+	}
+
+	if *third.ID == *first.ID {
+		t.Errorf("UpsertPosition() without winloss ratio updated %s, want a new position", first.ID)
+	}
+}
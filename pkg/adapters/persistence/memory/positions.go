@@ -0,0 +1,470 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/export"
+	domain "github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	positionSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+type positionsPersistence struct {
+	store *Store
+}
+
+func NewPositionsPersistence(store *Store) positionSVC.Persistence {
+	return &positionsPersistence{store: store}
+}
+
+type positionKey struct {
+	workspace    workspaces.Workspace
+	buySignalID  bsDomain.ID
+	fullname     domain.Fullname
+	winlossRatio float64
+}
+
+// keyOfPosition returns false when the position has no winloss ratio
+// A NULL winloss ratio never violates the unique (workspace, buy_signal_id, fullname, winloss_ratio) constraint
+func keyOfPosition(workspace workspaces.Workspace, p domain.Details) (positionKey, bool) {
+	if p.WinlossRatio == nil {
+		return positionKey{}, false
+	}
+
+	return positionKey{workspace: workspace, buySignalID: p.BuySignalID, fullname: p.Fullname, winlossRatio: float64(*p.WinlossRatio)}, true
+}
+
+// toStoredPosition applies the conversions of the Postgres persistence: new ID and TP / SL truncated to 2 decimals
+func toStoredPosition(p domain.Details) domain.Details {
+	stored := p
+	stored.BuySignal = nil
+
+	if stored.TP > 0 {
+		stored.TP = float64(int(stored.TP*100)) / 100
+	}
+
+	if stored.SL > 0 {
+		stored.SL = float64(int(stored.SL*100)) / 100
+	}
+
+	id := domain.ID(uuid.New())
+	if p.ID != nil && uuid.UUID(*p.ID) != uuid.Nil {
+		id = *p.ID
+	}
+	stored.ID = &id
+
+	if p.WinlossRatio != nil {
+		winlossRatio := *p.WinlossRatio
+		stored.WinlossRatio = &winlossRatio
+	}
+
+	if p.Ratio != nil {
+		stored.Ratio = &domain.Ratio{Value: p.Ratio.Value, Date: p.Ratio.Date}
+	}
+
+	return stored
+}
+
+// withBuySignal returns the position with its buy signal relation, the caller holds the lock
+func (p *positionsPersistence) withBuySignal(pos domain.Details) domain.Details {
+	if bs, found := p.store.buySignals[uuid.UUID(pos.BuySignalID)]; found {
+		pos.BuySignal = &bs
+	}
+
+	return pos
+}
+
+// hasBuySignal returns whether the buy signal exists in the workspace, as the foreign key checks, the caller holds the lock
+func (p *positionsPersistence) hasBuySignal(workspace workspaces.Workspace, id bsDomain.ID) bool {
+	_, found := p.store.buySignals[uuid.UUID(id)]
+	return found && p.store.buySignalWorkspaces[uuid.UUID(id)] == workspace
+}
+
+// sortedPositions returns the stored positions of the workspace matching the filter sorted by serial ID, the caller holds the lock
+func (p *positionsPersistence) sortedPositions(workspace workspaces.Workspace, filter func(domain.Details) bool) []domain.Details {
+	res := make([]domain.Details, 0)
+	for id, pos := range p.store.positions {
+		if p.store.positionWorkspaces[id] == workspace && filter(pos) {
+			res = append(res, pos)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].SerialID < res[j].SerialID
+	})
+
+	return res
+}
+
+// InsertPositions inserts the positions, the existing ones are ignored as ON CONFLICT DO NOTHING does
+// An unknown buy signal fails the whole insert, as the foreign key does
+func (p *positionsPersistence) InsertPositions(ctx context.Context, pos *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error) {
+	if pos == nil || len(*pos) == 0 {
+		return &[]domain.Details{}, nil, nil
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	existing := make(map[positionKey]domain.ID, len(p.store.positions))
+	for id, stored := range p.store.positions {
+		if key, ok := keyOfPosition(p.store.positionWorkspaces[id], stored); ok {
+			existing[key] = *stored.ID
+		}
+	}
+
+	inserted := make([]domain.Details, 0, len(*pos))
+	results := make([]common.ItemResult[domain.ID], len(*pos))
+	insertedIDs := make(map[domain.ID]bool, len(*pos))
+	for i, position := range *pos {
+		stored := toStoredPosition(position)
+		if !p.hasBuySignal(workspace, stored.BuySignalID) {
+			return &[]domain.Details{}, nil, fmt.Errorf("buy signal %s not found, foreign key violation", stored.BuySignalID)
+		}
+
+		// A position inserted earlier in the batch conflicts too, as in a single insert statement
+		if _, found := p.store.positions[uuid.UUID(*stored.ID)]; found || insertedIDs[*stored.ID] {
+			results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeDuplicate, ID: stored.ID}
+			continue
+		}
+
+		key, hasKey := keyOfPosition(workspace, stored)
+		if existingID, found := existing[key]; hasKey && found {
+			results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeDuplicate, ID: &existingID}
+			continue
+		}
+
+		if hasKey {
+			existing[key] = *stored.ID
+		}
+		insertedIDs[*stored.ID] = true
+		inserted = append(inserted, stored)
+		results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeCreated, ID: stored.ID}
+	}
+
+	for i := range inserted {
+		p.store.positionsSerialID++
+		inserted[i].SerialID = domain.SerialID(p.store.positionsSerialID)
+		p.store.positions[uuid.UUID(*inserted[i].ID)] = inserted[i]
+		p.store.positionWorkspaces[uuid.UUID(*inserted[i].ID)] = workspace
+	}
+
+	return &inserted, results, nil
+}
+
+// InsertRatios updates the ratio of the positions found by ID, other positions are ignored
+func (p *positionsPersistence) InsertRatios(ctx context.Context, pos *[]domain.Details) (*[]domain.Details, error) {
+	if pos == nil || len(*pos) == 0 {
+		return &[]domain.Details{}, nil
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	updated := make([]domain.Details, 0, len(*pos))
+	for _, position := range *pos {
+		if position.ID == nil {
+			continue
+		}
+
+		stored, found := p.store.positions[uuid.UUID(*position.ID)]
+		if !found || p.store.positionWorkspaces[uuid.UUID(*position.ID)] != workspace {
+			continue
+		}
+
+		stored.Ratio = nil
+		if position.Ratio != nil {
+			stored.Ratio = &domain.Ratio{Value: position.Ratio.Value, Date: position.Ratio.Date}
+		}
+
+		stored.Stale = position.Stale
+
+		p.store.positions[uuid.UUID(*stored.ID)] = stored
+		updated = append(updated, stored)
+	}
+
+	return &updated, nil
+}
+
+// hasNoRatio is a position to compute, a stale ratio is computed again
+func hasNoRatio(pos domain.Details) bool {
+	return (pos.Ratio == nil || pos.Stale) && pos.TP > 0 && pos.SL > 0
+}
+
+func (p *positionsPersistence) GetPositionsWithNoRatio(ctx context.Context, cursor *int64, limit int) (positions *[]domain.Details, hasMore bool, nextCursor *int64, err error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	res := p.sortedPositions(workspaces.GetWorkspaceFromContext(ctx), func(pos domain.Details) bool {
+		return hasNoRatio(pos) && (cursor == nil || int64(pos.SerialID) >= *cursor)
+	})
+
+	if limit > 0 && len(res) > limit {
+		next := int64(res[limit].SerialID)
+		nextCursor = &next
+		hasMore = true
+		res = res[:limit]
+	}
+
+	for i := range res {
+		res[i] = p.withBuySignal(res[i])
+	}
+
+	return &res, hasMore, nextCursor, nil
+}
+
+func (p *positionsPersistence) GetPositionsWithNoRatioCount(ctx context.Context) (count int, err error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	for id, pos := range p.store.positions {
+		if p.store.positionWorkspaces[id] == workspace && hasNoRatio(pos) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (p *positionsPersistence) GetPositionByID(ctx context.Context, id domain.ID) (*domain.Details, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	pos, found := p.store.positions[uuid.UUID(id)]
+	if !found || p.store.positionWorkspaces[uuid.UUID(id)] != workspaces.GetWorkspaceFromContext(ctx) {
+		return nil, appErrors.NewNotFound(fmt.Sprintf("position %s not found", id))
+	}
+
+	pos = p.withBuySignal(pos)
+	return &pos, nil
+}
+
+// UpsertPosition updates the TP and SL of the position with the same buy signal, fullname and winloss ratio, or inserts it
+// As the Postgres persistence, only the name, fullname, buy signal, TP, SL and winloss ratio of a new position are stored
+func (p *positionsPersistence) UpsertPosition(ctx context.Context, position *domain.Details) (*domain.Details, error) {
+	if position == nil {
+		return nil, fmt.Errorf("unable to upsert position: nil position")
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
+	stored := toStoredPosition(*position)
+	if !p.hasBuySignal(workspace, stored.BuySignalID) {
+		return nil, fmt.Errorf("unable to upsert position: buy signal %s not found, foreign key violation", stored.BuySignalID)
+	}
+
+	if key, ok := keyOfPosition(workspace, stored); ok {
+		for id, existing := range p.store.positions {
+			if existingKey, found := keyOfPosition(p.store.positionWorkspaces[id], existing); !found || existingKey != key {
+				continue
+			}
+
+			existing.TP = stored.TP
+			existing.SL = stored.SL
+			p.store.positions[id] = existing
+			return &existing, nil
+		}
+	}
+
+	if _, found := p.store.positions[uuid.UUID(*stored.ID)]; found {
+		return nil, fmt.Errorf("unable to upsert position: id %s already exists", stored.ID)
+	}
+
+	p.store.positionsSerialID++
+	newPosition := domain.Details{
+		ID:           stored.ID,
+		SerialID:     domain.SerialID(p.store.positionsSerialID),
+		Name:         stored.Name,
+		Fullname:     stored.Fullname,
+		BuySignalID:  stored.BuySignalID,
+		TP:           stored.TP,
+		SL:           stored.SL,
+		WinlossRatio: stored.WinlossRatio,
+	}
+	p.store.positions[uuid.UUID(*newPosition.ID)] = newPosition
+	p.store.positionWorkspaces[uuid.UUID(*newPosition.ID)] = workspace
+
+	return &newPosition, nil
+}
+
+func (p *positionsPersistence) QueryPositionsByBuySignalIDs(ctx context.Context, ids []bsDomain.ID, fullname domain.Fullname) (*[]domain.Details, error) {
+	if len(ids) == 0 {
+		return &[]domain.Details{}, nil
+	}
+
+	wanted := make(map[bsDomain.ID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	res := p.sortedPositions(workspaces.GetWorkspaceFromContext(ctx), func(pos domain.Details) bool {
+		return wanted[pos.BuySignalID] && (fullname == "" || pos.Fullname == fullname)
+	})
+
+	for i := range res {
+		res[i] = p.withBuySignal(res[i])
+	}
+
+	return &res, nil
+}
+
+func (p *positionsPersistence) QueryComputedPositions(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time) (*[]domain.Details, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	res := make([]domain.Details, 0)
+	for _, pos := range p.sortedPositions(workspaces.GetWorkspaceFromContext(ctx), func(pos domain.Details) bool { return pos.Ratio != nil }) {
+		pos = p.withBuySignal(pos)
+		if pos.BuySignal == nil || pos.BuySignal.Pair != pair || pos.BuySignal.Interval != interval {
+			continue
+		}
+
+		buyDate := time.Time(pos.BuySignal.Date)
+		if startDate != nil && buyDate.Before(*startDate) {
+			continue
+		}
+
+		if endDate != nil && !buyDate.Before(*endDate) {
+			continue
+		}
+
+		res = append(res, pos)
+	}
+
+	// Positions are sorted by serial ID, a stable sort keeps this order for the same buy signal date
+	sort.SliceStable(res, func(i, j int) bool {
+		return time.Time(res[i].BuySignal.Date).Before(time.Time(res[j].BuySignal.Date))
+	})
+
+	return &res, nil
+}
+
+// StreamBuySignalsPositions joins the buy signals of the workspace with their positions as the v_buy_signals_positions view does
+// The rows are built under the lock, fn is called without it
+func (p *positionsPersistence) StreamBuySignalsPositions(ctx context.Context, filter domain.ExportFilter, fn func(domain.BuySignalPosition) error) error {
+	rows, err := p.buySignalsPositions(workspaces.GetWorkspaceFromContext(ctx), filter)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *positionsPersistence) buySignalsPositions(workspace workspaces.Workspace, filter domain.ExportFilter) ([]domain.BuySignalPosition, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	positionsByBuySignal := map[bsDomain.ID][]domain.Details{}
+	for _, pos := range p.sortedPositions(workspace, func(domain.Details) bool { return true }) {
+		positionsByBuySignal[pos.BuySignalID] = append(positionsByBuySignal[pos.BuySignalID], pos)
+	}
+
+	res := []domain.BuySignalPosition{}
+	for id, bs := range p.store.buySignals {
+		buyDate := time.Time(bs.Date)
+		if p.store.buySignalWorkspaces[id] != workspace ||
+			(filter.Pair != "" && bs.Pair != filter.Pair) ||
+			(filter.Interval != "" && bs.Interval != filter.Interval) ||
+			(filter.BuyFullname != "" && bs.Fullname != filter.BuyFullname) ||
+			(filter.StartDate != nil && buyDate.Before(*filter.StartDate)) ||
+			(filter.EndDate != nil && buyDate.After(*filter.EndDate)) {
+			continue
+		}
+
+		row := domain.BuySignalPosition{
+			Pair:             string(bs.Pair),
+			BuyInterval:      string(bs.Interval),
+			BuyFullname:      string(bs.Fullname),
+			BuyDate:          buyDate.UTC(),
+			BuyPrice:         bs.Price,
+			BuyMetadata:      export.JSON(nil),
+			PositionMetadata: export.JSON(nil),
+			BuyID:            id.String(),
+		}
+
+		if bs.Metadata != nil {
+			metadata, err := json.Marshal(bs.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("unable to marshal the buy signal metadata: %w", err)
+			}
+
+			row.BuyMetadata = metadata
+		}
+
+		positions := positionsByBuySignal[bsDomain.ID(id)]
+		if len(positions) == 0 && filter.PositionFullname == "" {
+			res = append(res, row)
+		}
+
+		for _, pos := range positions {
+			if filter.PositionFullname != "" && pos.Fullname != filter.PositionFullname {
+				continue
+			}
+
+			posRow, err := withPosition(row, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, posRow)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].BuyDate.Equal(res[j].BuyDate) {
+			return res[i].BuyDate.Before(res[j].BuyDate)
+		}
+
+		if res[i].BuyID != res[j].BuyID {
+			return res[i].BuyID < res[j].BuyID
+		}
+
+		return res[i].PositionFullname != nil && (res[j].PositionFullname == nil || *res[i].PositionFullname < *res[j].PositionFullname)
+	})
+
+	return res, nil
+}
+
+// withPosition fills the position columns of a buy signal row
+func withPosition(row domain.BuySignalPosition, pos domain.Details) (domain.BuySignalPosition, error) {
+	fullname, tp, sl, id := string(pos.Fullname), pos.TP, pos.SL, pos.ID.String()
+	row.PositionFullname, row.TP, row.SL, row.PositionID = &fullname, &tp, &sl, &id
+	if pos.Ratio != nil {
+		value, date := pos.Ratio.Value, time.Time(pos.Ratio.Date).UTC()
+		row.RatioValue, row.RatioDate = &value, &date
+	}
+
+	if pos.Metadata != nil {
+		metadata, err := json.Marshal(pos.Metadata)
+		if err != nil {
+			return row, fmt.Errorf("unable to marshal the position metadata: %w", err)
+		}
+
+		row.PositionMetadata = metadata
+	}
+
+	return row, nil
+}
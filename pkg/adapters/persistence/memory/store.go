@@ -0,0 +1,53 @@
+// Package memory implements the services persistences without database
+// It reproduces the Postgres unique constraints, conflicts and cursors so the services can be tested or embedded
+package memory
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sopial42/bifrost/pkg/domains/auth"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/idempotency"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+// Store holds the data shared by the persistences, as a single database does
+type Store struct {
+	mu sync.RWMutex
+
+	candles    map[uuid.UUID]candles.Candle
+	buySignals map[uuid.UUID]bsDomain.Details
+	positions  map[uuid.UUID]positions.Details
+	// buySignalWorkspaces and positionWorkspaces hold the workspace column, the domain models do not have it
+	buySignalWorkspaces map[uuid.UUID]workspaces.Workspace
+	positionWorkspaces  map[uuid.UUID]workspaces.Workspace
+	// buySignalKeys indexes the buy signals by unique key, as the unique constraint index does
+	buySignalKeys map[buySignalKey]uuid.UUID
+	// positionsSerialID mimics the positions serial_id sequence
+	positionsSerialID int64
+	// candleRevisions are the replaced versions of a candle, oldest first
+	candleRevisions map[uuid.UUID][]candles.Revision
+
+	idempotencyKeys map[idempotencyKey]idempotency.Record
+	apiKeys         map[uuid.UUID]auth.APIKey
+}
+
+func NewStore() *Store {
+	return &Store{
+		candles:    make(map[uuid.UUID]candles.Candle),
+		buySignals: make(map[uuid.UUID]bsDomain.Details),
+		positions:  make(map[uuid.UUID]positions.Details),
+
+		buySignalWorkspaces: make(map[uuid.UUID]workspaces.Workspace),
+		positionWorkspaces:  make(map[uuid.UUID]workspaces.Workspace),
+		buySignalKeys:       make(map[buySignalKey]uuid.UUID),
+
+		candleRevisions: make(map[uuid.UUID][]candles.Revision),
+
+		idempotencyKeys: make(map[idempotencyKey]idempotency.Record),
+		apiKeys:         make(map[uuid.UUID]auth.APIKey),
+	}
+}
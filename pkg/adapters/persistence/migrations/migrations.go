@@ -0,0 +1,122 @@
+// Package migrations holds the versioned database schema, embedded in the binary
+// Each migration is a pair of sql/<version>_<name>.tx.(up|down).sql files run in a transaction
+// The "-- +migrate" annotations let the venom dbfixtures load the same files
+// The first migrations, up to 006, create their tables if not exists: a database created before the migrations is adopted by the first up
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	// TableName tracks the applied migrations, LocksTableName is required by bun but the lock is an advisory one
+	TableName      = "schema_migrations"
+	LocksTableName = "schema_migration_locks"
+
+	// advisoryLockKey serializes the migrations of concurrent Bifrost instances
+	advisoryLockKey = 7265636
+)
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// Migrations is the set of embedded migrations
+var Migrations = migrate.NewMigrations()
+
+func init() {
+	sqlDir, err := fs.Sub(sqlFiles, "sql")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := Migrations.Discover(sqlDir); err != nil {
+		panic(err)
+	}
+}
+
+// Migrator runs the embedded migrations, one instance at a time
+type Migrator struct {
+	db       *bun.DB
+	migrator *migrate.Migrator
+}
+
+func NewMigrator(db *bun.DB) *Migrator {
+	return &Migrator{
+		db: db,
+		migrator: migrate.NewMigrator(db, Migrations,
+			migrate.WithTableName(TableName),
+			migrate.WithLocksTableName(LocksTableName),
+			migrate.WithMarkAppliedOnSuccess(true),
+		),
+	}
+}
+
+// Up applies all the unapplied migrations as a new group
+func (m *Migrator) Up(ctx context.Context) (*migrate.MigrationGroup, error) {
+	var group *migrate.MigrationGroup
+	err := m.withLock(ctx, func() error {
+		var err error
+		group, err = m.migrator.Migrate(ctx)
+		return err
+	})
+	if err != nil {
+		return group, fmt.Errorf("unable to apply migrations: %w", err)
+	}
+
+	return group, nil
+}
+
+// Down rollbacks the last applied group of migrations
+func (m *Migrator) Down(ctx context.Context) (*migrate.MigrationGroup, error) {
+	var group *migrate.MigrationGroup
+	err := m.withLock(ctx, func() error {
+		var err error
+		group, err = m.migrator.Rollback(ctx)
+		return err
+	})
+	if err != nil {
+		return group, fmt.Errorf("unable to rollback migrations: %w", err)
+	}
+
+	return group, nil
+}
+
+// Status returns all the migrations with their applied group, if any
+func (m *Migrator) Status(ctx context.Context) (migrate.MigrationSlice, error) {
+	if err := m.migrator.Init(ctx); err != nil {
+		return nil, fmt.Errorf("unable to init migrations tables: %w", err)
+	}
+
+	ms, err := m.migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get migrations status: %w", err)
+	}
+
+	return ms, nil
+}
+
+// withLock runs fn while holding a session advisory lock, other instances wait for it
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", advisoryLockKey); err != nil {
+		return fmt.Errorf("unable to take the migrations lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(?)", advisoryLockKey) //nolint:errcheck
+
+	if err := m.migrator.Init(ctx); err != nil {
+		return fmt.Errorf("unable to init migrations tables: %w", err)
+	}
+
+	return fn()
+}
@@ -0,0 +1,133 @@
+package migrations_test
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"testing"
+
+	"github.com/uptrace/bun"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/migrations"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/pgtest"
+)
+
+func TestMigrationsHaveUpAndDown(t *testing.T) {
+	sorted := migrations.Migrations.Sorted()
+	if len(sorted) == 0 {
+		t.Fatalf("no embedded migrations")
+	}
+
+	for _, m := range sorted {
+		if m.Up == nil || m.Down == nil {
+			t.Errorf("migration %s should have up and down steps", m)
+		}
+	}
+}
+
+func TestMigrator_Postgres(t *testing.T) {
+	ctx := context.Background()
+	migrator := migrations.NewMigrator(pgtest.NewDB(t))
+
+	// pgtest already applied all the migrations
+	group, err := migrator.Up(ctx)
+	if err != nil || !group.IsZero() {
+		t.Fatalf("Up() = %v, %v, want nothing to apply", group, err)
+	}
+
+	group, err = migrator.Down(ctx)
+	if err != nil || len(group.Migrations) != len(migrations.Migrations.Sorted()) {
+		t.Fatalf("Down() = %v, %v, want all migrations rolled back", group, err)
+	}
+
+	group, err = migrator.Up(ctx)
+	if err != nil || len(group.Migrations) != len(migrations.Migrations.Sorted()) {
+		t.Fatalf("Up() = %v, %v, want all migrations applied", group, err)
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil || len(status.Unapplied()) != 0 {
+		t.Errorf("Status() = %v, %v, want all migrations applied", status, err)
+	}
+}
+
+// legacySchema is the schema of the databases created before the migrations, as the venom fixtures loaded it
+//
+//go:embed testdata/legacy/*.sql
+var legacySchema embed.FS
+
+// TestMigrator_AdoptExisting_Postgres upgrades a database created from the legacy schema, without the migrations tables
+// The constraints dropped by name by the migrations have to exist in the legacy schema
+func TestMigrator_AdoptExisting_Postgres(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewDB(t)
+	migrator := migrations.NewMigrator(db)
+
+	if _, err := migrator.Down(ctx); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP TABLE "+migrations.TableName+", "+migrations.LocksTableName); err != nil {
+		t.Fatalf("unable to drop the migrations tables: %v", err)
+	}
+
+	legacy, err := fs.Glob(legacySchema, "testdata/legacy/*.sql")
+	if err != nil || len(legacy) != 4 {
+		t.Fatalf("Glob() = %v, %v, want the 4 legacy schema files", legacy, err)
+	}
+
+	// The files are numbered in their creation order
+	for _, file := range legacy {
+		query, err := legacySchema.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+
+		if _, err := db.ExecContext(ctx, string(query)); err != nil {
+			t.Fatalf("unable to create the legacy schema of %s: %v", file, err)
+		}
+	}
+
+	legacyConstraints := []string{
+		"buy_signals_business_id_pair_interval_fullname_key",
+		"fk_buy_signal_id",
+		"positions_buy_signal_id_fullname_winloss_ratio_key",
+	}
+	for _, name := range legacyConstraints {
+		if !hasConstraint(ctx, t, db, name) {
+			t.Fatalf("legacy schema has no constraint %s", name)
+		}
+	}
+
+	group, err := migrator.Up(ctx)
+	if err != nil || len(group.Migrations) != len(migrations.Migrations.Sorted()) {
+		t.Fatalf("Up() = %v, %v, want all migrations applied on the legacy schema", group, err)
+	}
+
+	for _, name := range legacyConstraints {
+		if hasConstraint(ctx, t, db, name) {
+			t.Errorf("constraint %s should be replaced by its workspace version", name)
+		}
+	}
+
+	for _, name := range []string{
+		"buy_signals_workspace_business_id_pair_interval_fullname_key",
+		"fk_workspace_buy_signal_id",
+		"positions_workspace_buy_signal_id_fullname_winloss_ratio_key",
+	} {
+		if !hasConstraint(ctx, t, db, name) {
+			t.Errorf("migrated schema has no constraint %s", name)
+		}
+	}
+}
+
+func hasConstraint(ctx context.Context, t *testing.T, db *bun.DB, name string) bool {
+	t.Helper()
+
+	exists, err := db.NewSelect().Table("pg_constraint").Where("conname = ?", name).Exists(ctx)
+	if err != nil {
+		t.Fatalf("unable to check the constraint %s: %v", name, err)
+	}
+
+	return exists
+}
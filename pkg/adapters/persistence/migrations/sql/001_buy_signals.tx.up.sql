@@ -0,0 +1,16 @@
+-- +migrate Up
+-- 001 to 006 are the schema created before the versioned migrations, their tables are created if not exists to adopt such a database
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS buy_signals(
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  business_id     TEXT NOT NULL,
+  pair            TEXT NOT NULL,
+  interval        TEXT NOT NULL,
+  name            TEXT NOT NULL,
+  fullname        TEXT NOT NULL,
+  "date"          TIMESTAMPTZ NOT NULL,
+  price           DOUBLE PRECISION,
+  metadata JSONB,
+  UNIQUE  (business_id, pair, interval, fullname)
+);
@@ -0,0 +1,15 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS candles(
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  date            TIMESTAMPTZ NOT NULL,
+  pair            VARCHAR(255) NOT NULL,
+  interval        VARCHAR(255) NOT NULL,
+  open            DOUBLE PRECISION NOT NULL,
+  close           DOUBLE PRECISION NOT NULL,
+  high            DOUBLE PRECISION NOT NULL,
+  low             DOUBLE PRECISION NOT NULL,
+  rsi             JSONB,
+  UNIQUE (date, interval, pair)
+);
+
@@ -0,0 +1,4 @@
+-- +migrate Down
+
+DROP TABLE IF EXISTS experiment_results;
+DROP TABLE IF EXISTS experiments;
@@ -0,0 +1,33 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS experiments(
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  status          TEXT NOT NULL,
+  request         JSONB NOT NULL,
+  error           TEXT,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at     TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS experiment_results(
+  id                    UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  experiment_id         UUID NOT NULL,
+  rank                  INTEGER NOT NULL,
+  pair                  TEXT NOT NULL,
+  interval              TEXT NOT NULL,
+  buy_signal_fullname   TEXT NOT NULL,
+  position_fullname     TEXT NOT NULL,
+  winloss_ratio         DOUBLE PRECISION,
+  trades                INTEGER NOT NULL,
+  open                  INTEGER NOT NULL,
+  wins                  INTEGER NOT NULL,
+  losses                INTEGER NOT NULL,
+  win_rate              DOUBLE PRECISION NOT NULL,
+  avg_win               DOUBLE PRECISION NOT NULL,
+  avg_loss              DOUBLE PRECISION NOT NULL,
+  expectancy            DOUBLE PRECISION NOT NULL,
+  total_return          DOUBLE PRECISION NOT NULL,
+  profit_factor         DOUBLE PRECISION NOT NULL,
+  CONSTRAINT FK_experiment_id FOREIGN KEY(experiment_id) REFERENCES experiments(id) ON DELETE CASCADE,
+  UNIQUE (experiment_id, rank)
+);
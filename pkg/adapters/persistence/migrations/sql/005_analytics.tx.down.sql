@@ -0,0 +1,4 @@
+-- +migrate Down
+
+DROP TABLE IF EXISTS walk_forward_windows;
+DROP TABLE IF EXISTS walk_forwards;
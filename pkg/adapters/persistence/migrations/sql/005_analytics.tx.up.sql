@@ -0,0 +1,25 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS walk_forwards(
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  request         JSONB NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS walk_forward_windows(
+  id                    UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  walk_forward_id       UUID NOT NULL,
+  window_index          INTEGER NOT NULL,
+  metric                TEXT NOT NULL,
+  in_sample_start       TIMESTAMPTZ NOT NULL,
+  in_sample_end         TIMESTAMPTZ NOT NULL,
+  out_of_sample_start   TIMESTAMPTZ NOT NULL,
+  out_of_sample_end     TIMESTAMPTZ NOT NULL,
+  buy_signal_fullname   TEXT,
+  position_fullname     TEXT,
+  winloss_ratio         DOUBLE PRECISION,
+  in_sample             JSONB NOT NULL,
+  out_of_sample         JSONB NOT NULL,
+  CONSTRAINT FK_walk_forward_id FOREIGN KEY(walk_forward_id) REFERENCES walk_forwards(id) ON DELETE CASCADE,
+  UNIQUE (walk_forward_id, window_index, metric)
+);
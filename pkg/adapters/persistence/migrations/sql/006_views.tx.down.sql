@@ -0,0 +1,3 @@
+-- +migrate Down
+
+DROP VIEW IF EXISTS v_buy_signals_positions;
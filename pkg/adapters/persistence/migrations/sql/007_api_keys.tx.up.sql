@@ -0,0 +1,12 @@
+-- +migrate Up
+
+CREATE TABLE api_keys(
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner           TEXT NOT NULL,
+  prefix          TEXT NOT NULL,
+  hash            BYTEA NOT NULL,
+  scopes          JSONB NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  revoked_at      TIMESTAMPTZ,
+  UNIQUE (prefix)
+);
@@ -0,0 +1,39 @@
+-- +migrate Down
+
+DROP VIEW IF EXISTS v_buy_signals_positions;
+CREATE VIEW v_buy_signals_positions AS
+SELECT
+  bs.pair                          AS pair,
+  bs.interval                      AS "buy_interval",
+  bs.fullname                      AS buy_fullname,
+  bs."date"                        AS buy_date,
+  bs.price                         AS buy_price,
+  p.fullname                       AS position_fullname,
+  p.tp,
+  p.sl,
+  p.ratio_value,
+  p.ratio_date,
+  bs.metadata                      AS buy_metadata,
+  p.metadata                       AS position_metadata,
+  bs.id                            AS buy_id,
+  p.id                             AS position_id
+FROM buy_signals bs
+LEFT JOIN positions p ON p.buy_signal_id = bs.id;
+
+ALTER TABLE walk_forwards DROP COLUMN workspace;
+ALTER TABLE experiments DROP COLUMN workspace;
+
+ALTER TABLE positions DROP CONSTRAINT positions_workspace_buy_signal_id_fullname_winloss_ratio_key;
+ALTER TABLE positions DROP CONSTRAINT fk_workspace_buy_signal_id;
+ALTER TABLE positions DROP COLUMN workspace;
+ALTER TABLE positions ADD CONSTRAINT fk_buy_signal_id FOREIGN KEY (buy_signal_id) REFERENCES buy_signals(id);
+ALTER TABLE positions ADD CONSTRAINT positions_buy_signal_id_fullname_winloss_ratio_key
+  UNIQUE (buy_signal_id, fullname, winloss_ratio);
+
+ALTER TABLE buy_signals DROP CONSTRAINT buy_signals_workspace_id_key;
+ALTER TABLE buy_signals DROP CONSTRAINT buy_signals_workspace_business_id_pair_interval_fullname_key;
+ALTER TABLE buy_signals DROP COLUMN workspace;
+ALTER TABLE buy_signals ADD CONSTRAINT buy_signals_business_id_pair_interval_fullname_key
+  UNIQUE (business_id, pair, interval, fullname);
+
+ALTER TABLE api_keys DROP COLUMN workspace;
@@ -0,0 +1,46 @@
+-- +migrate Up
+
+-- Buy signals, positions, experiments and walk-forwards belong to a workspace, candles stay shared
+-- The existing rows move to the default workspace
+
+ALTER TABLE api_keys ADD COLUMN workspace TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE buy_signals ADD COLUMN workspace TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE buy_signals DROP CONSTRAINT buy_signals_business_id_pair_interval_fullname_key;
+ALTER TABLE buy_signals ADD CONSTRAINT buy_signals_workspace_business_id_pair_interval_fullname_key
+  UNIQUE (workspace, business_id, pair, interval, fullname);
+-- Target of the positions foreign key, a position can not reference the buy signal of another workspace
+ALTER TABLE buy_signals ADD CONSTRAINT buy_signals_workspace_id_key UNIQUE (workspace, id);
+
+ALTER TABLE positions ADD COLUMN workspace TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE positions DROP CONSTRAINT fk_buy_signal_id;
+ALTER TABLE positions DROP CONSTRAINT positions_buy_signal_id_fullname_winloss_ratio_key;
+ALTER TABLE positions ADD CONSTRAINT fk_workspace_buy_signal_id
+  FOREIGN KEY (workspace, buy_signal_id) REFERENCES buy_signals(workspace, id);
+-- Conflict target of the positions upsert
+ALTER TABLE positions ADD CONSTRAINT positions_workspace_buy_signal_id_fullname_winloss_ratio_key
+  UNIQUE (workspace, buy_signal_id, fullname, winloss_ratio);
+
+ALTER TABLE experiments ADD COLUMN workspace TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE walk_forwards ADD COLUMN workspace TEXT NOT NULL DEFAULT 'default';
+
+DROP VIEW IF EXISTS v_buy_signals_positions;
+CREATE VIEW v_buy_signals_positions AS
+SELECT
+  bs.workspace                     AS workspace,
+  bs.pair                          AS pair,
+  bs.interval                      AS "buy_interval",
+  bs.fullname                      AS buy_fullname,
+  bs."date"                        AS buy_date,
+  bs.price                         AS buy_price,
+  p.fullname                       AS position_fullname,
+  p.tp,
+  p.sl,
+  p.ratio_value,
+  p.ratio_date,
+  bs.metadata                      AS buy_metadata,
+  p.metadata                       AS position_metadata,
+  bs.id                            AS buy_id,
+  p.id                             AS position_id
+FROM buy_signals bs
+LEFT JOIN positions p ON p.workspace = bs.workspace AND p.buy_signal_id = bs.id;
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- The responses of the write requests with an Idempotency-Key header, replayed to their retries
+CREATE TABLE idempotency_keys(
+  workspace       TEXT NOT NULL,
+  idempotency_key TEXT NOT NULL,
+  fingerprint     TEXT NOT NULL,
+  status          INTEGER NOT NULL DEFAULT 0,
+  content_type    TEXT NOT NULL DEFAULT '',
+  body            BYTEA,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at      TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (workspace, idempotency_key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,4 @@
+-- +migrate Down
+
+DROP INDEX IF EXISTS candles_issues_idx;
+ALTER TABLE candles DROP COLUMN issues;
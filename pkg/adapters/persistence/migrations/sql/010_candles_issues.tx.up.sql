@@ -0,0 +1,7 @@
+-- +migrate Up
+
+-- The integrity issues of the candles stored by the flag validation, eg. high_below_low
+ALTER TABLE candles ADD COLUMN issues TEXT[] NOT NULL DEFAULT '{}';
+
+-- The quality report reads the flagged candles
+CREATE INDEX candles_issues_idx ON candles (pair, interval, date) WHERE issues <> '{}';
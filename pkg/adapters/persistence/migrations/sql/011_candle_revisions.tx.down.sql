@@ -0,0 +1,6 @@
+-- +migrate Down
+
+DROP INDEX IF EXISTS positions_stale_idx;
+ALTER TABLE positions DROP COLUMN stale;
+
+DROP TABLE IF EXISTS candle_revisions;
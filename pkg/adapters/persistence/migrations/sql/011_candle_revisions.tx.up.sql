@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- The replaced versions of the corrected candles, with the source and the date of the correction that replaced them
+CREATE TABLE candle_revisions(
+  id              BIGSERIAL PRIMARY KEY,
+  candle_id       UUID NOT NULL REFERENCES candles(id) ON DELETE CASCADE,
+  open            DOUBLE PRECISION NOT NULL,
+  close           DOUBLE PRECISION NOT NULL,
+  high            DOUBLE PRECISION NOT NULL,
+  low             DOUBLE PRECISION NOT NULL,
+  issues          TEXT[] NOT NULL DEFAULT '{}',
+  source          TEXT NOT NULL,
+  revised_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX candle_revisions_candle_id_idx ON candle_revisions (candle_id, revised_at);
+
+-- A stale position has a ratio computed on candles corrected since, compute-all computes it again
+ALTER TABLE positions ADD COLUMN stale BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX positions_stale_idx ON positions (workspace, serial_id) WHERE stale;
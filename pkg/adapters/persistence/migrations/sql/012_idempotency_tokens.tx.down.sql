@@ -0,0 +1,3 @@
+-- +migrate Down
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS token;
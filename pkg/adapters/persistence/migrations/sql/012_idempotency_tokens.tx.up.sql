@@ -0,0 +1,4 @@
+-- +migrate Up
+
+-- The token of the reservation fences the completion and the release of a key, a request that outlived its lock does not overwrite the next one
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS token UUID;
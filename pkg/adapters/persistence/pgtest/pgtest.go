@@ -0,0 +1,112 @@
+// Package pgtest provides a Postgres database to the tests that need one
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/migrations"
+)
+
+// DSNEnv is the env var of the test database DSN, tests are skipped when it is not set
+// The database schema is dropped, use a dedicated database
+const DSNEnv = "TEST_DB_DSN"
+
+// processLockKey is held by a test binary as long as it runs
+// go test runs the packages in parallel, the lock keeps them from resetting the schema of each other
+const processLockKey = 7265637
+
+var schemaOnce sync.Once
+var schemaErr error
+
+// SkipWithoutDB skips the test when the test database is not set
+// A suite calls it before its subtests, so it is reported as skipped rather than passed with all its subtests skipped
+func SkipWithoutDB(t testing.TB) {
+	t.Helper()
+
+	if os.Getenv(DSNEnv) == "" {
+		t.Skipf("%s is not set, skip Postgres tests", DSNEnv)
+	}
+}
+
+// NewDB returns a client on the test database with empty tables
+func NewDB(t *testing.T) *bun.DB {
+	t.Helper()
+
+	SkipWithoutDB(t)
+	dsn := os.Getenv(DSNEnv)
+
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	ctx := context.Background()
+	schemaOnce.Do(func() {
+		if schemaErr = lockProcess(ctx, dsn); schemaErr != nil {
+			return
+		}
+
+		schemaErr = resetSchema(ctx, db)
+	})
+
+	if schemaErr != nil {
+		t.Fatalf("unable to reset the test database schema: %v", schemaErr)
+	}
+
+	if err := truncateTables(ctx, db); err != nil {
+		t.Fatalf("unable to truncate the test database tables: %v", err)
+	}
+
+	return db
+}
+
+// lockProcess takes the process lock on a dedicated connection, never released before the process exits
+func lockProcess(ctx context.Context, dsn string) error {
+	conn, err := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))).Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("SELECT pg_advisory_lock(%d)", processLockKey))
+	return err
+}
+
+// resetSchema recreates the schema from the embedded migrations
+func resetSchema(ctx context.Context, db *bun.DB) error {
+	if _, err := db.ExecContext(ctx, "DROP SCHEMA IF EXISTS public CASCADE; CREATE SCHEMA public;"); err != nil {
+		return err
+	}
+
+	_, err := migrations.NewMigrator(db).Up(ctx)
+	return err
+}
+
+func truncateTables(ctx context.Context, db *bun.DB) error {
+	var tables []string
+	err := db.NewSelect().
+		Column("tablename").
+		Table("pg_tables").
+		Where("schemaname = 'public'").
+		Where("tablename NOT IN (?)", bun.In([]string{migrations.TableName, migrations.LocksTableName})).
+		Scan(ctx, &tables)
+	if err != nil {
+		return err
+	}
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	_, err = db.ExecContext(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" CASCADE")
+	return err
+}
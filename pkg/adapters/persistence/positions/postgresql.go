@@ -3,14 +3,17 @@ package positions
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/jackc/pgerrcode"
 	"github.com/uptrace/bun"
-	"github.com/uptrace/bun/driver/pgdriver"
 
-	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/google/uuid"
+
 	"github.com/sopial42/bifrost/pkg/common/logger"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
 	domain "github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
 	positionSVC "github.com/sopial42/bifrost/pkg/services/positions"
 )
 
@@ -22,29 +25,142 @@ func NewPersistence(client *bun.DB) positionSVC.Persistence {
 	return &pgPersistence{clientDB: client}
 }
 
-func (p *pgPersistence) InsertPositions(ctx context.Context, pos *[]domain.Details) (*[]domain.Details, error) {
+// InsertPositions inserts the positions, the existing ones are ignored
+// Returns the newly inserted positions, and the outcome of each position in input order
+func (p *pgPersistence) InsertPositions(ctx context.Context, pos *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error) {
 	log := logger.GetLogger(ctx)
 	if pos == nil || len(*pos) == 0 {
 		log.Warnf("unable to insert, position details is nil, or len = 0: %v", pos)
-		return &[]domain.Details{}, nil
+		return &[]domain.Details{}, nil, nil
 	}
 
-	positionDAOs := positionDetailsToPositionDAOs(pos)
-	_, err := p.clientDB.
+	positionDAOs := positionDetailsToPositionDAOs(ctx, pos)
+	insertedDAOs := []PositionDAO{}
+	err := p.clientDB.
 		NewInsert().
 		Model(&positionDAOs).
-		Exec(ctx)
+		On("CONFLICT DO NOTHING").
+		Returning("*").
+		Scan(ctx, &insertedDAOs)
 	if err != nil {
-		if errPg, ok := err.(pgdriver.Error); ok && errPg.Field('C') == pgerrcode.UniqueViolation {
-			return &[]domain.Details{}, appErrors.NewAlreadyExists("position already exists")
+		log.Errorf("Query refused, throw pgError, %v", err)
+		return &[]domain.Details{}, nil, err
+	}
+
+	inserted := make(map[uuid.UUID]bool, len(insertedDAOs))
+	for _, position := range insertedDAOs {
+		inserted[position.ID] = true
+	}
+
+	// The IDs are set before the insert, the ones not returned conflict with an existing ID or unique key
+	duplicates := make([]PositionDAO, 0, len(positionDAOs)-len(insertedDAOs))
+	for _, position := range positionDAOs {
+		if !inserted[position.ID] {
+			duplicates = append(duplicates, position)
 		}
+	}
 
-		log.Errorf("Query refused, throw pgError, %v", err)
-		return &[]domain.Details{}, err
+	existing, err := p.queryExisting(ctx, duplicates)
+	if err != nil {
+		return &[]domain.Details{}, nil, err
+	}
+
+	results := make([]common.ItemResult[domain.ID], len(positionDAOs))
+	for i, position := range positionDAOs {
+		if inserted[position.ID] {
+			id := domain.ID(position.ID)
+			results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeCreated, ID: &id}
+			continue
+		}
+
+		existingID, found := existing.find(position)
+		if !found {
+			return &[]domain.Details{}, nil, fmt.Errorf("unable to find the existing position of %s %s", position.BuySignalID, position.Fullname)
+		}
+
+		id := domain.ID(existingID)
+		results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeDuplicate, ID: &id}
+	}
+
+	res, err := positionDAOsToPositionDetails(insertedDAOs)
+	if err != nil {
+		return &[]domain.Details{}, nil, err
+	}
+
+	log.Debugf("Insert positions done (%d inserted, %d duplicates)", len(insertedDAOs), len(duplicates))
+	return res, results, nil
+}
+
+type positionKey struct {
+	buySignalID  uuid.UUID
+	fullname     string
+	winlossRatio float64
+}
+
+// existingPositions are the IDs of the stored positions by unique key and by ID
+type existingPositions struct {
+	byKey map[positionKey]uuid.UUID
+	byID  map[uuid.UUID]bool
+}
+
+// find returns the ID of the stored position conflicting with the position
+// A NULL winloss ratio never conflicts, such a position only conflicts on its ID
+func (e existingPositions) find(position PositionDAO) (uuid.UUID, bool) {
+	if position.WinlossRatio != nil {
+		if id, found := e.byKey[positionKey{buySignalID: position.BuySignalID, fullname: position.Fullname, winlossRatio: *position.WinlossRatio}]; found {
+			return id, true
+		}
+	}
+
+	return position.ID, e.byID[position.ID]
+}
+
+// queryExisting returns the stored positions of the workspace conflicting with the given ones
+func (p *pgPersistence) queryExisting(ctx context.Context, positionDAOs []PositionDAO) (existingPositions, error) {
+	res := existingPositions{
+		byKey: make(map[positionKey]uuid.UUID, len(positionDAOs)),
+		byID:  make(map[uuid.UUID]bool, len(positionDAOs)),
+	}
+	if len(positionDAOs) == 0 {
+		return res, nil
+	}
+
+	ids := make([]uuid.UUID, len(positionDAOs))
+	keys := make([][]any, 0, len(positionDAOs))
+	for i, position := range positionDAOs {
+		ids[i] = position.ID
+		if position.WinlossRatio != nil {
+			keys = append(keys, []any{position.BuySignalID, position.Fullname, *position.WinlossRatio})
+		}
+	}
+
+	existingDAOs := []PositionDAO{}
+	request := p.clientDB.NewSelect().
+		Model(&existingDAOs).
+		Column("id", "buy_signal_id", "fullname", "winloss_ratio").
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx))
+
+	if len(keys) > 0 {
+		request.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("id IN (?)", bun.In(ids)).
+				WhereOr("(buy_signal_id, fullname, winloss_ratio) IN (?)", bun.In(keys))
+		})
+	} else {
+		request.Where("id IN (?)", bun.In(ids))
+	}
+
+	if err := request.Scan(ctx); err != nil {
+		return res, fmt.Errorf("unable to query existing positions: %w", err)
 	}
 
-	log.Debugf("Insert positions done")
-	return positionDAOsToPositionDetails(positionDAOs)
+	for _, position := range existingDAOs {
+		res.byID[position.ID] = true
+		if position.WinlossRatio != nil {
+			res.byKey[positionKey{buySignalID: position.BuySignalID, fullname: position.Fullname, winlossRatio: *position.WinlossRatio}] = position.ID
+		}
+	}
+
+	return res, nil
 }
 
 func (p *pgPersistence) InsertRatios(ctx context.Context, pos *[]domain.Details) (*[]domain.Details, error) {
@@ -54,13 +170,14 @@ func (p *pgPersistence) InsertRatios(ctx context.Context, pos *[]domain.Details)
 		return &[]domain.Details{}, nil
 	}
 
-	positionDAOs := positionDetailsToPositionDAOs(pos)
+	positionDAOs := positionDetailsToPositionDAOs(ctx, pos)
 	var res []PositionDAO
 	err := p.clientDB.
 		NewUpdate().
 		Model(&positionDAOs).
-		Column("ratio_value", "ratio_date").
+		Column("ratio_value", "ratio_date", "stale").
 		Bulk().
+		Where("position_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
 		Returning("position_dao.*").
 		Scan(ctx, &res)
 	if err != nil {
@@ -73,7 +190,8 @@ func (p *pgPersistence) InsertRatios(ctx context.Context, pos *[]domain.Details)
 func (p *pgPersistence) GetPositionsWithNoRatio(ctx context.Context, cursor *int64, limit int) (positions *[]domain.Details, hasMore bool, nextCursor *int64, err error) {
 	positionsDAO := []PositionDAO{}
 	request := p.clientDB.NewSelect().Model(&positionsDAO).
-		Where("ratio_value IS NULL").
+		Where("position_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("(ratio_value IS NULL OR stale)").
 		Where("tp > 0").
 		Where("sl > 0").
 		Relation("BuySignal").
@@ -119,7 +237,8 @@ func (p *pgPersistence) GetPositionsWithNoRatioCount(ctx context.Context) (count
 	// Add check on tp and sl != 0
 	count, err = p.clientDB.NewSelect().
 		Model(&PositionDAO{}).
-		Where("ratio_value IS NULL").
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("(ratio_value IS NULL OR stale)").
 		Where("tp > 0").
 		Where("sl > 0").
 		Count(ctx)
@@ -133,6 +252,7 @@ func (p *pgPersistence) GetPositionByID(ctx context.Context, id domain.ID) (*dom
 	positionDAO := PositionDAO{}
 	err := p.clientDB.NewSelect().Model(&positionDAO).
 		Relation("BuySignal").
+		Where("position_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
 		Where("position_dao.id = ?", id.String()).
 		Scan(ctx)
 	if err != nil {
@@ -155,16 +275,16 @@ func (p *pgPersistence) UpsertPosition(ctx context.Context, position *domain.Det
 	log := logger.GetLogger(ctx)
 	log.Debugf("Upsert position start with position: %+v", position)
 
-	positionDAO := positionDetailsToPositionDAOs(&[]domain.Details{*position})
+	positionDAO := positionDetailsToPositionDAOs(ctx, &[]domain.Details{*position})
 
 	log.Infof("Upsert position DAOs: %+v", positionDAO)
 	_, err := p.clientDB.
 		NewInsert().
 		Model(&positionDAO).
-		On("CONFLICT (buy_signal_id, fullname) DO UPDATE").
+		On("CONFLICT (workspace, buy_signal_id, fullname, winloss_ratio) DO UPDATE").
 		Set("tp = EXCLUDED.tp").
 		Set("sl = EXCLUDED.sl").
-		Column("name", "fullname", "buy_signal_id", "tp", "sl").
+		Column("workspace", "name", "fullname", "buy_signal_id", "tp", "sl", "winloss_ratio").
 		Returning("*").
 		Exec(ctx)
 	if err != nil || len(positionDAO) == 0 {
@@ -183,3 +303,128 @@ func (p *pgPersistence) UpsertPosition(ctx context.Context, position *domain.Det
 
 	return &(*positionModel)[0], nil
 }
+
+func (p *pgPersistence) QueryPositionsByBuySignalIDs(ctx context.Context, ids []bsDomain.ID, fullname domain.Fullname) (*[]domain.Details, error) {
+	if len(ids) == 0 {
+		return &[]domain.Details{}, nil
+	}
+
+	uuids := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		uuids[i] = uuid.UUID(id)
+	}
+
+	positionsDAO := []PositionDAO{}
+	request := p.clientDB.NewSelect().Model(&positionsDAO).
+		Relation("BuySignal").
+		Where("position_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("position_dao.buy_signal_id IN (?)", bun.In(uuids)).
+		OrderExpr("position_dao.serial_id ASC")
+
+	if fullname != "" {
+		request.Where("position_dao.fullname = ?", fullname)
+	}
+
+	if err := request.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	positions, err := positionDAOsToPositionDetails(positionsDAO)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert positionsDAO to positionsModel: %w", err)
+	}
+
+	if positions == nil {
+		return &[]domain.Details{}, nil
+	}
+
+	return positions, nil
+}
+
+func (p *pgPersistence) QueryComputedPositions(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time) (*[]domain.Details, error) {
+	positionsDAO := []PositionDAO{}
+	request := p.clientDB.NewSelect().Model(&positionsDAO).
+		Relation("BuySignal").
+		Where("position_dao.workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		Where("buy_signal.pair = ?", pair).
+		Where("buy_signal.interval = ?", interval).
+		Where("position_dao.ratio_value IS NOT NULL").
+		OrderExpr("buy_signal.date ASC, position_dao.serial_id ASC")
+
+	if startDate != nil {
+		request.Where("buy_signal.date >= ?", *startDate)
+	}
+
+	if endDate != nil {
+		request.Where("buy_signal.date < ?", *endDate)
+	}
+
+	if err := request.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("unable to perform db query: %w", err)
+	}
+
+	positions, err := positionDAOsToPositionDetails(positionsDAO)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert positionsDAO to positionsModel: %w", err)
+	}
+
+	if positions == nil {
+		return &[]domain.Details{}, nil
+	}
+
+	return positions, nil
+}
+
+// StreamBuySignalsPositions scans the v_buy_signals_positions rows of the workspace one at a time, sorted by buy date
+func (p *pgPersistence) StreamBuySignalsPositions(ctx context.Context, filter domain.ExportFilter, fn func(domain.BuySignalPosition) error) error {
+	request := p.clientDB.NewSelect().Model((*BuySignalPositionDAO)(nil)).
+		Where("workspace = ?", workspaces.GetWorkspaceFromContext(ctx)).
+		OrderExpr("buy_date ASC, buy_id ASC, position_fullname ASC")
+
+	if filter.Pair != "" {
+		request.Where("pair = ?", filter.Pair)
+	}
+
+	if filter.Interval != "" {
+		request.Where("buy_interval = ?", filter.Interval)
+	}
+
+	if filter.BuyFullname != "" {
+		request.Where("buy_fullname = ?", filter.BuyFullname)
+	}
+
+	if filter.PositionFullname != "" {
+		request.Where("position_fullname = ?", filter.PositionFullname)
+	}
+
+	if filter.StartDate != nil {
+		request.Where("buy_date >= ?", *filter.StartDate)
+	}
+
+	if filter.EndDate != nil {
+		request.Where("buy_date <= ?", *filter.EndDate)
+	}
+
+	rows, err := request.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to perform db query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dao := BuySignalPositionDAO{}
+		if err := p.clientDB.ScanRow(ctx, rows, &dao); err != nil {
+			return fmt.Errorf("unable to scan buy signal position: %w", err)
+		}
+
+		if err := fn(buySignalPositionDAOToBuySignalPosition(&dao)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to read buy signals positions: %w", err)
+	}
+
+	return nil
+}
@@ -1,13 +1,17 @@
 package positions
 
 import (
+	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	bsPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/buySignals"
 	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
 	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/export"
 	positions "github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
 	"github.com/uptrace/bun"
 )
 
@@ -16,6 +20,7 @@ type PositionDAO struct {
 
 	ID           uuid.UUID                   `bun:"id,pk,type:uuid,default:uuid_generate_v4()"`
 	SerialID     int64                       `bun:"serial_id,autoincrement"`
+	Workspace    workspaces.Workspace        `bun:"workspace"`
 	BuySignalID  uuid.UUID                   `bun:"type:uuid"`
 	BuySignal    *bsPersistence.BuySignalDAO `bun:"rel:belongs-to,join:buy_signal_id=id"`
 	Name         string                      `bun:"name"`
@@ -25,11 +30,13 @@ type PositionDAO struct {
 	Metadata     map[string]any              `bun:"metadata,type:jsonb"`
 	RatioValue   *float64                    `bun:"ratio_value,nullzero"`
 	RatioDate    *time.Time                  `bun:"ratio_date,nullzero"`
+	Stale        bool                        `bun:"stale"`
 	WinlossRatio *float64                    `bun:"winloss_ratio,nullzero"`
 }
 
-func positionDetailsToPositionDAOs(positions *[]positions.Details) []PositionDAO {
+func positionDetailsToPositionDAOs(ctx context.Context, positions *[]positions.Details) []PositionDAO {
 	positionDAOs := make([]PositionDAO, len(*positions))
+	workspace := workspaces.GetWorkspaceFromContext(ctx)
 
 	for i, pos := range *positions {
 		tp := pos.TP
@@ -43,12 +50,14 @@ func positionDetailsToPositionDAOs(positions *[]positions.Details) []PositionDAO
 		}
 
 		positionDAOs[i] = PositionDAO{
+			Workspace:   workspace,
 			BuySignalID: uuid.UUID(pos.BuySignalID),
 			Name:        string(pos.Name),
 			Fullname:    string(pos.Fullname),
 			TP:          tp,
 			SL:          sl,
 			Metadata:    pos.Metadata,
+			Stale:       pos.Stale,
 		}
 
 		if pos.WinlossRatio != nil {
@@ -88,6 +97,7 @@ func positionDAOsToPositionDetails(positionsDAO []PositionDAO) (*[]positions.Det
 			TP:          p.TP,
 			SL:          p.SL,
 			Metadata:    p.Metadata,
+			Stale:       p.Stale,
 		}
 
 		if p.ID != uuid.Nil {
@@ -128,3 +138,53 @@ func positionDAOsToPositionDetails(positionsDAO []PositionDAO) (*[]positions.Det
 
 	return &res, nil
 }
+
+// BuySignalPositionDAO is a row of the v_buy_signals_positions view, the position columns are NULL for a buy signal without position
+type BuySignalPositionDAO struct {
+	bun.BaseModel `bun:"table:v_buy_signals_positions"`
+
+	Workspace        workspaces.Workspace `bun:"workspace"`
+	Pair             string               `bun:"pair"`
+	BuyInterval      string               `bun:"buy_interval"`
+	BuyFullname      string               `bun:"buy_fullname"`
+	BuyDate          time.Time            `bun:"buy_date"`
+	BuyPrice         float64              `bun:"buy_price"`
+	PositionFullname *string              `bun:"position_fullname"`
+	TP               *float64             `bun:"tp"`
+	SL               *float64             `bun:"sl"`
+	RatioValue       *float64             `bun:"ratio_value"`
+	RatioDate        *time.Time           `bun:"ratio_date"`
+	BuyMetadata      json.RawMessage      `bun:"buy_metadata,type:jsonb"`
+	PositionMetadata json.RawMessage      `bun:"position_metadata,type:jsonb"`
+	BuyID            uuid.UUID            `bun:"buy_id,type:uuid"`
+	PositionID       *uuid.UUID           `bun:"position_id,type:uuid"`
+}
+
+func buySignalPositionDAOToBuySignalPosition(dao *BuySignalPositionDAO) positions.BuySignalPosition {
+	row := positions.BuySignalPosition{
+		Pair:             dao.Pair,
+		BuyInterval:      dao.BuyInterval,
+		BuyFullname:      dao.BuyFullname,
+		BuyDate:          dao.BuyDate.UTC(),
+		BuyPrice:         dao.BuyPrice,
+		PositionFullname: dao.PositionFullname,
+		TP:               dao.TP,
+		SL:               dao.SL,
+		RatioValue:       dao.RatioValue,
+		BuyMetadata:      export.JSON(dao.BuyMetadata),
+		PositionMetadata: export.JSON(dao.PositionMetadata),
+		BuyID:            dao.BuyID.String(),
+	}
+
+	if dao.RatioDate != nil {
+		ratioDate := dao.RatioDate.UTC()
+		row.RatioDate = &ratioDate
+	}
+
+	if dao.PositionID != nil {
+		positionID := dao.PositionID.String()
+		row.PositionID = &positionID
+	}
+
+	return row
+}
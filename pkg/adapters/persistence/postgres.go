@@ -3,11 +3,14 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"runtime"
 	"time"
 
 	"github.com/sopial42/bifrost/pkg/common/config"
+	"github.com/sopial42/bifrost/pkg/common/metrics"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
@@ -43,8 +46,9 @@ func NewPGClient(cfg config.DBConfig) *PGClient {
 		bundebug.WithEnabled(false),
 		bundebug.FromEnv("DB_LOG_LEVEL"),
 	))
+	client.AddQueryHook(&metricsQueryHook{})
 
-	// client.AddQueryHook(tracing.NewTracingHook(cfg.TracingEnabled))
+	client.AddQueryHook(tracing.NewTracingHook(cfg.TracingEnabled))
 	return &PGClient{Client: client}
 }
 
@@ -55,3 +59,30 @@ func (pg *PGClient) Ping(c context.Context) error {
 	}
 	return nil
 }
+
+// metricsQueryHook records the duration of each query and the inserted rows
+type metricsQueryHook struct{}
+
+func (h *metricsQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *metricsQueryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	table := ""
+	if event.IQuery != nil {
+		table = event.IQuery.GetTableName()
+	}
+
+	// No row is an expected result, eg. the lookup of an unknown ID
+	err := event.Err
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+
+	var rows int64
+	if err == nil && event.Result != nil {
+		rows, _ = event.Result.RowsAffected()
+	}
+
+	metrics.ObserveQuery(event.Operation(), table, time.Since(event.StartTime), rows, err)
+}
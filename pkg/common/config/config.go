@@ -4,17 +4,24 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
 )
 
 type Config struct {
-	Cors   Cors
-	DB     DBConfig
-	Logger logger.Config
-	Port   string
+	Auth        Auth
+	Candles     Candles
+	Cors        Cors
+	DB          DBConfig
+	Idempotency Idempotency
+	Logger      logger.Config
+	Port        string
+	Tracing     tracing.Config
 }
 
 type DBConfig struct {
@@ -24,6 +31,27 @@ type DBConfig struct {
 	Password string
 	DBName   string
 	Unsecure bool
+	// AutoMigrate applies the pending migrations on startup
+	AutoMigrate bool
+	// TracingEnabled creates a span per query, when the tracing exporter is set
+	TracingEnabled bool
+}
+
+type Auth struct {
+	// Enabled requires an API key on all the API routes
+	Enabled bool
+}
+
+type Candles struct {
+	// Validation refuses the inconsistent candles on creation, or stores them flagged
+	Validation candles.Validation
+}
+
+type Idempotency struct {
+	// TTL is how long the response of an Idempotency-Key is replayed to the retries
+	TTL time.Duration
+	// LockTimeout is how long a request in progress holds its Idempotency-Key, the retries are refused meanwhile
+	LockTimeout time.Duration
 }
 
 type Cors struct {
@@ -45,11 +73,32 @@ func Load() *Config {
 			Password: mustGet("DB_PASSWORD"),
 			DBName:   mustGet("DB_NAME"),
 			Unsecure: mustGetBool("DB_UNSECURE_MODE"),
+			// Optional, migrations can be applied with the migrate subcommand instead
+			AutoMigrate:    getBool("DB_AUTO_MIGRATE", false),
+			TracingEnabled: getBool("DB_TRACING_ENABLED", false),
 		},
 		Port: mustGet("PORT"),
 		Cors: Cors{
 			AllowOrigin: mustGet("CORS_ALLOW_ORIGIN"),
 		},
+		Auth: Auth{
+			// Optional, the API is open unless enabled
+			Enabled: getBool("AUTH_ENABLED", false),
+		},
+		Candles: Candles{
+			// Optional, the inconsistent candles are refused by default
+			Validation: getValidation("CANDLES_VALIDATION", candles.ValidationReject),
+		},
+		Idempotency: Idempotency{
+			// Optional, retries are expected within a day
+			TTL: getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			// Optional, longer than the slowest write request, eg. a large import
+			LockTimeout: getDuration("IDEMPOTENCY_LOCK_TIMEOUT", 5*time.Minute),
+		},
+		Tracing: tracing.Config{
+			// Optional, none by default
+			Exporter: tracing.Exporter(get("TRACING_EXPORTER", string(tracing.ExporterNone))),
+		},
 	}
 }
 
@@ -61,6 +110,15 @@ func mustGet(key string) string {
 	return val
 }
 
+// get is the optional version of mustGet, it returns the fallback if the environment variable is not set
+func get(key string, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+
+	return fallback
+}
+
 // mustGetBool is a helper function to get a boolean value from an environment variable
 // It panics if the environment variable is not set or cannot be converted to a boolean
 func mustGetBool(key string) bool {
@@ -74,3 +132,42 @@ func mustGetBool(key string) bool {
 	}
 	return boolVal
 }
+
+// getBool is the optional version of mustGetBool, it returns the fallback if the environment variable is not set
+func getBool(key string, fallback bool) bool {
+	if os.Getenv(key) == "" {
+		return fallback
+	}
+
+	return mustGetBool(key)
+}
+
+// getDuration returns the duration of the environment variable, eg. 24h, or the fallback if it is not set
+// It panics if the environment variable cannot be parsed as a positive duration
+func getDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(val)
+	if err != nil || duration <= 0 {
+		log.Fatalf("unable to cast env var %s to a positive duration: %v", key, val)
+	}
+	return duration
+}
+
+// getValidation returns the candles validation of the environment variable, reject or flag, or the fallback if it is not set
+// It panics if the environment variable is another value
+func getValidation(key string, fallback candles.Validation) candles.Validation {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+
+	validation, err := candles.ParseValidation(val)
+	if err != nil {
+		log.Fatalf("unable to cast env var %s: %v", key, err)
+	}
+	return validation
+}
@@ -2,10 +2,12 @@ package errors
 
 import (
 	"fmt"
+	"strings"
 )
 
 type AppErrorCode int
 
+// The codes are exposed as app_code by the legacy error envelope, new codes must be appended
 const (
 	CodeErrUnknown AppErrorCode = iota
 	CodeErrUnexpected
@@ -30,14 +32,26 @@ var (
 type AppError struct {
 	Code    AppErrorCode `json:"app_code"`
 	Message string       `json:"message"`
-	Origin  error        `json:"-"`
+	// Violations lists the invalid fields of an invalid input
+	Violations []FieldViolation `json:"violations,omitempty"`
+	Origin     error            `json:"-"`
 }
 
 func (e AppError) Error() string {
+	message := e.Message
+	if len(e.Violations) > 0 {
+		violations := make([]string, len(e.Violations))
+		for i, v := range e.Violations {
+			violations[i] = v.String()
+		}
+
+		message = fmt.Sprintf("%s [%s]", message, strings.Join(violations, ", "))
+	}
+
 	if e.Origin != nil {
-		return fmt.Sprintf("%s: %v", e.Message, e.Origin)
+		return fmt.Sprintf("%s: %v", message, e.Origin)
 	}
-	return e.Message
+	return message
 }
 
 func (e AppError) Is(target error) bool {
@@ -100,3 +114,67 @@ func NewAlreadyExists(message string) *AppError {
 		Origin:  nil,
 	}
 }
+
+func NewInvalidFields(message string, violations []FieldViolation) *AppError {
+	return &AppError{
+		Code:       CodeErrInvalidInput,
+		Message:    message,
+		Violations: violations,
+	}
+}
+
+type ViolationCode string
+
+const (
+	ViolationRequired ViolationCode = "required"
+	// ViolationReadOnly is a field set by the server, eg. an ID
+	ViolationReadOnly ViolationCode = "read_only"
+	// ViolationInvalidFormat is a field of the wrong type or format, eg. a date that is not RFC3339
+	ViolationInvalidFormat ViolationCode = "invalid_format"
+	// ViolationInvalidValue is a well formed field with a value out of the allowed ones
+	ViolationInvalidValue ViolationCode = "invalid_value"
+)
+
+// FieldViolation is an invalid field of a request
+// Field is a JSON pointer (RFC 6901) to the body field, eg. /positions/3/tp, or the name of a query or path parameter
+type FieldViolation struct {
+	Field   string        `json:"field"`
+	Code    ViolationCode `json:"code"`
+	Message string        `json:"message"`
+}
+
+func (v FieldViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+}
+
+type FieldViolations []FieldViolation
+
+func (v *FieldViolations) Add(field string, code ViolationCode, message string) {
+	*v = append(*v, FieldViolation{Field: field, Code: code, Message: message})
+}
+
+// Err returns an invalid input error listing the violations, nil without violation
+func (v FieldViolations) Err(message string) error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	if len(v) == 1 {
+		return NewInvalidFields(fmt.Sprintf("%s: 1 invalid field", message), v)
+	}
+
+	return NewInvalidFields(fmt.Sprintf("%s: %d invalid fields", message, len(v)), v)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Pointer returns the JSON pointer of the reference tokens, eg. Pointer("positions", 3, "tp") is /positions/3/tp
+func Pointer(tokens ...any) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(pointerEscaper.Replace(fmt.Sprint(token)))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		tokens []any
+		want   string
+	}{
+		{tokens: nil, want: ""},
+		{tokens: []any{"positions", 3, "tp"}, want: "/positions/3/tp"},
+		{tokens: []any{"metadata", "a/b", "c~d"}, want: "/metadata/a~1b/c~0d"},
+	}
+
+	for _, tt := range tests {
+		if got := Pointer(tt.tokens...); got != tt.want {
+			t.Errorf("Pointer(%v) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestFieldViolationsErr(t *testing.T) {
+	violations := FieldViolations{}
+	if err := violations.Err("invalid candles"); err != nil {
+		t.Fatalf("Err() = %v, want nil without violation", err)
+	}
+
+	violations.Add("/candles/0/date", ViolationRequired, "date is required")
+	err := violations.Err("invalid candles")
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("Err() = %v, want an invalid input error", err)
+	}
+
+	if want := "invalid candles: 1 invalid field [/candles/0/date: date is required]"; err.Error() != want {
+		t.Errorf("Err() = %q, want %q", err.Error(), want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
 )
 
 type ErrResponse struct {
@@ -13,10 +14,11 @@ type ErrResponse struct {
 }
 
 type ErrDetails struct {
-	AppCode AppErrorCode `json:"app_code"`
-	Message string       `json:"message"`
-	TraceID string       `json:"trace_id,omitempty"`
-	Origin  error        `json:"-"`
+	AppCode    AppErrorCode     `json:"app_code"`
+	Message    string           `json:"message"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+	TraceID    string           `json:"trace_id,omitempty"`
+	Origin     error            `json:"-"`
 }
 
 // type ErrDetails struct {
@@ -31,33 +33,50 @@ var unexpectedErrMessage = ErrResponse{
 	},
 }
 
+// ErrorsHandler renders the errors as RFC 7807 problems when the client accepts application/problem+json,
+// in the legacy {"error": ...} envelope otherwise
+//
 //nolint:errcheck
 var ErrorsHandler = func(err error, ctx echo.Context) {
 	if ctx.Response().Committed {
 		return
 	}
 
-	// Handle appErrors
-	var appErr *AppError
+	status, details := errDetails(err, ctx)
+	if AcceptsProblem(ctx.Request().Header.Get(echo.HeaderAccept)) {
+		requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
+		if requestID == "" {
+			requestID = ctx.Request().Header.Get(echo.HeaderXRequestID)
+		}
+
+		ctx.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+		ctx.JSON(status, NewProblem(details, status, ctx.Request().URL.Path, requestID))
+		return
+	}
+
+	ctx.JSON(status, ErrResponse{Error: details})
+}
+
+// errDetails logs the error and returns its status and details, the unexpected ones are not exposed
+func errDetails(err error, ctx echo.Context) (int, ErrDetails) {
 	c := ctx.Request().Context()
 	log := logger.GetLogger(c)
 
+	// Handle appErrors
+	var appErr *AppError
 	if errors.As(err, &appErr) {
 		log.Err(appErr).Errorf("AppError: %v", appErr)
 		errResponse := ErrResponse{
 			Error: ErrDetails{
-				AppCode: appErr.Code,
-				Message: appErr.Message,
-				Origin:  appErr.Origin,
+				AppCode:    appErr.Code,
+				Message:    appErr.Message,
+				Violations: appErr.Violations,
+				TraceID:    tracing.GetTracingIDFromContext(c),
+				Origin:     appErr.Origin,
 			},
 		}
 
-		// if traceID := tracing.GetTracingIDFromContext(c); traceID != "" {
-		// 	errMessage.Error.TraceID = traceID
-		// }
-
-		ctx.JSON(errResponse.GetHTTPCode(), errResponse)
-		return
+		return errResponse.GetHTTPCode(), errResponse.Error
 	}
 
 	// Handle if its a client error
@@ -71,25 +90,19 @@ var ErrorsHandler = func(err error, ctx echo.Context) {
 			}
 
 			log.Err(err).Debugf("HTTP error")
-			errResponse := ErrResponse{
-				Error: ErrDetails{
-					Message: msgStr,
-				},
+			return httpErr.Code, ErrDetails{
+				Message: msgStr,
+				TraceID: tracing.GetTracingIDFromContext(c),
 			}
-
-			ctx.JSON(httpErr.Code, errResponse)
-			return
 		}
 	}
 
 	// Handle if its an unexpected error
-	// if traceID := tracing.GetTracingIDFromContext(c); traceID != "" {
-	// 	unexpectedErrMessage.TraceID = traceID
-	// }
-
-	unexpectedErrMessage.Error.Origin = err
+	errResponse := unexpectedErrMessage
+	errResponse.Error.TraceID = tracing.GetTracingIDFromContext(c)
+	errResponse.Error.Origin = err
 	log.Err(err).Errorf("HTTP unexpected error")
-	ctx.JSON(http.StatusInternalServerError, unexpectedErrMessage)
+	return http.StatusInternalServerError, errResponse.Error
 }
 
 func (e *ErrResponse) GetHTTPCode() int {
@@ -0,0 +1,126 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemTypePrefix prefixes the stable type URIs of the problems, eg. urn:bifrost:problem:invalid-input
+const problemTypePrefix = "urn:bifrost:problem:"
+
+// problemTypeBlank is the RFC 7807 type of the problems with no more semantics than their HTTP status
+const problemTypeBlank = "about:blank"
+
+type problemKind struct {
+	slug  string
+	title string
+}
+
+// problemKinds must never change, the type URIs are matched by the clients
+var problemKinds = map[AppErrorCode]problemKind{
+	CodeErrUnexpected:    {slug: "unexpected", title: "Unexpected error"},
+	CodeErrNotFound:      {slug: "not-found", title: "Not found"},
+	CodeErrUnauthorized:  {slug: "unauthorized", title: "Unauthorized"},
+	CodeErrForbidden:     {slug: "forbidden", title: "Forbidden"},
+	CodeErrInvalidInput:  {slug: "invalid-input", title: "Invalid input"},
+	CodeErrAlreadyExists: {slug: "already-exists", title: "Already exists"},
+}
+
+// Problem is an RFC 7807 error response, served as application/problem+json
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestID is the X-Request-Id of the request, to find its logs
+	RequestID  string           `json:"request_id,omitempty"`
+	TraceID    string           `json:"trace_id,omitempty"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+}
+
+// ProblemType returns the type URI of the app code, about:blank for an unknown code
+func ProblemType(code AppErrorCode) string {
+	kind, ok := problemKinds[code]
+	if !ok {
+		return problemTypeBlank
+	}
+
+	return problemTypePrefix + kind.slug
+}
+
+// NewProblem converts the error details, the problem of an unknown code is only described by its status
+func NewProblem(details ErrDetails, status int, instance string, requestID string) Problem {
+	title := http.StatusText(status)
+	if kind, ok := problemKinds[details.AppCode]; ok {
+		title = kind.title
+	}
+
+	return Problem{
+		Type:       ProblemType(details.AppCode),
+		Title:      title,
+		Status:     status,
+		Detail:     details.Message,
+		Instance:   instance,
+		RequestID:  requestID,
+		TraceID:    details.TraceID,
+		Violations: details.Violations,
+	}
+}
+
+// AppError converts the problem back, an about:blank problem is mapped from its status
+func (p Problem) AppError() *AppError {
+	code := CodeErrUnexpected
+	if slug, ok := strings.CutPrefix(p.Type, problemTypePrefix); ok {
+		for c, kind := range problemKinds {
+			if kind.slug == slug {
+				code = c
+			}
+		}
+	} else {
+		code = statusAppCode(p.Status)
+	}
+
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+
+	return &AppError{
+		Code:       code,
+		Message:    message,
+		Violations: p.Violations,
+	}
+}
+
+func statusAppCode(status int) AppErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeErrInvalidInput
+	case http.StatusUnauthorized:
+		return CodeErrUnauthorized
+	case http.StatusForbidden:
+		return CodeErrForbidden
+	case http.StatusNotFound:
+		return CodeErrNotFound
+	case http.StatusConflict:
+		return CodeErrAlreadyExists
+	}
+
+	return CodeErrUnexpected
+}
+
+// AcceptsProblem reports whether the Accept header asks for application/problem+json
+// The legacy {"error": ...} envelope stays the default during the transition
+func AcceptsProblem(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), MIMEApplicationProblemJSON) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestErrorsHandlerNegotiation(t *testing.T) {
+	e := echo.New()
+	SetCustomErrorHandler(e)
+	e.GET("/api/v1/candles", func(echo.Context) error {
+		return NewInvalidFields("invalid candles: 1 invalid field", []FieldViolation{{Field: "/candles/0/date", Code: ViolationRequired, Message: "date is required"}})
+	})
+
+	tests := []struct {
+		name        string
+		accept      string
+		target      string
+		wantProblem bool
+		wantStatus  int
+		wantType    string
+	}{
+		{name: "legacy by default", target: "/api/v1/candles", wantStatus: http.StatusBadRequest},
+		{name: "legacy for json", accept: "application/json", target: "/api/v1/candles", wantStatus: http.StatusBadRequest},
+		{name: "problem", accept: "application/json, application/problem+json;q=0.9", target: "/api/v1/candles", wantProblem: true, wantStatus: http.StatusBadRequest, wantType: "urn:bifrost:problem:invalid-input"},
+		{name: "problem of an echo error", accept: "application/problem+json", target: "/api/v1/unknown", wantProblem: true, wantStatus: http.StatusNotFound, wantType: "about:blank"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set(echo.HeaderAccept, tt.accept)
+			req.Header.Set(echo.HeaderXRequestID, "req-1")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if !tt.wantProblem {
+				var res ErrResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || res.Error.AppCode != CodeErrInvalidInput || len(res.Error.Violations) != 1 {
+					t.Errorf("body = %s, want the legacy envelope", rec.Body.String())
+				}
+				return
+			}
+
+			if contentType := rec.Header().Get(echo.HeaderContentType); contentType != MIMEApplicationProblemJSON {
+				t.Errorf("Content-Type = %q, want %q", contentType, MIMEApplicationProblemJSON)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("unable to decode the problem: %v", err)
+			}
+
+			if problem.Type != tt.wantType || problem.Status != tt.wantStatus || problem.Title == "" || problem.Instance != tt.target || problem.RequestID != "req-1" {
+				t.Errorf("problem = %+v, want type %s, status %d, instance %s and request ID req-1", problem, tt.wantType, tt.wantStatus, tt.target)
+			}
+		})
+	}
+}
+
+func TestProblemAppError(t *testing.T) {
+	for code := range problemKinds {
+		details := ErrDetails{AppCode: code, Message: "detail"}
+		errResponse := ErrResponse{Error: details}
+		appErr := NewProblem(details, errResponse.GetHTTPCode(), "/", "").AppError()
+		if appErr.Code != code || appErr.Message != "detail" {
+			t.Errorf("AppError() = %+v, want code %d", appErr, code)
+		}
+	}
+
+	blank := Problem{Type: problemTypeBlank, Title: "Method Not Allowed", Status: http.StatusMethodNotAllowed}
+	if appErr := blank.AppError(); appErr.Code != CodeErrUnexpected || appErr.Message != "Method Not Allowed" {
+		t.Errorf("AppError() = %+v, want an unexpected error with the title", appErr)
+	}
+}
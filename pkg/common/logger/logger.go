@@ -9,6 +9,8 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
+
+	"github.com/sopial42/bifrost/pkg/common/tracing"
 )
 
 type ctxKey struct{}
@@ -16,8 +18,9 @@ type ctxKey struct{}
 var loggerKey = &ctxKey{}
 
 const (
-	usernameKey = "username"
-	// requestIDKey = "request_id"
+	usernameKey  = "username"
+	traceIDKey   = "trace_id"
+	requestIDKey = "request_id"
 )
 
 type Config struct {
@@ -93,9 +96,13 @@ func SetLoggerMiddlewareEcho(e *echo.Echo, baseLogger Logger) {
 		return func(c echo.Context) error {
 			logger := baseLogger
 			ctx := c.Request().Context()
-			// if requestID := tracing.GetTracingIDFromContext(ctx); requestID != "" {
-			// 	logger = logger.WithField(requestIDKey, requestID)
-			// }
+			if traceID := tracing.GetTracingIDFromContext(ctx); traceID != "" {
+				logger = logger.WithField(traceIDKey, traceID)
+			}
+
+			if requestID := c.Response().Header().Get(echo.HeaderXRequestID); requestID != "" {
+				logger = logger.WithField(requestIDKey, requestID)
+			}
 
 			ctx = context.WithValue(ctx, loggerKey, logger)
 			c.SetRequest(c.Request().WithContext(ctx))
@@ -104,6 +111,12 @@ func SetLoggerMiddlewareEcho(e *echo.Echo, baseLogger Logger) {
 	})
 }
 
+// SetRequestIDMiddlewareEcho sets the X-Request-Id response header, from the request one or a generated one
+// It must be registered before the logger middleware, so the request logs have the ID returned to the client
+func SetRequestIDMiddlewareEcho(e *echo.Echo) {
+	e.Use(middleware.RequestID())
+}
+
 func SetUserDetailsToLogger(c echo.Context, username string) {
 	ctx := c.Request().Context()
 	logger := GetLogger(ctx)
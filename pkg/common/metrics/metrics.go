@@ -0,0 +1,145 @@
+// Package metrics exposes the Prometheus metrics of Bifrost on a dedicated registry
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bifrost"
+
+// unmatchedRoute labels the requests without route, so unknown URLs do not create a serie each
+const unmatchedRoute = "unmatched"
+
+// Registry holds all the Bifrost metrics, the Go runtime and process ones included
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of the database queries by operation, table and result.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"operation", "table", "result"})
+
+	rowsInserted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "rows_inserted_total",
+		Help:      "Rows inserted by entity, the entity is the table name.",
+	}, []string{"entity"})
+
+	positionsComputed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "positions",
+		Name:      "computed_total",
+		Help:      "Positions computed by result: closed when the TP or the SL is hit, open otherwise, or failed.",
+	}, []string{"result"})
+
+	positionsPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "positions",
+		Name:      "compute_all_pending",
+		Help:      "Positions left to process by the running compute-all, 0 when none is running.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		dbQueryDuration,
+		rowsInserted,
+		positionsComputed,
+		positionsPending,
+	)
+}
+
+// SetMetricsHandler exposes the registry on the route, eg. /metrics
+func SetMetricsHandler(e *echo.Echo, route string) {
+	e.GET(route, echo.WrapHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})))
+}
+
+// SetMetricsMiddlewareEcho observes the duration of the requests, the skipper excludes routes such as the metrics one
+// An error returned by the handler is sent through the error handler first, so its status is the one observed
+func SetMetricsMiddlewareEcho(e *echo.Echo, skipper func(c echo.Context) bool) {
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper != nil && skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" || route == "/*" {
+				route = unmatchedRoute
+			}
+
+			httpRequestDuration.
+				WithLabelValues(c.Request().Method, route, strconv.Itoa(c.Response().Status)).
+				Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	})
+}
+
+// RegisterDBStats exposes the connection pool statistics of sql.DB.Stats()
+func RegisterDBStats(db *sql.DB, dbName string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// ObserveQuery records a database query, the rows of a successful insert are counted for its table
+func ObserveQuery(operation string, table string, duration time.Duration, rows int64, err error) {
+	if table == "" {
+		table = "unknown"
+	}
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	dbQueryDuration.WithLabelValues(operation, table, result).Observe(duration.Seconds())
+	if err == nil && operation == "INSERT" && rows > 0 {
+		rowsInserted.WithLabelValues(table).Add(float64(rows))
+	}
+}
+
+type ComputeResult string
+
+const (
+	ComputeResultClosed ComputeResult = "closed"
+	ComputeResultOpen   ComputeResult = "open"
+	ComputeResultFailed ComputeResult = "failed"
+)
+
+func IncPositionsComputed(result ComputeResult) {
+	positionsComputed.WithLabelValues(string(result)).Inc()
+}
+
+// SetPositionsPending sets the progress of the compute-all
+func SetPositionsPending(count int) {
+	positionsPending.Set(float64(count))
+}
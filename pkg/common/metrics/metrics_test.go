@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSetMetricsMiddlewareEcho(t *testing.T) {
+	e := echo.New()
+	SetMetricsMiddlewareEcho(e, func(c echo.Context) bool { return c.Path() == "/metrics" })
+	SetMetricsHandler(e, "/metrics")
+	e.GET("/api/v1/things/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound, "not found")
+		}
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, url := range []string{"/api/v1/things/1", "/api/v1/things/2", "/api/v1/things/missing", "/unknown", "/metrics"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	tests := []struct {
+		route  string
+		status int
+		want   int
+	}{
+		{route: "/api/v1/things/:id", status: http.StatusOK, want: 2},
+		{route: "/api/v1/things/:id", status: http.StatusNotFound, want: 1},
+		{route: unmatchedRoute, status: http.StatusNotFound, want: 1},
+	}
+
+	for _, tt := range tests {
+		line := fmt.Sprintf(`bifrost_http_request_duration_seconds_count{method="GET",route="%s",status="%d"} %d`, tt.route, tt.status, tt.want)
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics do not contain %s", line)
+		}
+	}
+
+	if strings.Contains(body, `route="/metrics"`) {
+		t.Errorf("metrics contain the skipped /metrics route")
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	ObserveQuery("INSERT", "candles", time.Millisecond, 3, nil)
+	ObserveQuery("INSERT", "candles", time.Millisecond, 2, nil)
+	ObserveQuery("INSERT", "candles", time.Millisecond, 5, errors.New("unique violation"))
+	ObserveQuery("UPDATE", "candles", time.Millisecond, 7, nil)
+
+	if got := testutil.ToFloat64(rowsInserted.WithLabelValues("candles")); got != 5 {
+		t.Errorf("rows inserted = %v, want 5", got)
+	}
+
+	if got := testutil.CollectAndCount(dbQueryDuration); got != 3 {
+		t.Errorf("query duration series = %d, want 3: INSERT success, INSERT error and UPDATE success", got)
+	}
+}
@@ -0,0 +1,157 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+)
+
+// defaultRetryableMethods are the idempotent HTTP methods
+var defaultRetryableMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete}
+
+type retryPolicy struct {
+	// maxRetries is the count of retries after the first attempt, 0 disables the retries
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	methods        map[string]bool
+	budget         *retryBudget
+}
+
+func newRetryPolicy() retryPolicy {
+	p := retryPolicy{
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+	}
+	p.setMethods(defaultRetryableMethods)
+
+	return p
+}
+
+func (p *retryPolicy) setMethods(methods []string) {
+	p.methods = make(map[string]bool, len(methods))
+	for _, m := range methods {
+		p.methods[m] = true
+	}
+}
+
+// WithRetry retries the failed requests of the retryable methods up to maxRetries times
+// The delay before a retry grows exponentially from initialBackoff to maxBackoff, with a full jitter
+// Network errors, 429 and 5xx responses are retried, a 429 or 503 Retry-After header is a minimum delay
+func WithRetry(maxRetries int, initialBackoff time.Duration, maxBackoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retry.maxRetries = maxRetries
+		if initialBackoff > 0 {
+			c.retry.initialBackoff = initialBackoff
+		}
+
+		if maxBackoff > 0 {
+			c.retry.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithRetryableMethods replaces the methods that are retried, GET, HEAD, OPTIONS, PUT and DELETE by default
+// Only add POST or PATCH when the server handles them idempotently, see WithIdempotencyKeys
+func WithRetryableMethods(methods ...string) ClientOption {
+	return func(c *Client) {
+		c.retry.setMethods(methods)
+	}
+}
+
+// WithRetryBudget bounds the retries to a ratio of the requests, eg. 0.1 allows one retry every ten requests
+// minRetries is the count of retries allowed before any request, so a client that just started can retry
+// It keeps a failing server from receiving all the requests maxRetries+1 times
+func WithRetryBudget(ratio float64, minRetries int) ClientOption {
+	return func(c *Client) {
+		c.retry.budget = newRetryBudget(ratio, minRetries)
+	}
+}
+
+// retryBudget is a token bucket, each request deposits ratio token and each retry withdraws one
+type retryBudget struct {
+	mu        sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+func newRetryBudget(ratio float64, minRetries int) *retryBudget {
+	return &retryBudget{
+		ratio: ratio,
+		// The bucket does not save more than the minimum, so a long healthy period does not allow a retry storm
+		maxTokens: max(float64(minRetries), 1),
+		tokens:    float64(minRetries),
+	}
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+b.ratio, b.maxTokens)
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// shouldRetry returns whether the attempt result is a transient failure
+func shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		// The caller cancelled the request, or its deadline is over
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before the given retry, starting at 1
+func (p *retryPolicy) backoff(retry int, res *http.Response) time.Duration {
+	ceiling := p.maxBackoff
+	// Large shifts overflow, the max backoff is reached long before
+	if retry <= 32 {
+		if exp := p.initialBackoff << (retry - 1); exp > 0 && exp < ceiling {
+			ceiling = exp
+		}
+	}
+
+	delay := time.Duration(rand.Int64N(int64(ceiling) + 1))
+	if res != nil {
+		if retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			delay = max(delay, time.Duration(retryAfter)*time.Second)
+		}
+	}
+
+	return delay
+}
+
+// sleep waits for the delay, it returns early with the context error
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -5,16 +5,31 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
 	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	"github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/idempotency"
 )
 
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	retry      retryPolicy
+	apiKey     string
+	// idempotencyKeys sends an Idempotency-Key header with the POST and PATCH requests
+	idempotencyKeys bool
 }
 
 func NewSDKClient(baseURL string, opts ...ClientOption) *Client {
@@ -23,6 +38,7 @@ func NewSDKClient(baseURL string, opts ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		retry: newRetryPolicy(),
 	}
 
 	for _, opt := range opts {
@@ -40,9 +56,28 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
+// WithTimeout sets the timeout of each attempt, the context bounds the whole request with its retries
+// The HTTP client is copied, so a client shared through WithHTTPClient, eg. http.DefaultClient, keeps its own timeout
 func WithTimeout(timeout time.Duration) ClientOption {
 	return func(c *Client) {
-		c.httpClient.Timeout = timeout
+		httpClient := *c.httpClient
+		httpClient.Timeout = timeout
+		c.httpClient = &httpClient
+	}
+}
+
+// WithAPIKey authenticates the requests with the key, required when the server authentication is enabled
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithIdempotencyKeys sends a new Idempotency-Key header with each POST and PATCH request, reused by its retries
+// The server then replays the response of a request that already succeeded, so these methods are also retried
+func WithIdempotencyKeys() ClientOption {
+	return func(c *Client) {
+		c.idempotencyKeys = true
 	}
 }
 
@@ -60,6 +95,16 @@ func (c *Client) handleResponse(ctx context.Context, res *http.Response) ([]byte
 		return respBody, nil
 	}
 
+	// The server only answers problems when asked to, the legacy envelope is still decoded during the transition
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType == appErrors.MIMEApplicationProblemJSON {
+		var problem appErrors.Problem
+		if err := json.Unmarshal(respBody, &problem); err != nil {
+			return nil, appErrors.NewUnexpected("failed to handle problem response", err)
+		}
+
+		return nil, problem.AppError()
+	}
+
 	errResponse := struct {
 		Error *appErrors.AppError `json:"error"`
 	}{}
@@ -78,25 +123,107 @@ func (c *Client) handleResponse(ctx context.Context, res *http.Response) ([]byte
 	return nil, appErr
 }
 
-// Patch performs a PATCH request and handles error responses
-func (c *Client) Patch(ctx context.Context, url string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest("PATCH", c.baseURL+url, bytes.NewReader(body))
-	if err != nil {
-		return nil, appErrors.NewUnexpected("sdk unable to create PATCH request", err)
+// do performs the request with the context, and retries it on transient failures if allowed by the retry policy
+func (c *Client) do(ctx context.Context, method string, url string, body []byte) ([]byte, error) {
+	idempotencyKey := ""
+	if c.idempotencyKeys && (method == http.MethodPost || method == http.MethodPatch) {
+		idempotencyKey = uuid.NewString()
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, appErrors.NewUnexpected("sdk unable to PATCH request", err)
+	retryable := c.retry.maxRetries > 0 && (c.retry.methods[method] || idempotencyKey != "")
+	if c.retry.budget != nil {
+		c.retry.budget.deposit()
 	}
 
-	return c.handleResponse(ctx, res)
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		attemptCtx, span := tracing.Start(ctx, "HTTP "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.full", c.baseURL+url),
+			attribute.Int("http.request.resend_count", attempt),
+		))
+
+		req, err := http.NewRequestWithContext(attemptCtx, method, c.baseURL+url, bodyReader)
+		if err != nil {
+			tracing.End(span, err)
+			return nil, appErrors.NewUnexpected(fmt.Sprintf("sdk unable to create %s request", method), err)
+		}
+
+		contentType := ""
+		if body != nil {
+			contentType = "application/json"
+		}
+		c.setHeaders(attemptCtx, req, contentType)
+
+		if idempotencyKey != "" {
+			req.Header.Set(idempotency.HeaderIdempotencyKey, idempotencyKey)
+		}
+
+		res, err := c.httpClient.Do(req)
+		endAttemptSpan(span, res, err)
+		if !retryable || attempt >= c.retry.maxRetries || !shouldRetry(ctx, res, err) ||
+			(c.retry.budget != nil && !c.retry.budget.withdraw()) {
+			if err != nil {
+				return nil, appErrors.NewUnexpected(fmt.Sprintf("sdk unable to %s request", method), err)
+			}
+
+			return c.handleResponse(ctx, res)
+		}
+
+		delay := c.retry.backoff(attempt+1, res)
+		if res != nil {
+			// Drain the body so the connection is reused
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		logger.GetLogger(ctx).Debugf("sdk retry %s %s in %s, attempt %d failed: %v", method, url, delay, attempt+1, attemptError(res, err))
+		if err := sleep(ctx, delay); err != nil {
+			return nil, appErrors.NewUnexpected(fmt.Sprintf("sdk unable to %s request", method), err)
+		}
+	}
 }
 
-// Post performs a POST request and handles error responses
-func (c *Client) Post(ctx context.Context, url string, body []byte) ([]byte, error) {
-	res, err := c.httpClient.Post(c.baseURL+url, "application/json", bytes.NewReader(body))
+// setHeaders sets the headers common to all the requests, the content type is omitted when empty
+func (c *Client) setHeaders(ctx context.Context, req *http.Request, contentType string) {
+	// The W3C traceparent header makes the server spans children of the attempt one
+	tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	// Errors are answered as RFC 7807 problems, older servers still answer the legacy envelope
+	req.Header.Set("Accept", "application/json, "+appErrors.MIMEApplicationProblemJSON)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set(auth.HeaderAPIKey, c.apiKey)
+	}
+}
+
+// PostStream performs a POST request streaming the body, eg. a bulk upload, and handles error responses
+// A stream can not be replayed, so it is never retried, and only the context bounds it, not the client timeout
+func (c *Client) PostStream(ctx context.Context, url string, contentType string, body io.Reader) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "HTTP "+http.MethodPost, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", http.MethodPost),
+		attribute.String("url.full", c.baseURL+url),
+	))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+url, body)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, appErrors.NewUnexpected("sdk unable to create POST request", err)
+	}
+
+	c.setHeaders(ctx, req, contentType)
+
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	res, err := streamClient.Do(req)
+	endAttemptSpan(span, res, err)
 	if err != nil {
 		return nil, appErrors.NewUnexpected("sdk unable to POST request", err)
 	}
@@ -104,11 +231,37 @@ func (c *Client) Post(ctx context.Context, url string, body []byte) ([]byte, err
 	return c.handleResponse(ctx, res)
 }
 
-func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
-	res, err := c.httpClient.Get(c.baseURL + url)
+// endAttemptSpan ends the span of an attempt, 4xx and 5xx responses are errors of a client span
+func endAttemptSpan(span trace.Span, res *http.Response, err error) {
+	if err == nil && res != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+		if res.StatusCode >= http.StatusBadRequest {
+			err = errors.New(res.Status)
+		}
+	}
+
+	tracing.End(span, err)
+}
+
+// attemptError describes a failed attempt for the logs
+func attemptError(res *http.Response, err error) string {
 	if err != nil {
-		return nil, appErrors.NewUnexpected("sdk unable to GET request", err)
+		return err.Error()
 	}
 
-	return c.handleResponse(ctx, res)
+	return res.Status
+}
+
+// Patch performs a PATCH request and handles error responses
+func (c *Client) Patch(ctx context.Context, url string, body []byte) ([]byte, error) {
+	return c.do(ctx, http.MethodPatch, url, body)
+}
+
+// Post performs a POST request and handles error responses
+func (c *Client) Post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	return c.do(ctx, http.MethodPost, url, body)
+}
+
+func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, url, nil)
 }
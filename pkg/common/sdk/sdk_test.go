@@ -0,0 +1,341 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	"github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/idempotency"
+)
+
+// newFlakyServer fails the first requests with the given status, then succeeds
+func newFlakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"app_code":"UNEXPECTED_ERROR","message":"flaky"}}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, calls
+}
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		status    int
+		method    string
+		opts      []ClientOption
+		wantErr   bool
+		wantCalls int32
+	}{
+		{
+			name:      "no retry by default",
+			failures:  1,
+			status:    http.StatusBadGateway,
+			method:    http.MethodGet,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "GET retried on 5xx",
+			failures:  2,
+			status:    http.StatusBadGateway,
+			method:    http.MethodGet,
+			opts:      []ClientOption{WithRetry(3, time.Millisecond, time.Millisecond)},
+			wantCalls: 3,
+		},
+		{
+			name:      "retries exhausted",
+			failures:  5,
+			status:    http.StatusServiceUnavailable,
+			method:    http.MethodGet,
+			opts:      []ClientOption{WithRetry(2, time.Millisecond, time.Millisecond)},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+		{
+			name:      "4xx not retried",
+			failures:  1,
+			status:    http.StatusBadRequest,
+			method:    http.MethodGet,
+			opts:      []ClientOption{WithRetry(3, time.Millisecond, time.Millisecond)},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "POST not retried by default",
+			failures:  1,
+			status:    http.StatusBadGateway,
+			method:    http.MethodPost,
+			opts:      []ClientOption{WithRetry(3, time.Millisecond, time.Millisecond)},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:     "POST retried when allowed",
+			failures: 1,
+			status:   http.StatusBadGateway,
+			method:   http.MethodPost,
+			opts: []ClientOption{
+				WithRetry(3, time.Millisecond, time.Millisecond),
+				WithRetryableMethods(http.MethodGet, http.MethodPost),
+			},
+			wantCalls: 2,
+		},
+		{
+			name:     "retry budget exhausted",
+			failures: 5,
+			status:   http.StatusBadGateway,
+			method:   http.MethodGet,
+			opts: []ClientOption{
+				WithRetry(3, time.Millisecond, time.Millisecond),
+				WithRetryBudget(0, 1),
+			},
+			wantErr:   true,
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newFlakyServer(t, tt.failures, tt.status)
+			client := NewSDKClient(server.URL, tt.opts...)
+
+			var err error
+			switch tt.method {
+			case http.MethodPost:
+				_, err = client.Post(context.Background(), "/", []byte(`{}`))
+			default:
+				_, err = client.Get(context.Background(), "/")
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("request error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server received %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientRetry_ContextCancelled(t *testing.T) {
+	server, calls := newFlakyServer(t, 100, http.StatusBadGateway)
+	client := NewSDKClient(server.URL, WithRetry(100, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Get(ctx, "/")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get() error = %v, want the context error", err)
+	}
+
+	if got := calls.Load(); got > 2 {
+		t.Errorf("server received %d calls, the retries should stop with the context", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := newRetryPolicy()
+	for retry := 1; retry < 100; retry++ {
+		if delay := p.backoff(retry, nil); delay < 0 || delay > p.maxBackoff {
+			t.Fatalf("backoff(%d) = %s, want between 0 and %s", retry, delay, p.maxBackoff)
+		}
+	}
+
+	res := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+	if delay := p.backoff(1, res); delay < 2*time.Second {
+		t.Errorf("backoff() = %s, want at least the Retry-After delay", delay)
+	}
+}
+
+func TestClientTimeout_KeepsTheSharedClient(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+
+	client := NewSDKClient("http://localhost", WithHTTPClient(shared), WithTimeout(time.Second))
+	if client.httpClient.Timeout != time.Second {
+		t.Errorf("client timeout = %s, want 1s", client.httpClient.Timeout)
+	}
+
+	if shared.Timeout != time.Minute {
+		t.Errorf("shared client timeout = %s, want it unchanged", shared.Timeout)
+	}
+}
+
+func TestClientAPIKey(t *testing.T) {
+	var got atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get(auth.HeaderAPIKey))
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	if _, err := NewSDKClient(server.URL).Get(context.Background(), "/"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if key := got.Load(); key != "" {
+		t.Errorf("%s header = %q, want none without WithAPIKey", auth.HeaderAPIKey, key)
+	}
+
+	if _, err := NewSDKClient(server.URL, WithAPIKey("bfk_0a1b2c3d_secret")).Post(context.Background(), "/", []byte(`{}`)); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	if key := got.Load(); key != "bfk_0a1b2c3d_secret" {
+		t.Errorf("%s header = %q, want the client key", auth.HeaderAPIKey, key)
+	}
+}
+
+func TestClientIdempotencyKeys(t *testing.T) {
+	keys := make(chan string, 10)
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get(idempotency.HeaderIdempotencyKey)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	// Without the option a POST is not retried, nor sent with a key
+	if _, err := NewSDKClient(server.URL, WithRetry(2, time.Millisecond, time.Millisecond)).Post(context.Background(), "/", []byte(`{}`)); err == nil {
+		t.Fatal("Post() error = nil, want the 503")
+	}
+
+	if key := <-keys; key != "" {
+		t.Errorf("%s header = %q, want none without WithIdempotencyKeys", idempotency.HeaderIdempotencyKey, key)
+	}
+
+	calls.Store(0)
+	client := NewSDKClient(server.URL, WithRetry(2, time.Millisecond, time.Millisecond), WithIdempotencyKeys())
+	if _, err := client.Post(context.Background(), "/", []byte(`{}`)); err != nil {
+		t.Fatalf("Post() error = %v, want the retry to succeed", err)
+	}
+
+	first, retry := <-keys, <-keys
+	if first == "" || retry != first {
+		t.Errorf("keys = %q and %q, want the same key for the retry", first, retry)
+	}
+
+	if _, err := client.Post(context.Background(), "/", []byte(`{}`)); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	if next := <-keys; next == "" || next == first {
+		t.Errorf("key = %q, want a new key for another request", next)
+	}
+
+	if _, err := client.Get(context.Background(), "/"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if key := <-keys; key != "" {
+		t.Errorf("%s header = %q, want none for a GET", idempotency.HeaderIdempotencyKey, key)
+	}
+}
+
+func TestClientTracePropagation(t *testing.T) {
+	if _, err := tracing.Init(context.Background(), tracing.Config{Exporter: tracing.ExporterNone}); err != nil {
+		t.Fatalf("tracing.Init() error = %v", err)
+	}
+
+	var got atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get("traceparent"))
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	if _, err := NewSDKClient(server.URL).Get(ctx, "/"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if header, _ := got.Load().(string); !strings.HasPrefix(header, "00-"+traceID.String()+"-") {
+		t.Errorf("traceparent header = %q, want the trace %s", header, traceID)
+	}
+}
+
+func TestClientErrorFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "legacy envelope",
+			contentType: "application/json",
+			body:        `{"error":{"app_code":5,"message":"invalid positions: 1 invalid field","violations":[{"field":"/positions/3/tp","code":"required","message":"tp is required"}]}}`,
+		},
+		{
+			name:        "problem",
+			contentType: "application/problem+json; charset=UTF-8",
+			body:        `{"type":"urn:bifrost:problem:invalid-input","title":"Invalid input","status":400,"detail":"invalid positions: 1 invalid field","violations":[{"field":"/positions/3/tp","code":"required","message":"tp is required"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept.Store(r.Header.Get("Accept"))
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+
+			_, err := NewSDKClient(server.URL).Post(context.Background(), "/", []byte(`{}`))
+
+			if header, _ := accept.Load().(string); !appErrors.AcceptsProblem(header) {
+				t.Errorf("Accept header = %q, want problems accepted", header)
+			}
+
+			var appErr *appErrors.AppError
+			if !errors.As(err, &appErr) || !errors.Is(err, appErrors.ErrInvalidInput) {
+				t.Fatalf("Post() error = %v, want an invalid input error", err)
+			}
+
+			if appErr.Message != "invalid positions: 1 invalid field" {
+				t.Errorf("message = %q, want the detail", appErr.Message)
+			}
+
+			want := appErrors.FieldViolation{Field: "/positions/3/tp", Code: appErrors.ViolationRequired, Message: "tp is required"}
+			if len(appErr.Violations) != 1 || appErr.Violations[0] != want {
+				t.Errorf("violations = %+v, want [%+v]", appErr.Violations, want)
+			}
+		})
+	}
+}
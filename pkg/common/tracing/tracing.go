@@ -0,0 +1,136 @@
+// Package tracing configures OpenTelemetry: the tracer provider and its exporter, the W3C propagation,
+// and the spans of the echo handlers, the services and the bun queries
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/extra/bunotel"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ServiceName = "bifrost"
+
+	// instrumentationName names the tracer of the Bifrost spans
+	instrumentationName = "github.com/sopial42/bifrost"
+)
+
+type Exporter string
+
+const (
+	// ExporterNone creates no span, the propagation still forwards the incoming trace context
+	ExporterNone Exporter = "none"
+	// ExporterStdout prints the spans, eg. to break down a slow request locally
+	ExporterStdout Exporter = "stdout"
+	// ExporterOTLP sends the spans over OTLP/HTTP, configured with the standard OTEL_EXPORTER_OTLP_* env vars
+	ExporterOTLP Exporter = "otlp"
+)
+
+type Config struct {
+	Exporter Exporter
+}
+
+// Init sets the global tracer provider and the W3C trace context and baggage propagators
+// The returned shutdown flushes the pending spans, it must be called before exiting
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected none, stdout or otlp", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the %s tracing exporter: %w", cfg.Exporter, err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the default service name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the tracing resource: %w", err)
+	}
+
+	// The sampler is parent based by default, OTEL_TRACES_SAMPLER changes it
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// SetTracingMiddlewareEcho starts a span per request, named after the route, from the incoming W3C trace context
+// It must be the first middleware, so the logger and the handlers see the span
+func SetTracingMiddlewareEcho(e *echo.Echo, skipper func(c echo.Context) bool) {
+	e.Use(otelecho.Middleware(ServiceName, otelecho.WithSkipper(skipper)))
+}
+
+// NewTracingHook returns the bun hook creating a span per query, a noop hook if disabled
+func NewTracingHook(enabled bool) bun.QueryHook {
+	if !enabled {
+		return noopQueryHook{}
+	}
+
+	return bunotel.NewQueryHook(bunotel.WithDBName(ServiceName))
+}
+
+type noopQueryHook struct{}
+
+func (noopQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (noopQueryHook) AfterQuery(context.Context, *bun.QueryEvent) {}
+
+// Start starts a child span of the context one, eg. for a service method
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// GetTracingIDFromContext returns the trace ID of the current span, empty without span
+func GetTracingIDFromContext(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
+
+// Inject adds the W3C headers of the context span to the outgoing request headers
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
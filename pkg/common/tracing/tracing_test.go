@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter Exporter
+		wantErr  bool
+	}{
+		{name: "default", exporter: ""},
+		{name: "none", exporter: ExporterNone},
+		{name: "stdout", exporter: ExporterStdout},
+		{name: "unknown", exporter: "jaeger", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Init(context.Background(), Config{Exporter: tt.exporter})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Init() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetTracingIDFromContext(t *testing.T) {
+	if id := GetTracingIDFromContext(context.Background()); id != "" {
+		t.Errorf("GetTracingIDFromContext() = %q, want empty without span", id)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	if id := GetTracingIDFromContext(ctx); id != traceID.String() {
+		t.Errorf("GetTracingIDFromContext() = %q, want %q", id, traceID)
+	}
+}
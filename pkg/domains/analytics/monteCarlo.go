@@ -0,0 +1,197 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+)
+
+const (
+	DefaultIterations = 1000
+	MaxIterations     = 100000
+)
+
+var DefaultPercentiles = []float64{5, 25, 50, 75, 95}
+
+// ResamplingMethod is the way a trades sequence is resampled
+type ResamplingMethod string
+
+const (
+	// Bootstrap draws trades with replacement, the final return varies
+	Bootstrap ResamplingMethod = "bootstrap"
+	// Permutation shuffles the trades order, only the path dependent stats vary
+	Permutation ResamplingMethod = "permutation"
+)
+
+func ParseResamplingMethod(arg string) (ResamplingMethod, error) {
+	switch ResamplingMethod(arg) {
+	case "":
+		return Bootstrap, nil
+	case Bootstrap, Permutation:
+		return ResamplingMethod(arg), nil
+	default:
+		return "", fmt.Errorf("resampling method not allowed: %s", arg)
+	}
+}
+
+type MonteCarloRequest struct {
+	Pair              common.Pair             `json:"pair"`
+	Interval          common.Interval         `json:"interval"`
+	BuySignalFullname buySignals.Fullname     `json:"buy_signal_fullname"`
+	PositionFullname  positions.Fullname      `json:"position_fullname"`
+	WinlossRatio      *positions.WinLossRatio `json:"winloss_ratio,omitempty"`
+	Method            ResamplingMethod        `json:"method"`
+	Iterations        int                     `json:"iterations"`
+	Seed              int64                   `json:"seed"`
+	Percentiles       []float64               `json:"percentiles"`
+	StartDate         *time.Time              `json:"start_date,omitempty"`
+	EndDate           *time.Time              `json:"end_date,omitempty"`
+}
+
+// PathStats describes one equity curve built from a trades sequence
+type PathStats struct {
+	FinalReturn float64 `json:"final_return"`
+	// MaxDrawdown is the largest peak to trough loss of the equity curve, 0.2 is a 20% drawdown
+	MaxDrawdown         float64 `json:"max_drawdown"`
+	LongestLosingStreak int     `json:"longest_losing_streak"`
+}
+
+type Percentile struct {
+	Percentile float64 `json:"percentile"`
+	Value      float64 `json:"value"`
+}
+
+type Distribution struct {
+	Mean        float64      `json:"mean"`
+	Percentiles []Percentile `json:"percentiles"`
+}
+
+type MonteCarlo struct {
+	Request MonteCarloRequest `json:"request"`
+	Trades  int               `json:"trades"`
+	// Original is the stats of the trades in their historical order
+	Original            PathStats    `json:"original"`
+	FinalReturn         Distribution `json:"final_return"`
+	MaxDrawdown         Distribution `json:"max_drawdown"`
+	LongestLosingStreak Distribution `json:"longest_losing_streak"`
+}
+
+// ComputePathStats computes the stats of the ratios taken in sequence, compounding every trade
+func ComputePathStats(ratios []float64) PathStats {
+	stats := PathStats{}
+	equity, peak := 1.0, 1.0
+	streak := 0
+	for _, r := range ratios {
+		equity *= r
+		if equity > peak {
+			peak = equity
+		}
+
+		if drawdown := 1 - equity/peak; drawdown > stats.MaxDrawdown {
+			stats.MaxDrawdown = drawdown
+		}
+
+		if r <= 1 {
+			streak++
+			if streak > stats.LongestLosingStreak {
+				stats.LongestLosingStreak = streak
+			}
+		} else {
+			streak = 0
+		}
+	}
+
+	stats.FinalReturn = equity - 1
+	return stats
+}
+
+// RunMonteCarlo resamples the ratios the given count of iterations
+// The same seed always returns the same distributions
+func RunMonteCarlo(ratios []float64, method ResamplingMethod, iterations int, seed int64, percentiles []float64) (*MonteCarlo, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("no trades to resample")
+	}
+
+	if iterations <= 0 || iterations > MaxIterations {
+		return nil, fmt.Errorf("iterations should be between 1 and %d: %d", MaxIterations, iterations)
+	}
+
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile should be between 0 and 100: %v", p)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	finalReturns := make([]float64, iterations)
+	drawdowns := make([]float64, iterations)
+	streaks := make([]float64, iterations)
+	sample := make([]float64, len(ratios))
+
+	for i := 0; i < iterations; i++ {
+		switch method {
+		case Permutation:
+			copy(sample, ratios)
+			rng.Shuffle(len(sample), func(a, b int) {
+				sample[a], sample[b] = sample[b], sample[a]
+			})
+		case Bootstrap:
+			for j := range sample {
+				sample[j] = ratios[rng.Intn(len(ratios))]
+			}
+		default:
+			return nil, fmt.Errorf("resampling method not allowed: %s", method)
+		}
+
+		stats := ComputePathStats(sample)
+		finalReturns[i] = stats.FinalReturn
+		drawdowns[i] = stats.MaxDrawdown
+		streaks[i] = float64(stats.LongestLosingStreak)
+	}
+
+	return &MonteCarlo{
+		Trades:              len(ratios),
+		Original:            ComputePathStats(ratios),
+		FinalReturn:         newDistribution(finalReturns, percentiles),
+		MaxDrawdown:         newDistribution(drawdowns, percentiles),
+		LongestLosingStreak: newDistribution(streaks, percentiles),
+	}, nil
+}
+
+func newDistribution(values []float64, percentiles []float64) Distribution {
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	d := Distribution{
+		Mean:        sum / float64(len(values)),
+		Percentiles: make([]Percentile, len(percentiles)),
+	}
+
+	for i, p := range percentiles {
+		d.Percentiles[i] = Percentile{Percentile: p, Value: percentileOf(values, p)}
+	}
+
+	return d
+}
+
+// percentileOf interpolates linearly between the closest ranks of the sorted values
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	low := int(math.Floor(rank))
+	high := int(math.Ceil(rank))
+	return sorted[low] + (sorted[high]-sorted[low])*(rank-float64(low))
+}
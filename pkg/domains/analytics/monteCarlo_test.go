@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestComputePathStats(t *testing.T) {
+	got := ComputePathStats([]float64{1.1, 0.9, 0.9, 1.2, 0.95})
+
+	if math.Abs(got.FinalReturn-(1.1*0.9*0.9*1.2*0.95-1)) > 1e-9 {
+		t.Errorf("ComputePathStats().FinalReturn = %v", got.FinalReturn)
+	}
+
+	if math.Abs(got.MaxDrawdown-0.19) > 1e-9 {
+		t.Errorf("ComputePathStats().MaxDrawdown = %v, want 0.19", got.MaxDrawdown)
+	}
+
+	if got.LongestLosingStreak != 2 {
+		t.Errorf("ComputePathStats().LongestLosingStreak = %v, want 2", got.LongestLosingStreak)
+	}
+}
+
+func TestRunMonteCarlo(t *testing.T) {
+	ratios := []float64{1.05, 0.98, 1.05, 0.98, 0.98, 1.1, 0.97}
+	tests := []struct {
+		name        string
+		method      ResamplingMethod
+		iterations  int
+		percentiles []float64
+		wantErr     bool
+	}{
+		{name: "bootstrap", method: Bootstrap, iterations: 500, percentiles: DefaultPercentiles},
+		{name: "permutation", method: Permutation, iterations: 500, percentiles: DefaultPercentiles},
+		{name: "invalid iterations", method: Bootstrap, iterations: 0, wantErr: true},
+		{name: "invalid percentile", method: Bootstrap, iterations: 10, percentiles: []float64{101}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunMonteCarlo(ratios, tt.method, tt.iterations, 42, tt.percentiles)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunMonteCarlo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			again, _ := RunMonteCarlo(ratios, tt.method, tt.iterations, 42, tt.percentiles)
+			if !reflect.DeepEqual(got, again) {
+				t.Errorf("RunMonteCarlo() is not reproducible with the same seed")
+			}
+
+			for _, d := range []Distribution{got.FinalReturn, got.MaxDrawdown, got.LongestLosingStreak} {
+				for i := 1; i < len(d.Percentiles); i++ {
+					if d.Percentiles[i].Value < d.Percentiles[i-1].Value {
+						t.Errorf("RunMonteCarlo() percentiles are not sorted: %+v", d.Percentiles)
+					}
+				}
+			}
+
+			// A permutation keeps the product of the ratios
+			if tt.method == Permutation {
+				for _, p := range got.FinalReturn.Percentiles {
+					if math.Abs(p.Value-got.Original.FinalReturn) > 1e-9 {
+						t.Errorf("RunMonteCarlo() permutation final return = %v, want %v", p.Value, got.Original.FinalReturn)
+					}
+				}
+			}
+		})
+	}
+}
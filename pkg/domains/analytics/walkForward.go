@@ -0,0 +1,375 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+)
+
+const LoggerKeyWalkForwardID = "walk_forward_id"
+
+// MaxWindows bounds the count of windows a walk-forward evaluation can build
+const MaxWindows = 500
+
+type ID uuid.UUID
+
+func (i ID) String() string {
+	return uuid.UUID(i).String()
+}
+
+func (i ID) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%s"`, uuid.UUID(i).String())), nil
+}
+
+func (i *ID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*i = ID(parsed)
+	return nil
+}
+
+// Metric is the in-sample performance used to select the best strategy of a window
+type Metric string
+
+const (
+	MetricExpectancy   Metric = "expectancy"
+	MetricTotalReturn  Metric = "total_return"
+	MetricWinRate      Metric = "win_rate"
+	MetricProfitFactor Metric = "profit_factor"
+)
+
+var AllAvailableMetrics = []Metric{
+	MetricExpectancy,
+	MetricTotalReturn,
+	MetricWinRate,
+	MetricProfitFactor,
+}
+
+func ParseMetrics(args []string) ([]Metric, error) {
+	if len(args) == 0 {
+		return AllAvailableMetrics, nil
+	}
+
+	metrics := make([]Metric, 0, len(args))
+	errors := []string{}
+	for _, arg := range args {
+		found := false
+		for _, m := range AllAvailableMetrics {
+			if Metric(arg) == m {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errors = append(errors, arg)
+			continue
+		}
+
+		metrics = append(metrics, Metric(arg))
+	}
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("metrics not allowed: %s", errors)
+	}
+
+	return metrics, nil
+}
+
+func (m Metric) value(p positions.Performance) float64 {
+	switch m {
+	case MetricTotalReturn:
+		return p.TotalReturn
+	case MetricWinRate:
+		return p.WinRate
+	case MetricProfitFactor:
+		// A strategy without loss has the best possible profit factor
+		if p.Losses == 0 && p.Wins > 0 {
+			return math.Inf(1)
+		}
+		return p.ProfitFactor
+	default:
+		return p.Expectancy
+	}
+}
+
+// Strategy identifies a buy signal and position strategies couple
+type Strategy struct {
+	BuySignalFullname buySignals.Fullname     `json:"buy_signal_fullname"`
+	PositionFullname  positions.Fullname      `json:"position_fullname"`
+	WinlossRatio      *positions.WinLossRatio `json:"winloss_ratio,omitempty"`
+}
+
+func (s Strategy) key() string {
+	ratio := "none"
+	if s.WinlossRatio != nil {
+		ratio = fmt.Sprintf("%.9f", float64(*s.WinlossRatio))
+	}
+
+	return fmt.Sprintf("%s|%s|%s", s.BuySignalFullname, s.PositionFullname, ratio)
+}
+
+// Ratios returns the ratio values of the strategy computed positions, in the given order
+// A strategy without winloss ratio matches the positions of any winloss ratio, the created ones have none
+func (s Strategy) Ratios(computed []positions.Details) []float64 {
+	ratios := make([]float64, 0)
+	for _, p := range computed {
+		if p.BuySignal == nil || p.Ratio == nil || p.Ratio.Value == 0 {
+			continue
+		}
+
+		if s.matches(strategyOf(p)) {
+			ratios = append(ratios, p.Ratio.Value)
+		}
+	}
+
+	return ratios
+}
+
+func (s Strategy) matches(other Strategy) bool {
+	if s.WinlossRatio == nil {
+		return s.BuySignalFullname == other.BuySignalFullname && s.PositionFullname == other.PositionFullname
+	}
+
+	return s.key() == other.key()
+}
+
+func strategyOf(p positions.Details) Strategy {
+	s := Strategy{PositionFullname: p.Fullname, WinlossRatio: p.WinlossRatio}
+	if p.BuySignal != nil {
+		s.BuySignalFullname = p.BuySignal.Fullname
+	}
+
+	return s
+}
+
+type WalkForwardRequest struct {
+	Pair     common.Pair     `json:"pair"`
+	Interval common.Interval `json:"interval"`
+	// InSampleDays, OutOfSampleDays and StepDays are the windows sizes, StepDays defaults to OutOfSampleDays
+	InSampleDays    int `json:"in_sample_days"`
+	OutOfSampleDays int `json:"out_of_sample_days"`
+	StepDays        int `json:"step_days,omitempty"`
+	// Anchored windows keep their in-sample start at the beginning of the history
+	Anchored bool     `json:"anchored,omitempty"`
+	Metrics  []Metric `json:"metrics"`
+	// MinTrades is the count of in-sample trades required for a strategy to be selected
+	MinTrades int        `json:"min_trades,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+type WalkForward struct {
+	ID        *ID                `json:"id,omitempty"`
+	Request   WalkForwardRequest `json:"request"`
+	CreatedAt time.Time          `json:"created_at"`
+	Windows   []Window           `json:"windows"`
+	// Summaries compare, per metric, the in-sample and out-of-sample performances of the selected strategies
+	Summaries []Summary `json:"summaries"`
+}
+
+// Window is the evaluation of one metric on one in-sample / out-of-sample split
+type Window struct {
+	Index            int       `json:"index"`
+	Metric           Metric    `json:"metric"`
+	InSampleStart    time.Time `json:"in_sample_start"`
+	InSampleEnd      time.Time `json:"in_sample_end"`
+	OutOfSampleStart time.Time `json:"out_of_sample_start"`
+	OutOfSampleEnd   time.Time `json:"out_of_sample_end"`
+	// Strategy is nil when no strategy had enough in-sample trades
+	Strategy    *Strategy             `json:"strategy,omitempty"`
+	InSample    positions.Performance `json:"in_sample"`
+	OutOfSample positions.Performance `json:"out_of_sample"`
+}
+
+type Summary struct {
+	Metric      Metric                `json:"metric"`
+	InSample    positions.Performance `json:"in_sample"`
+	OutOfSample positions.Performance `json:"out_of_sample"`
+	// Efficiency is the out-of-sample expectancy over the in-sample one, far below 1 shows overfitting
+	Efficiency float64 `json:"efficiency"`
+}
+
+// BuildWindows splits [first, last) in rolling windows, only full windows are returned
+// Windows have no metric, see Evaluate
+func BuildWindows(first time.Time, last time.Time, inSample time.Duration, outOfSample time.Duration, step time.Duration, anchored bool) ([]Window, error) {
+	if inSample <= 0 || outOfSample <= 0 || step <= 0 {
+		return nil, fmt.Errorf("windows sizes should be greater than 0")
+	}
+
+	windows := make([]Window, 0)
+	for start := first; !start.Add(inSample + outOfSample).After(last); start = start.Add(step) {
+		if len(windows) >= MaxWindows {
+			return nil, fmt.Errorf("too many windows: more than %d", MaxWindows)
+		}
+
+		isStart := start
+		if anchored {
+			isStart = first
+		}
+
+		isEnd := start.Add(inSample)
+		windows = append(windows, Window{
+			Index:            len(windows),
+			InSampleStart:    isStart,
+			InSampleEnd:      isEnd,
+			OutOfSampleStart: isEnd,
+			OutOfSampleEnd:   isEnd.Add(outOfSample),
+		})
+	}
+
+	return windows, nil
+}
+
+// Evaluate selects, for each window and metric, the best in-sample strategy and reports its out-of-sample performance
+// Positions should be computed ones, sorted by buy signal date
+// In-sample positions must be closed before the in-sample end so the selection never looks ahead
+func Evaluate(computed []positions.Details, windows []Window, metrics []Metric, minTrades int) []Window {
+	if minTrades <= 0 {
+		minTrades = 1
+	}
+
+	res := make([]Window, 0, len(windows)*len(metrics))
+	for _, w := range windows {
+		inSample := make(map[string][]positions.Details)
+		strategies := make(map[string]Strategy)
+		outOfSample := make(map[string][]positions.Details)
+
+		for _, p := range computed {
+			if p.BuySignal == nil || p.Ratio == nil || p.Ratio.Value == 0 {
+				continue
+			}
+
+			s := strategyOf(p)
+			buyDate := time.Time(p.BuySignal.Date)
+			switch {
+			case !buyDate.Before(w.InSampleStart) && time.Time(p.Ratio.Date).Before(w.InSampleEnd):
+				inSample[s.key()] = append(inSample[s.key()], p)
+				strategies[s.key()] = s
+			case !buyDate.Before(w.OutOfSampleStart) && buyDate.Before(w.OutOfSampleEnd):
+				outOfSample[s.key()] = append(outOfSample[s.key()], p)
+			}
+		}
+
+		keys := make([]string, 0, len(inSample))
+		for k := range inSample {
+			keys = append(keys, k)
+		}
+		// Sorted keys make the selection deterministic on ties
+		sort.Strings(keys)
+
+		for _, m := range metrics {
+			window := w
+			window.Metric = m
+
+			bestValue := math.Inf(-1)
+			bestKey := ""
+			for _, k := range keys {
+				perf := positions.ComputePerformance(inSample[k])
+				if perf.Trades < minTrades {
+					continue
+				}
+
+				if v := m.value(perf); v > bestValue {
+					bestValue = v
+					bestKey = k
+					window.InSample = perf
+				}
+			}
+
+			if bestKey != "" {
+				s := strategies[bestKey]
+				window.Strategy = &s
+				window.OutOfSample = positions.ComputePerformance(outOfSample[bestKey])
+			}
+
+			res = append(res, window)
+		}
+	}
+
+	return res
+}
+
+// Summarize aggregates the trades of each metric windows, in windows order
+func Summarize(windows []Window, metrics []Metric) []Summary {
+	res := make([]Summary, 0, len(metrics))
+	for _, m := range metrics {
+		var inSample, outOfSample []positions.Performance
+		for _, w := range windows {
+			if w.Metric != m || w.Strategy == nil {
+				continue
+			}
+
+			inSample = append(inSample, w.InSample)
+			outOfSample = append(outOfSample, w.OutOfSample)
+		}
+
+		summary := Summary{
+			Metric:      m,
+			InSample:    mergePerformances(inSample),
+			OutOfSample: mergePerformances(outOfSample),
+		}
+
+		if summary.InSample.Expectancy != 0 {
+			summary.Efficiency = summary.OutOfSample.Expectancy / summary.InSample.Expectancy
+		}
+
+		res = append(res, summary)
+	}
+
+	return res
+}
+
+// mergePerformances aggregates performances as if their trades were taken in sequence
+func mergePerformances(perfs []positions.Performance) positions.Performance {
+	res := positions.Performance{}
+	var sumWins, sumLosses, sumReturns float64
+	compounded := 1.0
+	for _, p := range perfs {
+		res.Trades += p.Trades
+		res.Open += p.Open
+		res.Wins += p.Wins
+		res.Losses += p.Losses
+		sumWins += p.AvgWin * float64(p.Wins)
+		sumLosses += p.AvgLoss * float64(p.Losses)
+		sumReturns += p.Expectancy * float64(p.Trades)
+		compounded *= 1 + p.TotalReturn
+	}
+
+	if res.Trades == 0 {
+		return res
+	}
+
+	res.WinRate = float64(res.Wins) / float64(res.Trades)
+	res.Expectancy = sumReturns / float64(res.Trades)
+	res.TotalReturn = compounded - 1
+
+	if res.Wins > 0 {
+		res.AvgWin = sumWins / float64(res.Wins)
+	}
+
+	if res.Losses > 0 {
+		res.AvgLoss = sumLosses / float64(res.Losses)
+	}
+
+	if sumLosses < 0 {
+		res.ProfitFactor = sumWins / math.Abs(sumLosses)
+	}
+
+	return res
+}
@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+)
+
+var day = 24 * time.Hour
+
+func TestBuildWindows(t *testing.T) {
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		last      time.Time
+		anchored  bool
+		wantCount int
+		wantLast  Window
+		wantErr   bool
+	}{
+		{
+			name:      "rolling windows",
+			last:      first.Add(10 * day),
+			wantCount: 3,
+			wantLast: Window{
+				Index:            2,
+				InSampleStart:    first.Add(4 * day),
+				InSampleEnd:      first.Add(8 * day),
+				OutOfSampleStart: first.Add(8 * day),
+				OutOfSampleEnd:   first.Add(10 * day),
+			},
+		},
+		{
+			name:      "anchored windows",
+			last:      first.Add(10 * day),
+			anchored:  true,
+			wantCount: 3,
+			wantLast: Window{
+				Index:            2,
+				InSampleStart:    first,
+				InSampleEnd:      first.Add(8 * day),
+				OutOfSampleStart: first.Add(8 * day),
+				OutOfSampleEnd:   first.Add(10 * day),
+			},
+		},
+		{
+			name:      "history too short",
+			last:      first.Add(5 * day),
+			wantCount: 0,
+		},
+		{
+			name:    "too many windows",
+			last:    first.Add(10000 * day),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildWindows(first, tt.last, 4*day, 2*day, 2*day, tt.anchored)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != tt.wantCount {
+				t.Fatalf("BuildWindows() returned %d windows, want %d", len(got), tt.wantCount)
+			}
+
+			if tt.wantCount > 0 && got[len(got)-1] != tt.wantLast {
+				t.Errorf("BuildWindows() last window = %+v, want %+v", got[len(got)-1], tt.wantLast)
+			}
+		})
+	}
+}
+
+func newComputedPosition(fullname positions.Fullname, buyDate time.Time, ratioDate time.Time, ratio float64) positions.Details {
+	return positions.Details{
+		Fullname:  fullname,
+		BuySignal: &buySignals.Details{Fullname: "morningStar", Date: buySignals.Date(buyDate)},
+		Ratio:     &positions.Ratio{Value: ratio, Date: candles.Date(ratioDate)},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	windows, err := BuildWindows(first, first.Add(6*day), 4*day, 2*day, 2*day, false)
+	if err != nil || len(windows) != 1 {
+		t.Fatalf("BuildWindows() = %v, %v", windows, err)
+	}
+
+	computed := []positions.Details{
+		// "steady" wins often in-sample, "lucky" has the best single trade
+		newComputedPosition("steady", first, first.Add(day), 1.02),
+		newComputedPosition("steady", first.Add(day), first.Add(2*day), 1.02),
+		newComputedPosition("lucky", first.Add(day), first.Add(2*day), 1.1),
+		newComputedPosition("lucky", first.Add(2*day), first.Add(3*day), 0.99),
+		// Closed after the in-sample end, it must not be used for the selection
+		newComputedPosition("late", first.Add(3*day), first.Add(5*day), 1.5),
+		// Out of sample
+		newComputedPosition("steady", first.Add(4*day), first.Add(5*day), 0.98),
+		newComputedPosition("lucky", first.Add(4*day), first.Add(5*day), 1.05),
+	}
+
+	got := Evaluate(computed, windows, []Metric{MetricExpectancy, MetricWinRate}, 2)
+	if len(got) != 2 {
+		t.Fatalf("Evaluate() returned %d windows, want 2", len(got))
+	}
+
+	tests := []struct {
+		metric       Metric
+		wantFullname positions.Fullname
+		wantOOSWins  int
+	}{
+		{metric: MetricExpectancy, wantFullname: "lucky", wantOOSWins: 1},
+		{metric: MetricWinRate, wantFullname: "steady", wantOOSWins: 0},
+	}
+
+	for i, tt := range tests {
+		w := got[i]
+		if w.Metric != tt.metric || w.Strategy == nil || w.Strategy.PositionFullname != tt.wantFullname {
+			t.Errorf("Evaluate()[%d] = %+v, want metric %s and strategy %s", i, w, tt.metric, tt.wantFullname)
+			continue
+		}
+
+		if w.InSample.Trades != 2 || w.OutOfSample.Trades != 1 || w.OutOfSample.Wins != tt.wantOOSWins {
+			t.Errorf("Evaluate()[%d] in-sample %+v, out-of-sample %+v", i, w.InSample, w.OutOfSample)
+		}
+	}
+
+	summaries := Summarize(got, []Metric{MetricExpectancy})
+	if len(summaries) != 1 || summaries[0].OutOfSample.Trades != 1 || summaries[0].Efficiency <= 0 {
+		t.Errorf("Summarize() = %+v", summaries)
+	}
+}
+
+func TestStrategyRatios(t *testing.T) {
+	one, half := positions.WinLossRatio(1), positions.WinLossRatio(0.5)
+	bs := &buySignals.Details{Fullname: "morningStar"}
+	computed := []positions.Details{
+		{Fullname: "percentage_tp2", WinlossRatio: &one, BuySignal: bs, Ratio: &positions.Ratio{Value: 1.02}},
+		{Fullname: "percentage_tp2", WinlossRatio: &half, BuySignal: bs, Ratio: &positions.Ratio{Value: 0.99}},
+		{Fullname: "percentage_tp2", BuySignal: bs, Ratio: &positions.Ratio{Value: 1.01}},
+		{Fullname: "percentage_tp5", WinlossRatio: &one, BuySignal: bs, Ratio: &positions.Ratio{Value: 1.05}},
+		{Fullname: "percentage_tp2", WinlossRatio: &one, BuySignal: bs},
+	}
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []float64
+	}{
+		{name: "winloss ratio", strategy: Strategy{BuySignalFullname: "morningStar", PositionFullname: "percentage_tp2", WinlossRatio: &half}, want: []float64{0.99}},
+		{name: "any winloss ratio", strategy: Strategy{BuySignalFullname: "morningStar", PositionFullname: "percentage_tp2"}, want: []float64{1.02, 0.99, 1.01}},
+		{name: "other buy signal strategy", strategy: Strategy{BuySignalFullname: "rsiDivergence", PositionFullname: "percentage_tp2"}, want: []float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.strategy.Ratios(computed); !slices.Equal(got, tt.want) {
+				t.Errorf("Ratios() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
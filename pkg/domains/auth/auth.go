@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+// HeaderAPIKey is the request header holding the API key
+const HeaderAPIKey = "X-API-Key"
+
+const LoggerKeyAPIKeyID = "api_key_id"
+
+// keyPrefix identifies Bifrost keys, eg. in a secret scanner
+const keyPrefix = "bfk"
+
+type ID uuid.UUID
+
+func (i ID) String() string {
+	return uuid.UUID(i).String()
+}
+
+func (i ID) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%s"`, uuid.UUID(i).String())), nil
+}
+
+func (i *ID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*i = ID(parsed)
+	return nil
+}
+
+type Scope string
+
+const (
+	// ScopeRead allows every request that does not write, GET ones and queries sent as POST
+	ScopeRead             Scope = "read"
+	ScopeCandlesWrite     Scope = "candles:write"
+	ScopeSignalsWrite     Scope = "signals:write"
+	ScopePositionsWrite   Scope = "positions:write"
+	ScopePositionsCompute Scope = "positions:compute"
+	ScopeAnalyticsWrite   Scope = "analytics:write"
+	// ScopeAdmin allows every request, including the API keys management of its workspace
+	ScopeAdmin Scope = "admin"
+	// ScopeGlobalAdmin allows every request and the API keys management of all the workspaces
+	ScopeGlobalAdmin Scope = "global:admin"
+)
+
+var AllAvailableScopes = []Scope{
+	ScopeRead,
+	ScopeCandlesWrite,
+	ScopeSignalsWrite,
+	ScopePositionsWrite,
+	ScopePositionsCompute,
+	ScopeAnalyticsWrite,
+	ScopeAdmin,
+	ScopeGlobalAdmin,
+}
+
+func ParseScopes(args []string) ([]Scope, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	scopes := make([]Scope, 0, len(args))
+	errors := []string{}
+	for _, arg := range args {
+		found := false
+		for _, s := range AllAvailableScopes {
+			if Scope(arg) == s {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errors = append(errors, arg)
+			continue
+		}
+
+		scopes = append(scopes, Scope(arg))
+	}
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("scopes not allowed: %s", errors)
+	}
+
+	return scopes, nil
+}
+
+type APIKey struct {
+	ID    *ID    `json:"id,omitempty"`
+	Owner string `json:"owner"`
+	// Workspace is the tenant of the requests authenticated by the key
+	Workspace workspaces.Workspace `json:"workspace"`
+	// Prefix is the public part of the key, it identifies the key without revealing it
+	Prefix    string     `json:"prefix"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Hash is the SHA-256 of the key, the key itself is never stored
+	Hash []byte `json:"-"`
+}
+
+// Allows returns whether the key grants the scope, admin and global admin grant all of them
+func (k APIKey) Allows(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin || s == ScopeGlobalAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsGlobalAdmin returns whether the key manages the API keys of all the workspaces
+func (k APIKey) IsGlobalAdmin() bool {
+	for _, s := range k.Scopes {
+		if s == ScopeGlobalAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// GenerateKey returns a new random key, formatted as bfk_<prefix>_<secret>, and its prefix
+func GenerateKey() (key string, prefix string, err error) {
+	random := make([]byte, 4+32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", fmt.Errorf("unable to generate a random key: %w", err)
+	}
+
+	prefix = hex.EncodeToString(random[:4])
+	return fmt.Sprintf("%s_%s_%s", keyPrefix, prefix, hex.EncodeToString(random[4:])), prefix, nil
+}
+
+// ParseKey returns the prefix of the key
+func ParseKey(key string) (string, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("malformed api key")
+	}
+
+	return parts[1], nil
+}
+
+// HashKey returns the SHA-256 of the key, keys are random enough to not need a slow hash
+func HashKey(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+	return hash[:]
+}
+
+// Matches compares the key to the stored hash in constant time
+func (k APIKey) Matches(key string) bool {
+	return subtle.ConstantTimeCompare(k.Hash, HashKey(key)) == 1
+}
+
+type ctxKey struct{}
+
+var apiKeyCtxKey = &ctxKey{}
+
+// SetAPIKeyToContext stores the authenticated key, handlers and services can read it back
+func SetAPIKeyToContext(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyCtxKey, key)
+}
+
+// GetAPIKeyFromContext returns nil when the request is not authenticated, eg. if the authentication is disabled
+func GetAPIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyCtxKey).(*APIKey)
+	return key
+}
+
+// ManagesAllWorkspaces returns whether the request key is a global admin one
+// The API keys of the other workspaces are hidden from the requests without one, including the unauthenticated ones
+func ManagesAllWorkspaces(ctx context.Context) bool {
+	key := GetAPIKeyFromContext(ctx)
+	return key != nil && key.IsGlobalAdmin()
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, prefix, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	if !strings.HasPrefix(key, "bfk_"+prefix+"_") {
+		t.Errorf("GenerateKey() key = %s, want it to start with bfk_%s_", key, prefix)
+	}
+
+	parsed, err := ParseKey(key)
+	if err != nil {
+		t.Fatalf("ParseKey() error = %v", err)
+	}
+
+	if parsed != prefix {
+		t.Errorf("ParseKey() = %s, want %s", parsed, prefix)
+	}
+
+	other, _, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	if other == key {
+		t.Errorf("GenerateKey() returned the same key twice")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "valid", key: "bfk_0a1b2c3d_secret"},
+		{name: "empty", key: "", wantErr: true},
+		{name: "unknown prefix", key: "abc_0a1b2c3d_secret", wantErr: true},
+		{name: "missing secret", key: "bfk_0a1b2c3d_", wantErr: true},
+		{name: "too many parts", key: "bfk_0a1b2c3d_secret_more", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMatches(t *testing.T) {
+	key, _, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	apiKey := APIKey{Hash: HashKey(key)}
+	if !apiKey.Matches(key) {
+		t.Errorf("Matches() = false, want true for the generated key")
+	}
+
+	if apiKey.Matches(key + "0") {
+		t.Errorf("Matches() = true, want false for another key")
+	}
+}
+
+func TestAPIKeyAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []Scope
+		scope  Scope
+		want   bool
+	}{
+		{name: "granted scope", scopes: []Scope{ScopeRead, ScopeCandlesWrite}, scope: ScopeCandlesWrite, want: true},
+		{name: "missing scope", scopes: []Scope{ScopeRead}, scope: ScopeSignalsWrite, want: false},
+		{name: "admin grants all", scopes: []Scope{ScopeAdmin}, scope: ScopePositionsCompute, want: true},
+		{name: "global admin grants all", scopes: []Scope{ScopeGlobalAdmin}, scope: ScopeAdmin, want: true},
+		{name: "no scope", scopes: nil, scope: ScopeRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (APIKey{Scopes: tt.scopes}).Allows(tt.scope); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes([]string{"read", "positions:compute"})
+	if err != nil {
+		t.Fatalf("ParseScopes() error = %v", err)
+	}
+
+	if len(scopes) != 2 || scopes[0] != ScopeRead || scopes[1] != ScopePositionsCompute {
+		t.Errorf("ParseScopes() = %v", scopes)
+	}
+
+	if _, err := ParseScopes([]string{"read", "candles:delete"}); err == nil {
+		t.Errorf("ParseScopes() error = nil, want an error for an unknown scope")
+	}
+
+	if _, err := ParseScopes(nil); err == nil {
+		t.Errorf("ParseScopes() error = nil, want an error without scope")
+	}
+}
+
+func TestAPIKeyContext(t *testing.T) {
+	if got := GetAPIKeyFromContext(context.Background()); got != nil {
+		t.Errorf("GetAPIKeyFromContext() = %v, want nil", got)
+	}
+
+	apiKey := &APIKey{Owner: "bot"}
+	ctx := SetAPIKeyToContext(context.Background(), apiKey)
+	if got := GetAPIKeyFromContext(ctx); got != apiKey {
+		t.Errorf("GetAPIKeyFromContext() = %v, want %v", got, apiKey)
+	}
+}
+
+func TestManagesAllWorkspaces(t *testing.T) {
+	if ManagesAllWorkspaces(context.Background()) {
+		t.Errorf("ManagesAllWorkspaces() = true, want false without key")
+	}
+
+	ctx := SetAPIKeyToContext(context.Background(), &APIKey{Scopes: []Scope{ScopeAdmin}})
+	if ManagesAllWorkspaces(ctx) {
+		t.Errorf("ManagesAllWorkspaces() = true, want false for an admin key")
+	}
+
+	ctx = SetAPIKeyToContext(context.Background(), &APIKey{Scopes: []Scope{ScopeRead, ScopeGlobalAdmin}})
+	if !ManagesAllWorkspaces(ctx) {
+		t.Errorf("ManagesAllWorkspaces() = false, want true for a global admin key")
+	}
+}
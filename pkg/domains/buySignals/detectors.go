@@ -0,0 +1,153 @@
+package buysignals
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// Detector scans a chronologically ordered candle list and returns the buy signals it finds
+// Detectors are pure: the same candles and params always produce the same signals
+type Detector interface {
+	Name() Name
+	// Fullname is the detector name with its params, it is used as the buy signals fullname
+	Fullname() Fullname
+	// Warmup is the count of candles before a range the detector needs to find the same signals as over the whole history
+	Warmup() int
+	Detect(candles []candles.Candle) []Details
+}
+
+// NewDetector builds the detector of the given strategy name
+// Params not provided in the metadata keep their default values
+func NewDetector(name Name, params Metadata) (Detector, error) {
+	switch name {
+	case MorningStarName:
+		p := DefaultMorningStarParams
+		if err := common.MergeParams(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid detector params: %w", err)
+		}
+
+		return NewMorningStarDetector(p)
+	case RSIDivergenceName:
+		p := DefaultRSIDivergenceParams
+		if err := common.MergeParams(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid detector params: %w", err)
+		}
+
+		return NewRSIDivergenceDetector(p)
+	}
+
+	return nil, fmt.Errorf("no detector available for signal strategy: %q", name)
+}
+
+// DetectSince detects over the whole candle list and keeps the signals triggered from the start date
+// The candles before the start date only warm the detector up, see Detector.Warmup
+func DetectSince(d Detector, candleList []candles.Candle, start time.Time) []Details {
+	first := len(candleList)
+	for i, c := range candleList {
+		if !time.Time(c.Date).Before(start) {
+			first = i
+			break
+		}
+	}
+
+	if first == len(candleList) {
+		return []Details{}
+	}
+
+	// A signal triggered on the first candle of the range is bought at the next interval
+	from := common.AddOneInterval(time.Time(candleList[first].Date), candleList[first].Interval)
+	if from == nil {
+		return []Details{}
+	}
+
+	res := make([]Details, 0)
+	for _, signal := range d.Detect(candleList) {
+		if !time.Time(signal.Date).Before(*from) {
+			res = append(res, signal)
+		}
+	}
+
+	return res
+}
+
+// buildBusinessID ensures a signal detected twice on the same candle gets the same business ID
+func buildBusinessID(name Name, pair common.Pair, interval common.Interval, date time.Time) BusinessID {
+	return BusinessID(fmt.Sprintf("%s-%s-%s-%d", name, pair, interval, date.Unix()))
+}
+
+// newDetectedSignal builds a buy signal triggered at the close of the given candle
+// The buy date is the beginning of the next interval, the price is the candle close
+func newDetectedSignal(d Detector, trigger candles.Candle, metadata Metadata) (Details, bool) {
+	buyDate := common.AddOneInterval(time.Time(trigger.Date), trigger.Interval)
+	if buyDate == nil {
+		return Details{}, false
+	}
+
+	return Details{
+		Name:       d.Name(),
+		BusinessID: buildBusinessID(d.Name(), trigger.Pair, trigger.Interval, time.Time(trigger.Date)),
+		Fullname:   d.Fullname(),
+		Pair:       trigger.Pair,
+		Interval:   trigger.Interval,
+		Date:       Date(*buyDate),
+		Price:      trigger.Close,
+		Metadata:   metadata,
+	}, true
+}
+
+// ComputeRSI computes the Wilder RSI of the given closes
+// Values are NaN until enough closes are available
+func ComputeRSI(closes []float64, period int) []float64 {
+	rsi := make([]float64, len(closes))
+	for i := range rsi {
+		rsi[i] = math.NaN()
+	}
+
+	if period <= 0 || len(closes) <= period {
+		return rsi
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		gain, loss := splitChange(closes[i] - closes[i-1])
+		avgGain += gain
+		avgLoss += loss
+	}
+
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	rsi[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(closes); i++ {
+		gain, loss := splitChange(closes[i] - closes[i-1])
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		rsi[i] = rsiValue(avgGain, avgLoss)
+	}
+
+	return rsi
+}
+
+func splitChange(change float64) (gain float64, loss float64) {
+	if change > 0 {
+		return change, 0
+	}
+
+	return 0, -change
+}
+
+func rsiValue(avgGain float64, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+
+		return 100
+	}
+
+	return 100 - 100/(1+avgGain/avgLoss)
+}
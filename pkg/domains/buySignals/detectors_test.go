@@ -0,0 +1,275 @@
+package buysignals
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+var testStartDate = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestCandles builds 1h candles from [open, close, high, low] rows
+func newTestCandles(rows [][4]float64) []candles.Candle {
+	res := make([]candles.Candle, len(rows))
+	for i, r := range rows {
+		res[i] = candles.Candle{
+			Date:     candles.Date(testStartDate.Add(time.Duration(i) * time.Hour)),
+			Pair:     common.SOLUSDC,
+			Interval: common.H1,
+			Open:     r[0],
+			Close:    r[1],
+			High:     r[2],
+			Low:      r[3],
+		}
+	}
+
+	return res
+}
+
+func TestMorningStarDetector_Detect(t *testing.T) {
+	downtrend := [][4]float64{
+		{121, 120, 122, 119},
+		{120, 118, 121, 117},
+		{118, 116, 119, 115},
+		{115, 105, 116, 104},
+		{103, 102, 104, 101},
+	}
+
+	tests := []struct {
+		name      string
+		rows      [][4]float64
+		wantCount int
+		wantDate  time.Time
+		wantPrice float64
+	}{
+		{
+			name:      "morning star after a downtrend",
+			rows:      append(append([][4]float64{}, downtrend...), [4]float64{103, 112, 113, 102}),
+			wantCount: 1,
+			wantDate:  testStartDate.Add(6 * time.Hour),
+			wantPrice: 112,
+		},
+		{
+			name:      "third candle does not recover enough of the first body",
+			rows:      append(append([][4]float64{}, downtrend...), [4]float64{103, 108, 109, 102}),
+			wantCount: 0,
+		},
+		{
+			name: "no downtrend before the pattern",
+			rows: [][4]float64{
+				{100, 101, 102, 99},
+				{101, 103, 104, 100},
+				{103, 114, 115, 102},
+				{115, 105, 116, 104},
+				{103, 102, 104, 101},
+				{103, 112, 113, 102},
+			},
+			wantCount: 0,
+		},
+		{
+			name:      "not enough candles",
+			rows:      downtrend[:3],
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewMorningStarDetector(DefaultMorningStarParams)
+			if err != nil {
+				t.Fatalf("NewMorningStarDetector() error = %v", err)
+			}
+
+			got := d.Detect(newTestCandles(tt.rows))
+			if len(got) != tt.wantCount {
+				t.Fatalf("Detect() returned %d signals, want %d", len(got), tt.wantCount)
+			}
+
+			if tt.wantCount == 0 {
+				return
+			}
+
+			if !time.Time(got[0].Date).Equal(tt.wantDate) {
+				t.Errorf("Detect() date = %v, want %v", got[0].Date, tt.wantDate)
+			}
+
+			if got[0].Price != tt.wantPrice {
+				t.Errorf("Detect() price = %v, want %v", got[0].Price, tt.wantPrice)
+			}
+
+			if got[0].Fullname != "morningStar_b0.6_s0.3_p0.5_t3" {
+				t.Errorf("Detect() fullname = %v", got[0].Fullname)
+			}
+
+			again := d.Detect(newTestCandles(tt.rows))
+			if again[0].BusinessID != got[0].BusinessID {
+				t.Errorf("Detect() business ID is not deterministic: %v != %v", again[0].BusinessID, got[0].BusinessID)
+			}
+		})
+	}
+}
+
+func TestRSIDivergenceDetector_Detect(t *testing.T) {
+	params := RSIDivergenceParams{
+		Period:      2,
+		PivotWindow: 1,
+		MinDistance: 3,
+		MaxDistance: 10,
+		Oversold:    30,
+	}
+
+	tests := []struct {
+		name      string
+		lows      []float64
+		rsi       []float64
+		wantCount int
+		wantDate  time.Time
+	}{
+		{
+			name:      "lower low with higher RSI low",
+			lows:      []float64{10, 8, 10, 11, 7, 9},
+			rsi:       []float64{40, 25, 45, 50, 35, 45},
+			wantCount: 1,
+			wantDate:  testStartDate.Add(6 * time.Hour),
+		},
+		{
+			name:      "lower low with lower RSI low",
+			lows:      []float64{10, 8, 10, 11, 7, 9},
+			rsi:       []float64{40, 25, 45, 50, 20, 45},
+			wantCount: 0,
+		},
+		{
+			name:      "first pivot is not oversold",
+			lows:      []float64{10, 8, 10, 11, 7, 9},
+			rsi:       []float64{40, 31, 45, 50, 35, 45},
+			wantCount: 0,
+		},
+		{
+			name:      "pivots are too close",
+			lows:      []float64{10, 8, 10, 7, 9},
+			rsi:       []float64{40, 25, 45, 35, 45},
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := make([][4]float64, len(tt.lows))
+			for i, low := range tt.lows {
+				rows[i] = [4]float64{low + 1, low + 1, low + 2, low}
+			}
+
+			candleList := newTestCandles(rows)
+			for i := range candleList {
+				rsi := candles.RSI{candles.RSIPeriod(params.Period): candles.RSIValue(tt.rsi[i])}
+				candleList[i].RSI = &rsi
+			}
+
+			d, err := NewRSIDivergenceDetector(params)
+			if err != nil {
+				t.Fatalf("NewRSIDivergenceDetector() error = %v", err)
+			}
+
+			got := d.Detect(candleList)
+			if len(got) != tt.wantCount {
+				t.Fatalf("Detect() returned %d signals, want %d", len(got), tt.wantCount)
+			}
+
+			if tt.wantCount > 0 && !time.Time(got[0].Date).Equal(tt.wantDate) {
+				t.Errorf("Detect() date = %v, want %v", got[0].Date, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestComputeRSI(t *testing.T) {
+	got := ComputeRSI([]float64{1, 2, 3, 2, 3}, 2)
+	want := []float64{math.NaN(), math.NaN(), 100, 50, 75}
+
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Errorf("ComputeRSI()[%d] = %v, want NaN", i, got[i])
+			}
+			continue
+		}
+
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("ComputeRSI()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNewDetector(t *testing.T) {
+	d, err := NewDetector(RSIDivergenceName, Metadata{"period": 7})
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+
+	if d.Fullname() != "rsiDivergence_p7_w3_d5-60_o30" {
+		t.Errorf("NewDetector() fullname = %v", d.Fullname())
+	}
+
+	if _, err := NewDetector(XName, nil); err == nil {
+		t.Errorf("NewDetector() expected an error for a strategy without detector")
+	}
+}
+
+func TestDetectSince(t *testing.T) {
+	d, err := NewRSIDivergenceDetector(RSIDivergenceParams{
+		Period:      3,
+		PivotWindow: 1,
+		MinDistance: 3,
+		MaxDistance: 10,
+		Oversold:    40,
+	})
+	if err != nil {
+		t.Fatalf("NewRSIDivergenceDetector() error = %v", err)
+	}
+
+	// A falling price oscillating faster than it falls makes lower lows with higher RSI lows
+	rows := make([][4]float64, 300)
+	for i := range rows {
+		price := 100 - 0.05*float64(i) + 3*math.Sin(float64(i)/2) + math.Sin(float64(i)*1.7)
+		rows[i] = [4]float64{price, price, price + 0.5, price - 0.5}
+	}
+
+	candleList := newTestCandles(rows)
+	// The range starts between the two pivots of the last divergence
+	start := 286
+	startDate := time.Time(candleList[start].Date)
+
+	want := make([]Details, 0)
+	for _, signal := range d.Detect(candleList) {
+		if time.Time(signal.Date).After(startDate) {
+			want = append(want, signal)
+		}
+	}
+
+	if len(want) == 0 {
+		t.Fatalf("Detect() found no signal after the start date, the test candles should be reworked")
+	}
+
+	got := DetectSince(d, candleList[start-d.Warmup():], startDate)
+	if len(got) != len(want) {
+		t.Fatalf("DetectSince() returned %d signals, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if got[i].BusinessID != want[i].BusinessID {
+			t.Errorf("DetectSince()[%d] business ID = %v, want %v", i, got[i].BusinessID, want[i].BusinessID)
+		}
+
+		// The RSI before the warmup only leaves a negligible residue
+		for key, value := range want[i].Metadata {
+			wantRSI, isRSI := value.(float64)
+			gotRSI, _ := got[i].Metadata[key].(float64)
+			if isRSI && math.Abs(gotRSI-wantRSI) > 1e-6 || !isRSI && got[i].Metadata[key] != value {
+				t.Errorf("DetectSince()[%d] metadata %s = %v, want %v", i, key, got[i].Metadata[key], value)
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package buysignals
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// MorningStarParams configure the morning star candlestick pattern detection
+type MorningStarParams struct {
+	// MinFirstBodyRatio is the minimum body/range ratio of the first bearish candle
+	MinFirstBodyRatio float64 `json:"min_first_body_ratio"`
+	// MaxStarBodyRatio is the maximum star body size, relative to the first candle body
+	MaxStarBodyRatio float64 `json:"max_star_body_ratio"`
+	// MinPenetration is the minimum part of the first candle body recovered by the third candle close
+	MinPenetration float64 `json:"min_penetration"`
+	// TrendLookback is the count of candles before the pattern used to ensure a downtrend, 0 disables it
+	TrendLookback int `json:"trend_lookback"`
+}
+
+var DefaultMorningStarParams = MorningStarParams{
+	MinFirstBodyRatio: 0.6,
+	MaxStarBodyRatio:  0.3,
+	MinPenetration:    0.5,
+	TrendLookback:     3,
+}
+
+type morningStarDetector struct {
+	params MorningStarParams
+}
+
+func NewMorningStarDetector(params MorningStarParams) (Detector, error) {
+	if params.MinFirstBodyRatio <= 0 || params.MinFirstBodyRatio > 1 {
+		return nil, fmt.Errorf("min_first_body_ratio should be in ]0, 1]: %v", params.MinFirstBodyRatio)
+	}
+
+	if params.MaxStarBodyRatio <= 0 || params.MaxStarBodyRatio > 1 {
+		return nil, fmt.Errorf("max_star_body_ratio should be in ]0, 1]: %v", params.MaxStarBodyRatio)
+	}
+
+	if params.MinPenetration <= 0 || params.MinPenetration > 1 {
+		return nil, fmt.Errorf("min_penetration should be in ]0, 1]: %v", params.MinPenetration)
+	}
+
+	if params.TrendLookback < 0 {
+		return nil, fmt.Errorf("trend_lookback should be positive: %v", params.TrendLookback)
+	}
+
+	return &morningStarDetector{params: params}, nil
+}
+
+func (d *morningStarDetector) Name() Name {
+	return MorningStarName
+}
+
+func (d *morningStarDetector) Fullname() Fullname {
+	return Fullname(common.BuildFullname(string(MorningStarName),
+		common.FormatParam("b", d.params.MinFirstBodyRatio),
+		common.FormatParam("s", d.params.MaxStarBodyRatio),
+		common.FormatParam("p", d.params.MinPenetration),
+		"t"+strconv.Itoa(d.params.TrendLookback),
+	))
+}
+
+// Warmup covers the two first candles of the pattern and the trend lookback
+func (d *morningStarDetector) Warmup() int {
+	return d.params.TrendLookback + 2
+}
+
+// Detect looks for a bearish candle, followed by a small bodied star
+// and a bullish candle closing deep into the first candle body
+func (d *morningStarDetector) Detect(candleList []candles.Candle) []Details {
+	res := make([]Details, 0)
+
+	for i := d.params.TrendLookback + 2; i < len(candleList); i++ {
+		first, star, third := candleList[i-2], candleList[i-1], candleList[i]
+
+		firstBody := first.Open - first.Close
+		firstRange := first.High - first.Low
+		if firstBody <= 0 || firstRange <= 0 || firstBody/firstRange < d.params.MinFirstBodyRatio {
+			continue
+		}
+
+		starBody := math.Abs(star.Close - star.Open)
+		if starBody > firstBody*d.params.MaxStarBodyRatio {
+			continue
+		}
+
+		// The star should open and close below the first candle body
+		if math.Max(star.Open, star.Close) >= first.Close {
+			continue
+		}
+
+		if third.Close <= third.Open || third.Close < first.Close+firstBody*d.params.MinPenetration {
+			continue
+		}
+
+		if d.params.TrendLookback > 0 && candleList[i-2-d.params.TrendLookback].Close <= first.Open {
+			continue
+		}
+
+		metadata := Metadata{}
+		_ = metadata.SetMetadata(d.params)
+		metadata["first_candle_date"] = first.Date.String()
+		metadata["star_candle_date"] = star.Date.String()
+		metadata["confirmation_candle_date"] = third.Date.String()
+
+		if signal, ok := newDetectedSignal(d, third, metadata); ok {
+			res = append(res, signal)
+		}
+	}
+
+	return res
+}
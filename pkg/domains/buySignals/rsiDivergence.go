@@ -0,0 +1,167 @@
+package buysignals
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// RSIDivergenceParams configure the bullish RSI divergence detection
+type RSIDivergenceParams struct {
+	// Period is the RSI period
+	Period int `json:"period"`
+	// PivotWindow is the count of candles on each side of a low to consider it as a pivot
+	PivotWindow int `json:"pivot_window"`
+	// MinDistance and MaxDistance bound the count of candles between the two compared pivots
+	MinDistance int `json:"min_distance"`
+	MaxDistance int `json:"max_distance"`
+	// Oversold is the maximum RSI value of the first pivot
+	Oversold float64 `json:"oversold"`
+}
+
+var DefaultRSIDivergenceParams = RSIDivergenceParams{
+	Period:      14,
+	PivotWindow: 3,
+	MinDistance: 5,
+	MaxDistance: 60,
+	Oversold:    30,
+}
+
+// rsiWarmupFactor is the count of periods after which the Wilder RSI no longer depends on the first close, up to ~1e-9
+const rsiWarmupFactor = 20
+
+type rsiDivergenceDetector struct {
+	params RSIDivergenceParams
+}
+
+func NewRSIDivergenceDetector(params RSIDivergenceParams) (Detector, error) {
+	if params.Period <= 1 {
+		return nil, fmt.Errorf("period should be greater than 1: %v", params.Period)
+	}
+
+	if params.PivotWindow <= 0 {
+		return nil, fmt.Errorf("pivot_window should be greater than 0: %v", params.PivotWindow)
+	}
+
+	if params.MinDistance <= 0 || params.MaxDistance < params.MinDistance {
+		return nil, fmt.Errorf("distance should satisfy 0 < min_distance <= max_distance: %v, %v", params.MinDistance, params.MaxDistance)
+	}
+
+	if params.Oversold <= 0 || params.Oversold >= 100 {
+		return nil, fmt.Errorf("oversold should be in ]0, 100[: %v", params.Oversold)
+	}
+
+	return &rsiDivergenceDetector{params: params}, nil
+}
+
+func (d *rsiDivergenceDetector) Name() Name {
+	return RSIDivergenceName
+}
+
+func (d *rsiDivergenceDetector) Fullname() Fullname {
+	return Fullname(common.BuildFullname(string(RSIDivergenceName),
+		"p"+strconv.Itoa(d.params.Period),
+		"w"+strconv.Itoa(d.params.PivotWindow),
+		"d"+strconv.Itoa(d.params.MinDistance)+"-"+strconv.Itoa(d.params.MaxDistance),
+		common.FormatParam("o", d.params.Oversold),
+	))
+}
+
+// Warmup lets the Wilder RSI converge over rsiWarmupFactor periods, and the first pivot of a divergence lie before the range
+func (d *rsiDivergenceDetector) Warmup() int {
+	return d.params.Period*rsiWarmupFactor + d.params.MaxDistance + 2*d.params.PivotWindow
+}
+
+// Detect looks for two pivot lows where the price makes a lower low while the RSI makes a higher low
+// The signal is triggered when the second pivot is confirmed, PivotWindow candles later
+func (d *rsiDivergenceDetector) Detect(candleList []candles.Candle) []Details {
+	res := make([]Details, 0)
+	rsi := d.rsi(candleList)
+	w := d.params.PivotWindow
+
+	pivots := make([]int, 0)
+	for i := w; i < len(candleList)-w; i++ {
+		if math.IsNaN(rsi[i]) || !isPivotLow(candleList, i, w) {
+			continue
+		}
+
+		for j := len(pivots) - 1; j >= 0; j-- {
+			prev := pivots[j]
+			distance := i - prev
+			if distance > d.params.MaxDistance {
+				break
+			}
+
+			if distance < d.params.MinDistance {
+				continue
+			}
+
+			if candleList[i].Low >= candleList[prev].Low || rsi[i] <= rsi[prev] || rsi[prev] > d.params.Oversold {
+				continue
+			}
+
+			trigger := candleList[i+w]
+			metadata := Metadata{}
+			_ = metadata.SetMetadata(d.params)
+			metadata["first_pivot_date"] = candleList[prev].Date.String()
+			metadata["first_pivot_rsi"] = rsi[prev]
+			metadata["second_pivot_date"] = candleList[i].Date.String()
+			metadata["second_pivot_rsi"] = rsi[i]
+
+			if signal, ok := newDetectedSignal(d, trigger, metadata); ok {
+				res = append(res, signal)
+			}
+
+			break
+		}
+
+		pivots = append(pivots, i)
+	}
+
+	return res
+}
+
+// rsi uses the stored RSI when every candle has it for the detector period, else it is computed from closes
+func (d *rsiDivergenceDetector) rsi(candleList []candles.Candle) []float64 {
+	stored := make([]float64, len(candleList))
+	for i, c := range candleList {
+		if c.RSI == nil {
+			return computeRSIFromCandles(candleList, d.params.Period)
+		}
+
+		value, ok := (*c.RSI)[candles.RSIPeriod(d.params.Period)]
+		if !ok {
+			return computeRSIFromCandles(candleList, d.params.Period)
+		}
+
+		stored[i] = float64(value)
+	}
+
+	return stored
+}
+
+func computeRSIFromCandles(candleList []candles.Candle, period int) []float64 {
+	closes := make([]float64, len(candleList))
+	for i, c := range candleList {
+		closes[i] = c.Close
+	}
+
+	return ComputeRSI(closes, period)
+}
+
+func isPivotLow(candleList []candles.Candle, i int, window int) bool {
+	for j := i - window; j <= i+window; j++ {
+		if j == i {
+			continue
+		}
+
+		if candleList[j].Low < candleList[i].Low {
+			return false
+		}
+	}
+
+	return true
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
 	"github.com/sopial42/bifrost/pkg/domains/common"
 )
 
@@ -73,8 +75,35 @@ type Candle struct {
 	High     float64         `json:"high"`
 	Low      float64         `json:"low"`
 	RSI      *RSI            `json:"rsi,omitempty"`
+	// Issues are the integrity issues of a candle stored by the flag validation, see Check
+	Issues []Issue `json:"issues,omitempty"`
 }
 
 type RSI map[RSIPeriod]RSIValue
 type RSIPeriod int64
 type RSIValue float64
+
+// Validate adds the violations of the candle to create at the pointer
+func (c Candle) Validate(violations *appErrors.FieldViolations, pointer string) {
+	if c.ID != nil {
+		violations.Add(pointer+"/id", appErrors.ViolationReadOnly, "id must not be provided")
+	}
+
+	if len(c.Issues) > 0 {
+		violations.Add(pointer+"/issues", appErrors.ViolationReadOnly, "issues must not be provided")
+	}
+
+	if time.Time(c.Date).IsZero() {
+		violations.Add(pointer+"/date", appErrors.ViolationRequired, "date is required")
+	}
+
+	if c.Pair == "" {
+		violations.Add(pointer+"/pair", appErrors.ViolationRequired, "pair is required")
+	}
+
+	if c.Interval == "" {
+		violations.Add(pointer+"/interval", appErrors.ViolationRequired, "interval is required")
+	} else if !c.Interval.IsValid() {
+		violations.Add(pointer+"/interval", appErrors.ViolationInvalidValue, fmt.Sprintf("interval %q is not allowed", c.Interval))
+	}
+}
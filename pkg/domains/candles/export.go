@@ -0,0 +1,45 @@
+package candles
+
+import (
+	"time"
+
+	"github.com/sopial42/bifrost/pkg/domains/export"
+)
+
+// ExportColumns are the columns of a candles export, the ones of a CSV stream
+var ExportColumns = CSVColumns
+
+// ExportRow is the flat record of an exported candle, the RSI is not exported
+type ExportRow struct {
+	Date     time.Time `json:"date" parquet:"date,timestamp(millisecond)"`
+	Pair     string    `json:"pair" parquet:"pair,dict"`
+	Interval string    `json:"interval" parquet:"interval,dict"`
+	Open     float64   `json:"open" parquet:"open"`
+	High     float64   `json:"high" parquet:"high"`
+	Low      float64   `json:"low" parquet:"low"`
+	Close    float64   `json:"close" parquet:"close"`
+}
+
+func (c Candle) ExportRow() ExportRow {
+	return ExportRow{
+		Date:     time.Time(c.Date).UTC(),
+		Pair:     string(c.Pair),
+		Interval: string(c.Interval),
+		Open:     c.Open,
+		High:     c.High,
+		Low:      c.Low,
+		Close:    c.Close,
+	}
+}
+
+func (r ExportRow) Record() []string {
+	return []string{
+		r.Date.Format(time.RFC3339),
+		r.Pair,
+		r.Interval,
+		export.FormatFloat(r.Open),
+		export.FormatFloat(r.High),
+		export.FormatFloat(r.Low),
+		export.FormatFloat(r.Close),
+	}
+}
@@ -0,0 +1,491 @@
+package candles
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// ImportFormat is the layout of an imported candles file
+type ImportFormat string
+
+const (
+	// ImportBinance is a Binance spot kline dump, eg. BTCUSDC-1m-2024-01.csv, its columns are the open time,
+	// open, high, low, close, volume, close time... without header, the pair and interval are the ones of the file name
+	ImportBinance ImportFormat = "binance"
+	// ImportCSV is a CSV with a header, its columns are mapped to the candle fields by ImportOptions.Columns
+	ImportCSV ImportFormat = "csv"
+)
+
+// DateFormat is the format of the date column, RFC3339, a unix timestamp or a Go layout of a UTC date, eg. 2006-01-02 15:04:05
+type DateFormat string
+
+const (
+	DateRFC3339 DateFormat = "rfc3339"
+	// DateUnix is a timestamp in seconds, milliseconds or microseconds after its magnitude, the Binance dumps switched to microseconds in 2025
+	DateUnix DateFormat = "unix"
+)
+
+// Columns are the names of the CSV columns of the candle fields, the pair and interval ones are ignored when fixed by the options
+type Columns struct {
+	Date     string
+	Pair     string
+	Interval string
+	Open     string
+	High     string
+	Low      string
+	Close    string
+}
+
+// fields returns the columns by candle field, in the CSVColumns order
+func (c Columns) fields() []struct{ field, column string } {
+	return []struct{ field, column string }{
+		{"date", c.Date}, {"pair", c.Pair}, {"interval", c.Interval},
+		{"open", c.Open}, {"high", c.High}, {"low", c.Low}, {"close", c.Close},
+	}
+}
+
+// ParseColumns parses a field=column list, eg. date=open_time,close=c, the fields not listed keep the column of their name
+func ParseColumns(arg string) (Columns, error) {
+	columns := Columns{}
+	if arg == "" {
+		return columns, nil
+	}
+
+	targets := map[string]*string{
+		"date": &columns.Date, "pair": &columns.Pair, "interval": &columns.Interval,
+		"open": &columns.Open, "high": &columns.High, "low": &columns.Low, "close": &columns.Close,
+	}
+	for _, mapping := range strings.Split(arg, ",") {
+		field, column, ok := strings.Cut(mapping, "=")
+		target, known := targets[strings.TrimSpace(field)]
+		if !ok || !known || strings.TrimSpace(column) == "" {
+			return Columns{}, fmt.Errorf("invalid column mapping %q, expected <field>=<column> with a field of %s", mapping, strings.Join(CSVColumns, ", "))
+		}
+
+		*target = strings.TrimSpace(column)
+	}
+
+	return columns, nil
+}
+
+// ImportOptions configures the reading of an imported file
+type ImportOptions struct {
+	Format ImportFormat
+	// Pair and Interval are the ones of all the candles of the file, instead of its columns or its Binance file name
+	Pair     common.Pair
+	Interval common.Interval
+	// Columns and DateFormat only apply to ImportCSV, Binance dumps are read by position
+	Columns    Columns
+	DateFormat DateFormat
+	// Delimiter defaults to a comma
+	Delimiter rune
+}
+
+// withDefaults fills the columns not mapped with the ones of their field name, and the default date format
+func (o ImportOptions) withDefaults() ImportOptions {
+	defaults := Columns{Date: "date", Pair: "pair", Interval: "interval", Open: "open", High: "high", Low: "low", Close: "close"}
+	targets := []*string{&o.Columns.Date, &o.Columns.Pair, &o.Columns.Interval, &o.Columns.Open, &o.Columns.High, &o.Columns.Low, &o.Columns.Close}
+	for i, field := range defaults.fields() {
+		if *targets[i] == "" {
+			*targets[i] = field.column
+		}
+	}
+
+	if o.DateFormat == "" {
+		o.DateFormat = DateRFC3339
+		if o.Format == ImportBinance {
+			o.DateFormat = DateUnix
+		}
+	}
+
+	return o
+}
+
+// forFile validates the options of the named file, a Binance dump takes the pair and interval of its name when not set
+func (o ImportOptions) forFile(name string) (ImportOptions, error) {
+	o = o.withDefaults()
+	violations := appErrors.FieldViolations{}
+	if o.Format != ImportBinance && o.Format != ImportCSV {
+		violations.Add("format", appErrors.ViolationInvalidValue, fmt.Sprintf("format must be %s or %s", ImportBinance, ImportCSV))
+	}
+
+	if o.Pair != "" && !o.Pair.IsValid() {
+		violations.Add("pair", appErrors.ViolationInvalidValue, fmt.Sprintf("pair %q is not allowed", o.Pair))
+	}
+
+	if o.Interval != "" && (o.Interval == common.NA || !o.Interval.IsValid()) {
+		violations.Add("interval", appErrors.ViolationInvalidValue, fmt.Sprintf("interval %q is not allowed", o.Interval))
+	}
+
+	if o.Format == ImportBinance && (o.Pair == "" || o.Interval == "") {
+		pair, interval, err := ParseBinanceFileName(name)
+		if o.Pair == "" {
+			o.Pair = pair
+		}
+
+		if o.Interval == "" {
+			o.Interval = interval
+		}
+
+		if err != nil && o.Pair == "" {
+			violations.Add("pair", appErrors.ViolationRequired, fmt.Sprintf("pair is required, %v", err))
+		}
+
+		if err != nil && o.Interval == "" {
+			violations.Add("interval", appErrors.ViolationRequired, fmt.Sprintf("interval is required, %v", err))
+		}
+	}
+
+	if err := violations.Err("invalid import options"); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// ParseBinanceFileName returns the pair and the interval of a Binance dump name, eg. BTCUSDC-1m-2024-01.zip
+func ParseBinanceFileName(name string) (common.Pair, common.Interval, error) {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	parts := strings.Split(strings.TrimSuffix(base, path.Ext(base)), "-")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("the file name %q is not <symbol>-<interval>-<date>", base)
+	}
+
+	pair, err := common.ParseSymbol(parts[0])
+	if err != nil {
+		return "", "", fmt.Errorf("the file name %q symbol: %w", base, err)
+	}
+
+	interval, err := common.ParseExchangeInterval(parts[1])
+	if err != nil {
+		return pair, "", fmt.Errorf("the file name %q interval: %w", base, err)
+	}
+
+	return pair, interval, nil
+}
+
+// NewImportReader reads the candles of an imported CSV file, name is the file name, eg. BTCUSDC-1m-2024-01.csv
+// The symbols and intervals are mapped to the Bifrost ones, the consistency of the prices is checked by the ingestion with the configured validation
+// The violations fields are /<name>/<line>/<field>, or /<line>/<field> without name
+func NewImportReader(r io.Reader, name string, options ImportOptions) (Reader, error) {
+	options, err := options.forFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	}
+
+	return newCSVReader(r, name, options, true)
+}
+
+// NewZipImportReader reads the CSV files of a ZIP archive one after the other, eg. a Binance dump
+func NewZipImportReader(r io.ReaderAt, size int64, options ImportOptions) (Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, appErrors.NewInvalidInput("invalid zip archive", err)
+	}
+
+	files := []*zip.File{}
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() && strings.EqualFold(path.Ext(file.Name), ".csv") {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, appErrors.NewInvalidInput("no csv file in the zip archive", nil)
+	}
+
+	// The options of all the files are checked before reading any
+	for _, file := range files {
+		if _, err := options.forFile(file.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return &zipReader{files: files, options: options}, nil
+}
+
+type zipReader struct {
+	files   []*zip.File
+	options ImportOptions
+	current Reader
+	closer  io.Closer
+}
+
+func (r *zipReader) Read() (Candle, error) {
+	for {
+		if r.current == nil {
+			if len(r.files) == 0 {
+				return Candle{}, io.EOF
+			}
+
+			file := r.files[0]
+			r.files = r.files[1:]
+			rc, err := file.Open()
+			if err != nil {
+				return Candle{}, appErrors.NewInvalidInput(fmt.Sprintf("unable to open %s of the zip archive", file.Name), err)
+			}
+
+			r.closer = rc
+			r.current, err = NewImportReader(rc, file.Name, r.options)
+			if err != nil {
+				rc.Close()
+				return Candle{}, err
+			}
+		}
+
+		candle, err := r.current.Read()
+		if errors.Is(err, io.EOF) {
+			r.closer.Close()
+			r.current = nil
+			continue
+		}
+
+		return candle, err
+	}
+}
+
+// csvReader reads the candles of a CSV, its columns are mapped by name with a header, or by position for a Binance dump
+type csvReader struct {
+	reader *csv.Reader
+	// file prefixes the violations fields, empty for a stream
+	file    string
+	columns map[string]int
+	// pair and interval are fixed when set, instead of read from the columns
+	pair       common.Pair
+	interval   common.Interval
+	dateFormat DateFormat
+	// skipHeader skips a first line that is not a candle, some Binance dumps have a header
+	skipHeader bool
+	// imported maps the exchange symbols and intervals
+	imported bool
+}
+
+// binanceColumns are the positions of the Binance kline columns
+var binanceColumns = map[string]int{"date": 0, "open": 1, "high": 2, "low": 3, "close": 4}
+
+func newCSVReader(r io.Reader, file string, options ImportOptions, imported bool) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	if options.Delimiter != 0 {
+		reader.Comma = options.Delimiter
+	}
+
+	res := &csvReader{
+		reader:     reader,
+		file:       file,
+		pair:       options.Pair,
+		interval:   options.Interval,
+		dateFormat: options.DateFormat,
+		imported:   imported,
+	}
+
+	if options.Format == ImportBinance {
+		// The count of columns is checked by line, a header can have less
+		reader.FieldsPerRecord = -1
+		res.columns = binanceColumns
+		res.skipHeader = true
+		return res, nil
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, appErrors.NewInvalidInput("empty csv, the header is required", nil)
+	}
+
+	if err != nil {
+		return nil, appErrors.NewInvalidInput("unable to read the csv header", err)
+	}
+
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		indexes[strings.TrimSpace(name)] = i
+	}
+
+	res.columns = make(map[string]int, len(CSVColumns))
+	violations := appErrors.FieldViolations{}
+	for _, c := range options.Columns.fields() {
+		if (c.field == "pair" && res.pair != "") || (c.field == "interval" && res.interval != "") {
+			continue
+		}
+
+		index, found := indexes[c.column]
+		if !found {
+			violations.Add(res.pointer(1, c.column), appErrors.ViolationRequired, fmt.Sprintf("column %s is required", c.column))
+		}
+
+		res.columns[c.field] = index
+	}
+
+	if err := violations.Err("invalid csv header"); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *csvReader) pointer(line int, field ...string) string {
+	tokens := []any{}
+	if r.file != "" {
+		tokens = append(tokens, r.file)
+	}
+
+	tokens = append(tokens, line)
+	for _, f := range field {
+		tokens = append(tokens, f)
+	}
+
+	return appErrors.Pointer(tokens...)
+}
+
+func (r *csvReader) lineErr(line int, violations appErrors.FieldViolations) error {
+	if r.file != "" {
+		return violations.Err(fmt.Sprintf("invalid line %d of %s", line, r.file))
+	}
+
+	return violations.Err(fmt.Sprintf("invalid line %d", line))
+}
+
+func (r *csvReader) Read() (Candle, error) {
+	for {
+		record, err := r.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return Candle{}, io.EOF
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			violations := appErrors.FieldViolations{}
+			violations.Add(r.pointer(parseErr.StartLine), appErrors.ViolationInvalidFormat, parseErr.Err.Error())
+			return Candle{}, r.lineErr(parseErr.StartLine, violations)
+		}
+
+		if err != nil {
+			return Candle{}, appErrors.NewInvalidInput("unable to read the csv", err)
+		}
+
+		line, _ := r.reader.FieldPos(0)
+		if r.skipHeader {
+			r.skipHeader = false
+			if _, err := strconv.ParseFloat(record[0], 64); err != nil {
+				continue
+			}
+		}
+
+		return r.candle(record, line)
+	}
+}
+
+func (r *csvReader) candle(record []string, line int) (Candle, error) {
+	violations := appErrors.FieldViolations{}
+	for field, index := range r.columns {
+		if index >= len(record) {
+			violations.Add(r.pointer(line, field), appErrors.ViolationRequired, fmt.Sprintf("%s is required, the line has %d columns", field, len(record)))
+		}
+	}
+
+	if err := r.lineErr(line, violations); err != nil {
+		return Candle{}, err
+	}
+
+	candle := Candle{Pair: r.pair, Interval: r.interval}
+	if candle.Pair == "" {
+		candle.Pair = common.Pair(strings.TrimSpace(record[r.columns["pair"]]))
+		if r.imported && candle.Pair != "" {
+			pair, err := common.ParseSymbol(string(candle.Pair))
+			if err != nil {
+				violations.Add(r.pointer(line, "pair"), appErrors.ViolationInvalidValue, err.Error())
+			}
+
+			candle.Pair = pair
+		}
+	}
+
+	if candle.Interval == "" {
+		candle.Interval = common.Interval(strings.TrimSpace(record[r.columns["interval"]]))
+		if r.imported && candle.Interval != "" {
+			interval, err := common.ParseExchangeInterval(string(candle.Interval))
+			if err != nil {
+				violations.Add(r.pointer(line, "interval"), appErrors.ViolationInvalidValue, err.Error())
+			}
+
+			candle.Interval = interval
+		}
+	}
+
+	if value := strings.TrimSpace(record[r.columns["date"]]); value != "" {
+		date, err := parseDate(value, r.dateFormat)
+		if err != nil {
+			violations.Add(r.pointer(line, "date"), appErrors.ViolationInvalidFormat, fmt.Sprintf("date must be %s: %v", r.dateFormat, err))
+		}
+
+		candle.Date = Date(date)
+	}
+
+	prices := []struct {
+		name  string
+		value *float64
+	}{
+		{name: "open", value: &candle.Open},
+		{name: "high", value: &candle.High},
+		{name: "low", value: &candle.Low},
+		{name: "close", value: &candle.Close},
+	}
+	for _, price := range prices {
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[r.columns[price.name]]), 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			violations.Add(r.pointer(line, price.name), appErrors.ViolationInvalidFormat, price.name+" must be a number")
+		}
+
+		*price.value = value
+	}
+
+	if err := r.lineErr(line, violations); err != nil {
+		return Candle{}, err
+	}
+
+	candle.Validate(&violations, r.pointer(line))
+	if err := r.lineErr(line, violations); err != nil {
+		return Candle{}, err
+	}
+
+	return candle, nil
+}
+
+// parseDate parses a date of the format, the dates without time zone are UTC
+func parseDate(value string, format DateFormat) (time.Time, error) {
+	switch format {
+	case DateRFC3339:
+		return time.Parse(time.RFC3339, value)
+	case DateUnix:
+		timestamp, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		switch {
+		case timestamp < 1e11:
+			return time.Unix(timestamp, 0).UTC(), nil
+		case timestamp < 1e14:
+			return time.UnixMilli(timestamp).UTC(), nil
+		default:
+			return time.UnixMicro(timestamp).UTC(), nil
+		}
+	}
+
+	return time.Parse(string(format), value)
+}
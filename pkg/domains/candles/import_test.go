@@ -0,0 +1,184 @@
+package candles
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+func importAll(reader Reader, err error) ([]Candle, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Candle{}
+	for candle, err := range All(reader) {
+		if err != nil {
+			return res, err
+		}
+
+		res = append(res, candle)
+	}
+
+	return res, nil
+}
+
+func TestImportReader(t *testing.T) {
+	// The Binance klines columns are open time, open, high, low, close, volume, close time, quote volume, trades...
+	kline := "1704067200000,42000.1,42100,41900.5,42050,12.5,1704067259999,525000,100,6,250000,0\n"
+	tests := []struct {
+		name      string
+		file      string
+		options   ImportOptions
+		body      string
+		wantCount int
+		want      Candle
+		// wantField is the field of the first violation, empty when the body is valid
+		wantField string
+	}{
+		{
+			name:      "binance pair and interval of the file name",
+			file:      "BTCUSDC-1h-2024-01.csv",
+			options:   ImportOptions{Format: ImportBinance},
+			body:      kline,
+			wantCount: 1,
+			want:      Candle{Date: Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.BTCUSDC, Interval: common.H1, Open: 42000.1, High: 42100, Low: 41900.5, Close: 42050},
+		},
+		{
+			name:      "binance header and microseconds",
+			file:      "SOLUSDC-1d-2025-01.csv",
+			options:   ImportOptions{Format: ImportBinance},
+			body:      "open_time,open,high,low,close,volume\n1735689600000000,1,3,0.5,2,10\n",
+			wantCount: 1,
+			want:      Candle{Date: Date(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.SOLUSDC, Interval: common.D1, Open: 1, High: 3, Low: 0.5, Close: 2},
+		},
+		{
+			name:      "binance without pair",
+			options:   ImportOptions{Format: ImportBinance, Interval: common.M1},
+			body:      kline,
+			wantField: "pair",
+		},
+		{
+			// The ingestion checks them with the configured validation
+			name:      "binance inconsistent prices are read",
+			file:      "BTCUSDC-1m-2024-01.csv",
+			options:   ImportOptions{Format: ImportBinance},
+			body:      kline + "1704067260000,42000,41000,41900,42050,1\n",
+			wantCount: 2,
+			want:      Candle{Date: Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.BTCUSDC, Interval: common.M1, Open: 42000.1, High: 42100, Low: 41900.5, Close: 42050},
+		},
+		{
+			name:      "csv mapped columns and symbols",
+			options:   ImportOptions{Format: ImportCSV, Columns: Columns{Date: "time", Pair: "symbol", Interval: "tf", Open: "o", High: "h", Low: "l", Close: "c"}, DateFormat: "2006-01-02 15:04", Delimiter: ';'},
+			body:      "time;symbol;tf;o;h;l;c\n2024-01-01 00:00;btc/usdc;1H;1;3;0.5;2\n",
+			wantCount: 1,
+			want:      Candle{Date: Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.BTCUSDC, Interval: common.H1, Open: 1, High: 3, Low: 0.5, Close: 2},
+		},
+		{
+			name:      "csv fixed pair without its column",
+			options:   ImportOptions{Format: ImportCSV, Pair: common.SOLUSDC, Interval: common.M5},
+			body:      "date,open,high,low,close\n2024-01-01T00:00:00Z,1,3,0.5,2\n",
+			wantCount: 1,
+			want:      Candle{Date: Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.SOLUSDC, Interval: common.M5, Open: 1, High: 3, Low: 0.5, Close: 2},
+		},
+		{
+			name:      "csv unknown symbol",
+			options:   ImportOptions{Format: ImportCSV},
+			body:      "date,pair,interval,open,high,low,close\n2024-01-01T00:00:00Z,BTCUSDT,1m,1,3,0.5,2\n",
+			wantField: "/2/pair",
+		},
+		{
+			name:      "csv monthly interval",
+			options:   ImportOptions{Format: ImportCSV},
+			body:      "date,pair,interval,open,high,low,close\n2024-01-01T00:00:00Z,BTCUSDC,1M,1,3,0.5,2\n",
+			wantField: "/2/interval",
+		},
+		{
+			name:      "unknown format",
+			options:   ImportOptions{Format: "parquet"},
+			wantField: "format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importAll(NewImportReader(strings.NewReader(tt.body), tt.file, tt.options))
+			if len(got) != tt.wantCount {
+				t.Errorf("read %d candles, want %d", len(got), tt.wantCount)
+			}
+
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("read error = %v", err)
+				}
+
+				if !time.Time(got[0].Date).Equal(time.Time(tt.want.Date)) || got[0].Pair != tt.want.Pair || got[0].Interval != tt.want.Interval ||
+					got[0].Open != tt.want.Open || got[0].High != tt.want.High || got[0].Low != tt.want.Low || got[0].Close != tt.want.Close {
+					t.Errorf("candle = %+v, want %+v", got[0], tt.want)
+				}
+
+				return
+			}
+
+			var appErr *appErrors.AppError
+			if !errors.As(err, &appErr) || !errors.Is(err, appErrors.ErrInvalidInput) || len(appErr.Violations) == 0 || appErr.Violations[0].Field != tt.wantField {
+				t.Errorf("read error = %v, want a violation of %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestZipImportReader(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := map[string]string{
+		"BTCUSDC-1m-2024-01-01.csv": "1704067200000,1,3,0.5,2,10\n1704067260000,2,2,2,2,10\n",
+		"SOLUSDC-1m-2024-01-01.csv": "1704067200000,1,3,0.5,2,10\n",
+		"README.txt":                "not a dump",
+	}
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		w.Write([]byte(content))
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got, err := importAll(NewZipImportReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), ImportOptions{Format: ImportBinance}))
+	if err != nil || len(got) != 3 {
+		t.Fatalf("read %d candles, error = %v, want 3", len(got), err)
+	}
+
+	pairs := map[common.Pair]int{}
+	for _, candle := range got {
+		pairs[candle.Pair]++
+	}
+
+	if pairs[common.BTCUSDC] != 2 || pairs[common.SOLUSDC] != 1 {
+		t.Errorf("pairs = %v, want the pair of each file name", pairs)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	got, err := ParseColumns("date=open_time, close = c")
+	if err != nil || got.Date != "open_time" || got.Close != "c" || got.Open != "" {
+		t.Errorf("ParseColumns() = %+v, %v", got, err)
+	}
+
+	for _, arg := range []string{"volume=v", "date", "date="} {
+		if _, err := ParseColumns(arg); err == nil {
+			t.Errorf("ParseColumns(%q) error = nil, want an error", arg)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package candles
+
+import (
+	"fmt"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// Issue is an integrity issue of a candle, such candles corrupt the TP and SL hit searches
+type Issue string
+
+const (
+	IssueNonPositivePrice Issue = "non_positive_price"
+	IssueHighBelowLow     Issue = "high_below_low"
+	// IssueOutOfRange is an open or a close out of the low and high range
+	IssueOutOfRange Issue = "out_of_range"
+	// IssueMisalignedDate is a date that is not the beginning of its interval, see common.Interval.RoundDateToBeginingOfInterval
+	IssueMisalignedDate  Issue = "misaligned_date"
+	IssueUnknownInterval Issue = "unknown_interval"
+)
+
+// Validation is how the candles with issues are handled on creation
+type Validation string
+
+const (
+	// ValidationReject refuses the candles with issues
+	ValidationReject Validation = "reject"
+	// ValidationFlag stores the candles with their issues, so they are listed by the quality report
+	ValidationFlag Validation = "flag"
+)
+
+func ParseValidation(arg string) (Validation, error) {
+	switch validation := Validation(arg); validation {
+	case ValidationReject, ValidationFlag:
+		return validation, nil
+	}
+
+	return "", fmt.Errorf("wrong candles validation %q, must be %s or %s", arg, ValidationReject, ValidationFlag)
+}
+
+// Check returns the integrity issues of the candle, and adds their violations at the pointer
+// The prices must be positive, the low and the high bound the open and the close, and the date begins the interval
+func (c Candle) Check(violations *appErrors.FieldViolations, pointer string) []Issue {
+	issues := []Issue{}
+	prices := []struct {
+		name  string
+		value float64
+	}{{"open", c.Open}, {"high", c.High}, {"low", c.Low}, {"close", c.Close}}
+	for _, price := range prices {
+		if price.value <= 0 {
+			violations.Add(pointer+"/"+price.name, appErrors.ViolationInvalidValue, price.name+" must be positive")
+			if len(issues) == 0 {
+				issues = append(issues, IssueNonPositivePrice)
+			}
+		}
+	}
+
+	if c.High < c.Low {
+		violations.Add(pointer+"/high", appErrors.ViolationInvalidValue, fmt.Sprintf("high %v must not be below the low %v", c.High, c.Low))
+		issues = append(issues, IssueHighBelowLow)
+	} else if c.Low > min(c.Open, c.Close) || c.High < max(c.Open, c.Close) {
+		if c.Low > min(c.Open, c.Close) {
+			violations.Add(pointer+"/low", appErrors.ViolationInvalidValue, fmt.Sprintf("low %v must not be above the open and the close", c.Low))
+		}
+
+		if c.High < max(c.Open, c.Close) {
+			violations.Add(pointer+"/high", appErrors.ViolationInvalidValue, fmt.Sprintf("high %v must not be below the open and the close", c.High))
+		}
+
+		issues = append(issues, IssueOutOfRange)
+	}
+
+	if !c.Interval.IsValid() {
+		violations.Add(pointer+"/interval", appErrors.ViolationInvalidValue, fmt.Sprintf("interval %q is not allowed", c.Interval))
+		return append(issues, IssueUnknownInterval)
+	}
+
+	date := time.Time(c.Date)
+	if begin := c.Interval.RoundDateToBeginingOfInterval(date); begin != nil && !begin.Equal(date) {
+		violations.Add(pointer+"/date", appErrors.ViolationInvalidValue, fmt.Sprintf("date %s must be the beginning of a %s interval, eg. %s", c.Date, c.Interval, begin.Format(time.RFC3339)))
+		issues = append(issues, IssueMisalignedDate)
+	}
+
+	return issues
+}
+
+// QualityFilter selects the candles of a quality report, the empty fields select all of them
+type QualityFilter struct {
+	Pair      common.Pair
+	Interval  common.Interval
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// QualityReport lists the candles with issues, Count and Issues count all of them while Candles holds up to the report limit
+type QualityReport struct {
+	Count     int           `json:"count"`
+	Issues    map[Issue]int `json:"issues"`
+	Candles   []Candle      `json:"candles"`
+	Truncated bool          `json:"truncated"`
+}
+
+// Add counts the candle, it is listed while the report holds less than limit candles
+func (r *QualityReport) Add(candle Candle, limit int) {
+	r.Count++
+	for _, issue := range candle.Issues {
+		r.Issues[issue]++
+	}
+
+	if len(r.Candles) < limit {
+		r.Candles = append(r.Candles, candle)
+		return
+	}
+
+	r.Truncated = true
+}
@@ -0,0 +1,57 @@
+package candles
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+func TestCheck(t *testing.T) {
+	// A Monday, the beginning of a week
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := Candle{Date: Date(day), Pair: common.SOLUSDC, Interval: common.H4, Open: 2, High: 3, Low: 1, Close: 2.5}
+	with := func(update func(c *Candle)) Candle {
+		c := valid
+		update(&c)
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		candle     Candle
+		wantIssues []Issue
+		wantFields []string
+	}{
+		{name: "valid", candle: valid, wantIssues: []Issue{}},
+		{name: "valid week", candle: with(func(c *Candle) { c.Interval = common.W1 }), wantIssues: []Issue{}},
+		{name: "negative price", candle: with(func(c *Candle) { c.Low = -1 }), wantIssues: []Issue{IssueNonPositivePrice}, wantFields: []string{"/0/low"}},
+		{name: "high below low", candle: with(func(c *Candle) { c.High, c.Low = 1, 3 }), wantIssues: []Issue{IssueHighBelowLow}, wantFields: []string{"/0/high"}},
+		{name: "close above high", candle: with(func(c *Candle) { c.Close = 4 }), wantIssues: []Issue{IssueOutOfRange}, wantFields: []string{"/0/high"}},
+		{name: "open below low", candle: with(func(c *Candle) { c.Open = 0.5 }), wantIssues: []Issue{IssueOutOfRange}, wantFields: []string{"/0/low"}},
+		{name: "misaligned date", candle: with(func(c *Candle) { c.Date = Date(day.Add(time.Hour)) }), wantIssues: []Issue{IssueMisalignedDate}, wantFields: []string{"/0/date"}},
+		{name: "misaligned week", candle: with(func(c *Candle) { c.Interval, c.Date = common.W1, Date(day.Add(24*time.Hour)) }), wantIssues: []Issue{IssueMisalignedDate}, wantFields: []string{"/0/date"}},
+		{name: "unknown interval", candle: with(func(c *Candle) { c.Interval = "7h"; c.Close = 4 }), wantIssues: []Issue{IssueOutOfRange, IssueUnknownInterval}, wantFields: []string{"/0/high", "/0/interval"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := appErrors.FieldViolations{}
+			issues := tt.candle.Check(&violations, appErrors.Pointer(0))
+			if !slices.Equal(issues, tt.wantIssues) {
+				t.Errorf("Check() = %v, want %v", issues, tt.wantIssues)
+			}
+
+			fields := []string{}
+			for _, v := range violations {
+				fields = append(fields, v.Field)
+			}
+
+			if !slices.Equal(fields, tt.wantFields) && len(fields)+len(tt.wantFields) > 0 {
+				t.Errorf("violations = %+v, want the fields %v", violations, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package candles
+
+import (
+	"fmt"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// Correction overwrites the prices of existing candles, or inserts the missing ones
+// Source names where the corrected prices come from, eg. binance-vision, it is kept with the replaced versions
+type Correction struct {
+	Source  string   `json:"source"`
+	Candles []Candle `json:"candles"`
+}
+
+// Validate adds the violations of the correction, the candles are validated as the ones to create
+// A candle is given once, the correction is applied to all of them at once
+func (c Correction) Validate(violations *appErrors.FieldViolations) {
+	if c.Source == "" {
+		violations.Add("/source", appErrors.ViolationRequired, "source is required")
+	}
+
+	if len(c.Candles) == 0 {
+		violations.Add("/candles", appErrors.ViolationRequired, "candles are required")
+	}
+
+	type candleKey struct {
+		date     int64
+		pair     common.Pair
+		interval common.Interval
+	}
+
+	given := make(map[candleKey]int, len(c.Candles))
+	for i, candle := range c.Candles {
+		candle.Validate(violations, appErrors.Pointer("candles", i))
+
+		key := candleKey{date: time.Time(candle.Date).UnixNano(), pair: candle.Pair, interval: candle.Interval}
+		if first, found := given[key]; found {
+			violations.Add(appErrors.Pointer("candles", i), appErrors.ViolationInvalidValue, fmt.Sprintf("candle already given at %s", appErrors.Pointer("candles", first)))
+			continue
+		}
+
+		given[key] = i
+	}
+}
+
+// DateRange is the first and last dates of a set of candles
+type DateRange struct {
+	First time.Time
+	Last  time.Time
+}
+
+// RangesByPair returns the date range of the candles of the interval for each pair
+// The stale positions of a correction are marked once per pair, from the first to the last revised candle
+func RangesByPair(candles []Candle, interval common.Interval) map[common.Pair]DateRange {
+	ranges := map[common.Pair]DateRange{}
+	for _, candle := range candles {
+		if candle.Interval != interval {
+			continue
+		}
+
+		date := time.Time(candle.Date)
+		r, found := ranges[candle.Pair]
+		if !found || date.Before(r.First) {
+			r.First = date
+		}
+
+		if !found || date.After(r.Last) {
+			r.Last = date
+		}
+
+		ranges[candle.Pair] = r
+	}
+
+	return ranges
+}
+
+// Revision is a replaced version of a corrected candle, with the source and the date of the correction that replaced it
+type Revision struct {
+	CandleID  ID        `json:"candle_id"`
+	Open      float64   `json:"open"`
+	Close     float64   `json:"close"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Issues    []Issue   `json:"issues,omitempty"`
+	Source    string    `json:"source"`
+	RevisedAt time.Time `json:"revised_at"`
+}
+
+// RevisionOf returns the revision keeping the prices of the candle before its correction
+func RevisionOf(candle Candle, source string, revisedAt time.Time) Revision {
+	revision := Revision{
+		Open:      candle.Open,
+		Close:     candle.Close,
+		High:      candle.High,
+		Low:       candle.Low,
+		Issues:    candle.Issues,
+		Source:    source,
+		RevisedAt: revisedAt,
+	}
+
+	if candle.ID != nil {
+		revision.CandleID = *candle.ID
+	}
+
+	return revision
+}
+
+// SamePrices returns whether the correction leaves the prices of the candle unchanged, such a candle is not revised
+func (c Candle) SamePrices(other Candle) bool {
+	return c.Open == other.Open && c.Close == other.Close && c.High == other.High && c.Low == other.Low
+}
+
+// CorrectionResult lists the inserted and corrected candles, the unchanged ones are only counted
+// StalePositions counts the computed positions whose ratio may depend on a revised candle, they are computed again by compute-all
+type CorrectionResult struct {
+	Candles        []Candle `json:"candles"`
+	Inserted       int      `json:"inserted"`
+	Corrected      int      `json:"corrected"`
+	Unchanged      int      `json:"unchanged"`
+	StalePositions int      `json:"stale_positions"`
+}
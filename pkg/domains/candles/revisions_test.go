@@ -0,0 +1,42 @@
+package candles
+
+import (
+	"testing"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+func TestCorrectionValidateDuplicates(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := Candle{Date: Date(day), Pair: common.SOLUSDC, Interval: common.M1, Open: 2, High: 3, Low: 1, Close: 2.5}
+	other := candle
+	other.Interval = common.H1
+
+	violations := appErrors.FieldViolations{}
+	Correction{Source: "binance-vision", Candles: []Candle{candle, other, candle}}.Validate(&violations)
+	if len(violations) != 1 || violations[0].Field != "/candles/2" {
+		t.Errorf("violations = %+v, want the candle given twice", violations)
+	}
+}
+
+func TestRangesByPair(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := []Candle{
+		{Date: Date(day.Add(2 * time.Minute)), Pair: common.SOLUSDC, Interval: common.M1},
+		{Date: Date(day), Pair: common.SOLUSDC, Interval: common.M1},
+		{Date: Date(day.Add(time.Minute)), Pair: common.SOLUSDC, Interval: common.M1},
+		{Date: Date(day.Add(time.Hour)), Pair: common.SOLUSDC, Interval: common.H1},
+		{Date: Date(day), Pair: common.BTCUSDC, Interval: common.M1},
+	}
+
+	got := RangesByPair(candles, common.M1)
+	if len(got) != 2 || !got[common.SOLUSDC].First.Equal(day) || !got[common.SOLUSDC].Last.Equal(day.Add(2*time.Minute)) {
+		t.Errorf("RangesByPair() = %+v, want the 1m dates of SOLUSDC from the first to the third minute", got)
+	}
+
+	if !got[common.BTCUSDC].First.Equal(day) || !got[common.BTCUSDC].Last.Equal(day) {
+		t.Errorf("RangesByPair() of BTCUSDC = %+v, want its single date", got[common.BTCUSDC])
+	}
+}
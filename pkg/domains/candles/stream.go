@@ -0,0 +1,195 @@
+package candles
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"strconv"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+)
+
+// Format is the encoding of a candles stream, one candle per line
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+const (
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	MIMETextCSV           = "text/csv"
+)
+
+// CSVColumns is the header of a CSV stream, the columns can be in any order and the other ones are ignored
+var CSVColumns = []string{"date", "pair", "interval", "open", "high", "low", "close"}
+
+// maxLineSize bounds a NDJSON line, a candle with its RSI is far below
+const maxLineSize = 1 << 20
+
+// FormatOfMediaType returns the format of a Content-Type header
+func FormatOfMediaType(contentType string) (Format, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case MIMEApplicationNDJSON:
+		return FormatNDJSON, true
+	case MIMETextCSV:
+		return FormatCSV, true
+	}
+
+	return "", false
+}
+
+func (f Format) MediaType() string {
+	if f == FormatCSV {
+		return MIMETextCSV
+	}
+
+	return MIMEApplicationNDJSON
+}
+
+// IngestResult counts the candles of a bulk ingestion, the skipped ones already exist
+// Flagged counts the read candles with issues, stored unless they were skipped, see Validation
+type IngestResult struct {
+	Inserted int `json:"inserted"`
+	Skipped  int `json:"skipped"`
+	Flagged  int `json:"flagged,omitempty"`
+}
+
+// Reader decodes the candles of a stream one at a time
+// Read returns io.EOF at the end of the stream, and an invalid input error on the first invalid line
+// The violations fields are /<line>/<field>, the line number starts at 1
+type Reader interface {
+	Read() (Candle, error)
+}
+
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	case FormatCSV:
+		return newCSVReader(r, "", ImportOptions{Format: ImportCSV}.withDefaults(), false)
+	}
+
+	return nil, appErrors.NewInvalidInput(fmt.Sprintf("unknown candles format %q", format), nil)
+}
+
+// All iterates over the candles of the reader, the iteration stops on the first error
+func All(r Reader) iter.Seq2[Candle, error] {
+	return func(yield func(Candle, error) bool) {
+		for {
+			candle, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if !yield(candle, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Read() (Candle, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		candle := Candle{}
+		if err := json.Unmarshal(data, &candle); err != nil {
+			violations := appErrors.FieldViolations{}
+			violations.Add(appErrors.Pointer(r.line), appErrors.ViolationInvalidFormat, err.Error())
+			return Candle{}, violations.Err(fmt.Sprintf("invalid line %d", r.line))
+		}
+
+		violations := appErrors.FieldViolations{}
+		candle.Validate(&violations, appErrors.Pointer(r.line))
+		if err := violations.Err(fmt.Sprintf("invalid line %d", r.line)); err != nil {
+			return Candle{}, err
+		}
+
+		return candle, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return Candle{}, appErrors.NewInvalidInput(fmt.Sprintf("unable to read line %d", r.line+1), err)
+	}
+
+	return Candle{}, io.EOF
+}
+
+// Writer encodes the candles of a stream one at a time, Flush has to be called once done
+type Writer interface {
+	Write(Candle) error
+	Flush() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(CSVColumns); err != nil {
+			return nil, fmt.Errorf("unable to write the csv header: %w", err)
+		}
+
+		return &csvWriter{writer: writer}, nil
+	}
+
+	return nil, appErrors.NewInvalidInput(fmt.Sprintf("unknown candles format %q", format), nil)
+}
+
+type ndjsonWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+// Write encodes the candle followed by a newline
+func (w *ndjsonWriter) Write(candle Candle) error {
+	return w.encoder.Encode(candle)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buffered.Flush()
+}
+
+// csvWriter writes the CSVColumns, the RSI is not part of the CSV format
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(candle Candle) error {
+	return w.writer.Write([]string{
+		candle.Date.String(),
+		candle.Pair.String(),
+		candle.Interval.String(),
+		strconv.FormatFloat(candle.Open, 'f', -1, 64),
+		strconv.FormatFloat(candle.High, 'f', -1, 64),
+		strconv.FormatFloat(candle.Low, 'f', -1, 64),
+		strconv.FormatFloat(candle.Close, 'f', -1, 64),
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,154 @@
+package candles
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+func readAll(t *testing.T, format Format, body string) ([]Candle, error) {
+	t.Helper()
+
+	reader, err := NewReader(format, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Candle{}
+	for candle, err := range All(reader) {
+		if err != nil {
+			return res, err
+		}
+
+		res = append(res, candle)
+	}
+
+	return res, nil
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    Format
+		body      string
+		wantCount int
+		// wantField is the field of the first violation, empty when the body is valid
+		wantField string
+	}{
+		{
+			name:      "ndjson",
+			format:    FormatNDJSON,
+			body:      "{\"date\": \"2024-01-01T00:00:00Z\", \"pair\": \"SOLUSDC\", \"interval\": \"1m\", \"open\": 1, \"close\": 2, \"high\": 3, \"low\": 0.5}\n\n{\"date\": \"2024-01-01T00:01:00Z\", \"pair\": \"SOLUSDC\", \"interval\": \"1m\", \"open\": 2, \"close\": 2, \"high\": 2, \"low\": 2}",
+			wantCount: 2,
+		},
+		{
+			name:      "ndjson invalid json",
+			format:    FormatNDJSON,
+			body:      "{\"date\": \"2024-01-01T00:00:00Z\", \"pair\": \"SOLUSDC\", \"interval\": \"1m\"}\n{\"date\": ",
+			wantCount: 1,
+			wantField: "/2",
+		},
+		{
+			name:      "ndjson invalid interval",
+			format:    FormatNDJSON,
+			body:      "{\"date\": \"2024-01-01T00:00:00Z\", \"pair\": \"SOLUSDC\", \"interval\": \"7h\"}",
+			wantField: "/1/interval",
+		},
+		{
+			name:      "csv any column order",
+			format:    FormatCSV,
+			body:      "pair,interval,date,close,open,low,high,volume\nSOLUSDC,1m,2024-01-01T00:00:00Z,2,1,0.5,3,1000\n",
+			wantCount: 1,
+		},
+		{
+			name:      "csv missing column",
+			format:    FormatCSV,
+			body:      "date,pair,interval,open,high,low\n",
+			wantField: "/1/close",
+		},
+		{
+			name:      "csv invalid price",
+			format:    FormatCSV,
+			body:      "date,pair,interval,open,high,low,close\n2024-01-01T00:00:00Z,SOLUSDC,1m,1,3,0.5,2\n2024-01-01T00:01:00Z,SOLUSDC,1m,NaN,3,0.5,2\n",
+			wantCount: 1,
+			wantField: "/3/open",
+		},
+		{
+			name:      "csv missing pair",
+			format:    FormatCSV,
+			body:      "date,pair,interval,open,high,low,close\n2024-01-01T00:00:00Z,,1m,1,3,0.5,2\n",
+			wantField: "/2/pair",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAll(t, tt.format, tt.body)
+			if len(got) != tt.wantCount {
+				t.Errorf("read %d candles, want %d", len(got), tt.wantCount)
+			}
+
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("read error = %v", err)
+				}
+
+				return
+			}
+
+			var appErr *appErrors.AppError
+			if !errors.As(err, &appErr) || !errors.Is(err, appErrors.ErrInvalidInput) || len(appErr.Violations) == 0 || appErr.Violations[0].Field != tt.wantField {
+				t.Errorf("read error = %v, want a violation of %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	rsi := RSI{14: 55.5}
+	want := []Candle{
+		{Date: Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Pair: common.SOLUSDC, Interval: common.M1, Open: 1.25, Close: 2, High: 3, Low: 0.5, RSI: &rsi},
+		{Date: Date(time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)), Pair: common.SOLUSDC, Interval: common.M1, Open: 2, Close: 1e-7, High: 2, Low: 1e-7},
+	}
+
+	for _, format := range []Format{FormatNDJSON, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(format, &buf)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+
+			for _, candle := range want {
+				if err := writer.Write(candle); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			got, err := readAll(t, format, buf.String())
+			if err != nil || len(got) != len(want) {
+				t.Fatalf("read %d candles, error = %v, want %d", len(got), err, len(want))
+			}
+
+			for i := range want {
+				if !time.Time(got[i].Date).Equal(time.Time(want[i].Date)) || got[i].Open != want[i].Open || got[i].Close != want[i].Close || got[i].Low != want[i].Low {
+					t.Errorf("candle %d = %+v, want %+v", i, got[i], want[i])
+				}
+			}
+
+			// Only NDJSON carries the RSI
+			if hasRSI := got[0].RSI != nil; hasRSI != (format == FormatNDJSON) {
+				t.Errorf("candle RSI = %v with %s", got[0].RSI, format)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+)
+
+// Outcome is the outcome of an item of a batch create
+type Outcome string
+
+const (
+	OutcomeCreated   Outcome = "created"
+	OutcomeDuplicate Outcome = "duplicate"
+	OutcomeInvalid   Outcome = "invalid"
+)
+
+// ItemResult is the outcome of the item at Index of a batch create
+// ID is the one of the created item, or of the existing item for a duplicate
+type ItemResult[ID any] struct {
+	Index      int                        `json:"index"`
+	Outcome    Outcome                    `json:"outcome"`
+	ID         *ID                        `json:"id,omitempty"`
+	Violations []appErrors.FieldViolation `json:"violations,omitempty"`
+}
+
+// CountOutcome returns the number of results with the outcome
+func CountOutcome[ID any](results []ItemResult[ID], outcome Outcome) int {
+	count := 0
+	for _, res := range results {
+		if res.Outcome == outcome {
+			count++
+		}
+	}
+
+	return count
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -27,7 +28,6 @@ const (
 	NA  Interval = "NA"
 )
 
-
 var Intervals = []Interval{
 	// S1,
 	M1,
@@ -46,7 +46,6 @@ var Intervals = []Interval{
 	NA,
 }
 
-
 func (i Interval) RoundDateToBeginingOfInterval(currentTime time.Time) *time.Time {
 	var newTime time.Time
 
@@ -152,6 +151,21 @@ func ParseInterval(arg string) (Interval, error) {
 	}
 }
 
+// ParseExchangeInterval returns the interval of an exchange one, eg. the 1h, 1H or 1d of the Binance klines
+// The 1M month interval of Binance is not a candle interval, neither is NA
+func ParseExchangeInterval(arg string) (Interval, error) {
+	if arg == "1M" {
+		return "", fmt.Errorf("monthly interval %q is not supported", arg)
+	}
+
+	interval := Interval(strings.ToLower(strings.TrimSpace(arg)))
+	if interval == NA || !interval.IsValid() {
+		return "", fmt.Errorf("wrong interval: %q", arg)
+	}
+
+	return interval, nil
+}
+
 func ParseIntervals(argsInterval []string) ([]Interval, error) {
 	intervals := make([]Interval, len(argsInterval))
 	errors := []string{}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Pair string
@@ -79,6 +80,13 @@ func ParsePair(arg string) (Pair, error) {
 	}
 }
 
+var symbolSeparators = strings.NewReplacer("/", "", "-", "", "_", "")
+
+// ParseSymbol returns the pair of an exchange symbol, eg. BTCUSDC, btc-usdc or BTC/USDC
+func ParseSymbol(symbol string) (Pair, error) {
+	return ParsePair(symbolSeparators.Replace(strings.ToUpper(strings.TrimSpace(symbol))))
+}
+
 func ParsePairs(argsPair []string) ([]Pair, error) {
 	pairs := make([]Pair, len(argsPair))
 	errors := []string{}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MergeParams overrides the default params of a strategy with the provided ones, the params not provided keep their default value
+// It is shared by the buy signals detectors and the positions generators, so both read their params the same way
+func MergeParams[T any](params map[string]any, defaults *T) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	defaultBytes, err := json.Marshal(defaults)
+	if err != nil {
+		return fmt.Errorf("unable to marshal default params: %w", err)
+	}
+
+	merged := map[string]any{}
+	if err := json.Unmarshal(defaultBytes, &merged); err != nil {
+		return fmt.Errorf("unable to unmarshal default params: %w", err)
+	}
+
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	mergedBytes, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("unable to marshal params: %w", err)
+	}
+
+	var res T
+	if err := json.Unmarshal(mergedBytes, &res); err != nil {
+		return fmt.Errorf("unable to unmarshal params: %w", err)
+	}
+
+	*defaults = res
+	return nil
+}
+
+// BuildFullname formats a strategy name with its params in a stable order
+// eg. morningStar_b0.6_s0.3, percentage_tp2
+func BuildFullname(name string, params ...string) string {
+	return strings.Join(append([]string{name}, params...), "_")
+}
+
+// FormatParam formats a float param of a fullname with its prefix, eg. tp2.5
+func FormatParam(prefix string, value float64) string {
+	return prefix + strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestMergeParams(t *testing.T) {
+	type params struct {
+		Level    float64 `json:"level"`
+		Lookback int     `json:"lookback"`
+	}
+
+	defaults := params{Level: 1.618, Lookback: 10}
+	tests := []struct {
+		name    string
+		params  map[string]any
+		want    params
+		wantErr bool
+	}{
+		{name: "no params keeps the defaults", want: defaults},
+		{name: "partial params", params: map[string]any{"lookback": 20}, want: params{Level: 1.618, Lookback: 20}},
+		{name: "unknown params are ignored", params: map[string]any{"other": 1}, want: defaults},
+		{name: "invalid param type", params: map[string]any{"level": "high"}, want: defaults, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaults
+			if err := MergeParams(tt.params, &got); (err != nil) != tt.wantErr {
+				t.Fatalf("MergeParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("MergeParams() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFullname(t *testing.T) {
+	if got := BuildFullname("fibonacci", FormatParam("l", 0.618), "lb20"); got != "fibonacci_l0.618_lb20" {
+		t.Errorf("BuildFullname() = %s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package experiments
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+)
+
+const LoggerKeyID = "experiment_id"
+
+// MaxParamSets bounds the count of param sets a single ParamRanges can expand to
+const MaxParamSets = 1000
+
+type ID uuid.UUID
+
+func (i ID) String() string {
+	return uuid.UUID(i).String()
+}
+
+func (i ID) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%s"`, uuid.UUID(i).String())), nil
+}
+
+func (i *ID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*i = ID(parsed)
+	return nil
+}
+
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// ErrorInterrupted is the error of an experiment whose run was cut by a shutdown, or by a crash
+const ErrorInterrupted = "interrupted by shutdown"
+
+type Experiment struct {
+	ID         *ID        `json:"id,omitempty"`
+	Status     Status     `json:"status"`
+	Request    Request    `json:"request"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Results are ranked by expectancy
+	Results []Result `json:"results"`
+}
+
+// Request is a scenario grid, see positions.GetScenarios, and the params to try for each strategy
+type Request struct {
+	Pairs           []common.Pair                   `json:"pairs"`
+	Intervals       []common.Interval               `json:"intervals"`
+	BuySignalNames  []buySignals.Name               `json:"buy_signal_names"`
+	PositionNames   []positions.Name                `json:"position_names"`
+	BuySignalParams map[buySignals.Name]ParamRanges `json:"buy_signal_params,omitempty"`
+	PositionParams  map[positions.Name]ParamRanges  `json:"position_params,omitempty"`
+	WinLossRatios   []positions.WinLossRatio        `json:"winloss_ratios,omitempty"`
+	StartDate       *time.Time                      `json:"start_date,omitempty"`
+	EndDate         *time.Time                      `json:"end_date,omitempty"`
+}
+
+// Result is the performance of one strategy combination
+type Result struct {
+	Rank              int                     `json:"rank"`
+	Pair              common.Pair             `json:"pair"`
+	Interval          common.Interval         `json:"interval"`
+	BuySignalFullname buySignals.Fullname     `json:"buy_signal_fullname"`
+	PositionFullname  positions.Fullname      `json:"position_fullname"`
+	WinlossRatio      *positions.WinLossRatio `json:"winloss_ratio,omitempty"`
+	positions.Performance
+}
+
+// RankResults sorts results by expectancy, then by trades count, and sets their rank
+// Results without trade are ranked last
+func RankResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if (a.Trades == 0) != (b.Trades == 0) {
+			return a.Trades > 0
+		}
+
+		if a.Expectancy != b.Expectancy {
+			return a.Expectancy > b.Expectancy
+		}
+
+		return a.Trades > b.Trades
+	})
+
+	for i := range results {
+		results[i].Rank = i + 1
+	}
+}
+
+// ParamRange is an inclusive range of values
+// Values takes precedence over From, To and Step
+type ParamRange struct {
+	Values []any   `json:"values,omitempty"`
+	From   float64 `json:"from,omitempty"`
+	To     float64 `json:"to,omitempty"`
+	Step   float64 `json:"step,omitempty"`
+}
+
+func (r ParamRange) expand() ([]any, error) {
+	if len(r.Values) > 0 {
+		return r.Values, nil
+	}
+
+	if r.Step <= 0 {
+		return nil, fmt.Errorf("step should be greater than 0: %v", r.Step)
+	}
+
+	if r.To < r.From {
+		return nil, fmt.Errorf("to should be greater than from: %v < %v", r.To, r.From)
+	}
+
+	count := int(math.Floor((r.To-r.From)/r.Step+1e-9)) + 1
+	if count > MaxParamSets {
+		return nil, fmt.Errorf("too many values in range: %d > %d", count, MaxParamSets)
+	}
+
+	values := make([]any, count)
+	for i := range values {
+		// Round to avoid float accumulation errors in fullnames, eg. 0.30000000000000004
+		values[i] = math.Round((r.From+float64(i)*r.Step)*1e9) / 1e9
+	}
+
+	return values, nil
+}
+
+// ParamRanges maps a strategy param name to the values to try
+type ParamRanges map[string]ParamRange
+
+// Expand returns the cartesian product of all param values
+// An empty ParamRanges expands to a single empty param set, using the strategy default params
+func (p ParamRanges) Expand() ([]map[string]any, error) {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := []map[string]any{{}}
+	for _, k := range keys {
+		values, err := p[k].expand()
+		if err != nil {
+			return nil, fmt.Errorf("invalid range for param %q: %w", k, err)
+		}
+
+		if len(res)*len(values) > MaxParamSets {
+			return nil, fmt.Errorf("too many param sets: more than %d", MaxParamSets)
+		}
+
+		next := make([]map[string]any, 0, len(res)*len(values))
+		for _, set := range res {
+			for _, v := range values {
+				newSet := make(map[string]any, len(set)+1)
+				for sk, sv := range set {
+					newSet[sk] = sv
+				}
+				newSet[k] = v
+				next = append(next, newSet)
+			}
+		}
+
+		res = next
+	}
+
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package experiments
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+)
+
+func TestParamRanges_Expand(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  ParamRanges
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:   "no ranges uses default params",
+			ranges: nil,
+			want:   []map[string]any{{}},
+		},
+		{
+			name: "range and values",
+			ranges: ParamRanges{
+				"tp_percent": {From: 0.1, To: 0.3, Step: 0.1},
+				"lookback":   {Values: []any{10, 20}},
+			},
+			want: []map[string]any{
+				{"lookback": 10, "tp_percent": 0.1},
+				{"lookback": 10, "tp_percent": 0.2},
+				{"lookback": 10, "tp_percent": 0.3},
+				{"lookback": 20, "tp_percent": 0.1},
+				{"lookback": 20, "tp_percent": 0.2},
+				{"lookback": 20, "tp_percent": 0.3},
+			},
+		},
+		{
+			name:    "invalid step",
+			ranges:  ParamRanges{"tp_percent": {From: 1, To: 2}},
+			wantErr: true,
+		},
+		{
+			name:    "too many param sets",
+			ranges:  ParamRanges{"a": {From: 0, To: 100, Step: 1}, "b": {From: 0, To: 100, Step: 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ranges.Expand()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankResults(t *testing.T) {
+	results := []Result{
+		{PositionFullname: "no_trade"},
+		{PositionFullname: "low", Performance: positions.Performance{Trades: 3, Expectancy: 0.01}},
+		{PositionFullname: "high", Performance: positions.Performance{Trades: 1, Expectancy: 0.05}},
+		{PositionFullname: "negative", Performance: positions.Performance{Trades: 5, Expectancy: -0.02}},
+	}
+
+	RankResults(results)
+
+	want := []positions.Fullname{"high", "low", "negative", "no_trade"}
+	for i, r := range results {
+		if r.PositionFullname != want[i] || r.Rank != i+1 {
+			t.Errorf("RankResults()[%d] = %s rank %d, want %s rank %d", i, r.PositionFullname, r.Rank, want[i], i+1)
+		}
+	}
+}
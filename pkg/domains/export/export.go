@@ -0,0 +1,218 @@
+// Package export encodes the rows of an export as NDJSON, CSV or Parquet, one row at a time
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format is the encoding of an export
+type Format string
+
+const (
+	FormatNDJSON  Format = "ndjson"
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+const (
+	MIMEApplicationNDJSON  = "application/x-ndjson"
+	MIMETextCSV            = "text/csv"
+	MIMEApplicationParquet = "application/vnd.apache.parquet"
+)
+
+// Formats are the formats by media type, in the order of preference of a wildcard
+var Formats = []struct {
+	MediaType string
+	Format    Format
+}{
+	{MIMEApplicationNDJSON, FormatNDJSON},
+	{MIMETextCSV, FormatCSV},
+	{MIMEApplicationParquet, FormatParquet},
+}
+
+func (f Format) MediaType() string {
+	for _, format := range Formats {
+		if format.Format == f {
+			return format.MediaType
+		}
+	}
+
+	return ""
+}
+
+// Negotiate returns the format of the media range of an Accept header with the highest quality, NDJSON without Accept header
+// It returns false when no media range matches a format, eg. application/json
+func Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return FormatNDJSON, true
+	}
+
+	var res Format
+	best := 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality <= best {
+			continue
+		}
+
+		for _, format := range Formats {
+			if matches(mediaType, format.MediaType) {
+				res, best = format.Format, quality
+				break
+			}
+		}
+	}
+
+	return res, best > 0
+}
+
+func matches(mediaRange string, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	prefix, found := strings.CutSuffix(mediaRange, "/*")
+	return found && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// Row is an export row, its CSV record is in the order of the columns given to NewWriter
+// Its JSON and Parquet field names are expected to be the columns
+type Row interface {
+	Record() []string
+}
+
+// Writer encodes the rows of an export one at a time
+// Flush writes the buffered rows, eg. to send them to a client, and Close ends the export, eg. with the Parquet footer
+type Writer[T Row] interface {
+	Write(T) error
+	Flush() error
+	Close() error
+}
+
+func NewWriter[T Row](format Format, w io.Writer, columns []string) (Writer[T], error) {
+	switch format {
+	case FormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonWriter[T]{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(columns); err != nil {
+			return nil, fmt.Errorf("unable to write the csv header: %w", err)
+		}
+
+		return &csvWriter[T]{writer: writer}, nil
+	case FormatParquet:
+		return &parquetWriter[T]{writer: parquet.NewGenericWriter[T](w)}, nil
+	}
+
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type ndjsonWriter[T Row] struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *ndjsonWriter[T]) Write(row T) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonWriter[T]) Flush() error {
+	return w.buffered.Flush()
+}
+
+func (w *ndjsonWriter[T]) Close() error {
+	return w.Flush()
+}
+
+type csvWriter[T Row] struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter[T]) Write(row T) error {
+	return w.writer.Write(row.Record())
+}
+
+func (w *csvWriter[T]) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter[T]) Close() error {
+	return w.Flush()
+}
+
+// parquetWriter writes a row group by Flush, the flushes have to be spaced to keep the groups large
+type parquetWriter[T Row] struct {
+	writer *parquet.GenericWriter[T]
+	rows   [1]T
+}
+
+func (w *parquetWriter[T]) Write(row T) error {
+	w.rows[0] = row
+	_, err := w.writer.Write(w.rows[:])
+	return err
+}
+
+func (w *parquetWriter[T]) Flush() error {
+	return w.writer.Flush()
+}
+
+func (w *parquetWriter[T]) Close() error {
+	return w.writer.Close()
+}
+
+// FormatFloat formats a CSV price, without exponent and with the shortest precision
+func FormatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// FormatOptionalFloat formats an optional CSV value, empty when nil
+func FormatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+
+	return FormatFloat(*f)
+}
+
+var jsonNull = json.RawMessage("null")
+
+// JSON returns the JSON document, null when empty, the Parquet JSON columns are required as the optional ones are not read back
+func JSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return jsonNull
+	}
+
+	return raw
+}
+
+// FormatJSON formats a CSV JSON document, empty when null
+func FormatJSON(raw json.RawMessage) string {
+	if len(raw) == 0 || bytes.Equal(raw, jsonNull) {
+		return ""
+	}
+
+	return string(raw)
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   Format
+		wantOK bool
+	}{
+		{accept: "", want: FormatNDJSON, wantOK: true},
+		{accept: "*/*", want: FormatNDJSON, wantOK: true},
+		{accept: "text/csv", want: FormatCSV, wantOK: true},
+		{accept: "text/*", want: FormatCSV, wantOK: true},
+		{accept: "application/vnd.apache.parquet", want: FormatParquet, wantOK: true},
+		{accept: "text/csv;q=0.5, application/vnd.apache.parquet", want: FormatParquet, wantOK: true},
+		{accept: "application/json, text/csv;q=0.1", want: FormatCSV, wantOK: true},
+		{accept: "application/json", wantOK: false},
+		{accept: "text/csv;q=0", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got, ok := Negotiate(tt.accept)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("Negotiate(%q) = %q, %v, want %q, %v", tt.accept, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+type testRow struct {
+	Date  time.Time       `json:"date" parquet:"date,timestamp(millisecond)"`
+	Name  string          `json:"name" parquet:"name,dict"`
+	Value *float64        `json:"value" parquet:"value,optional"`
+	Meta  json.RawMessage `json:"meta" parquet:"meta,json"`
+}
+
+func (r testRow) Record() []string {
+	return []string{r.Date.Format(time.RFC3339), r.Name, FormatOptionalFloat(r.Value), FormatJSON(r.Meta)}
+}
+
+var testColumns = []string{"date", "name", "value", "meta"}
+
+func testRows() []testRow {
+	value := 1e-7
+	return []testRow{
+		{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Name: "a", Value: &value, Meta: json.RawMessage(`{"k":1}`)},
+		{Date: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC), Name: "b", Meta: JSON(nil)},
+	}
+}
+
+func write(t *testing.T, format Format, rows []testRow) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer, err := NewWriter[testRow](format, &buf, testColumns)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		// Flushing each row checks the writer goes on after a flush
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestWriter(t *testing.T) {
+	rows := testRows()
+
+	t.Run("csv", func(t *testing.T) {
+		want := "date,name,value,meta\n2024-01-01T00:00:00Z,a,0.0000001,\"{\"\"k\"\":1}\"\n2024-01-01T00:01:00Z,b,,\n"
+		if got := string(write(t, FormatCSV, rows)); got != want {
+			t.Errorf("csv = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(string(write(t, FormatNDJSON, rows))), "\n")
+		if len(lines) != 2 || lines[1] != `{"date":"2024-01-01T00:01:00Z","name":"b","value":null,"meta":null}` {
+			t.Errorf("ndjson = %q", lines)
+		}
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		data := write(t, FormatParquet, rows)
+		got, err := parquet.Read[testRow](bytes.NewReader(data), int64(len(data)))
+		if err != nil || len(got) != 2 {
+			t.Fatalf("read %d rows, error = %v, want 2", len(got), err)
+		}
+
+		if !got[0].Date.Equal(rows[0].Date) || got[0].Name != "a" || got[0].Value == nil || *got[0].Value != 1e-7 || string(got[0].Meta) != `{"k":1}` {
+			t.Errorf("row 0 = %+v, want %+v", got[0], rows[0])
+		}
+
+		if got[1].Value != nil || string(got[1].Meta) != "null" {
+			t.Errorf("row 1 = %+v, want a nil value and a null meta", got[1])
+		}
+	})
+}
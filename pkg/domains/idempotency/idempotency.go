@@ -0,0 +1,75 @@
+// Package idempotency makes the retries of a write request safe
+// The first response of a key is stored and replayed to the retries with the same key and request
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+// HeaderIdempotencyKey is the request header holding the idempotency key of a write request
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed is set on the responses replayed from a previous request
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+const LoggerKeyIdempotencyKey = "idempotency_key"
+
+const maxKeyLength = 255
+
+// Key is chosen by the client, eg. a UUID per logical request reused by its retries
+type Key string
+
+// ParseKey returns the key if it is made of up to 255 visible ASCII characters
+func ParseKey(s string) (Key, error) {
+	if s == "" || len(s) > maxKeyLength {
+		return "", fmt.Errorf("invalid idempotency key, expected 1 to %d characters", maxKeyLength)
+	}
+
+	for _, r := range s {
+		if r < '!' || r > '~' {
+			return "", fmt.Errorf("invalid idempotency key, expected visible ASCII characters")
+		}
+	}
+
+	return Key(s), nil
+}
+
+// Fingerprint identifies the request of a key, the uri includes the query
+func Fingerprint(method string, uri string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Record is the request of a key and, once completed, its response
+type Record struct {
+	Workspace   workspaces.Workspace
+	Key         Key
+	Fingerprint string
+	// Status is 0 while the request is in progress
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	// Token identifies the reservation of the key, only the request holding it stores its response or releases the key
+	Token uuid.UUID
+	// ExpiresAt ends the replays of a completed request, or the lock of a request in progress
+	ExpiresAt time.Time
+}
+
+func (r Record) IsCompleted() bool {
+	return r.Status != 0
+}
+
+func (r Record) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
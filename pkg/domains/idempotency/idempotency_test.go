@@ -0,0 +1,54 @@
+package idempotency
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "uuid", input: "8e03978e-40d5-43e8-bc93-6894a57f9324"},
+		{name: "visible ascii", input: "worker-1:batch#42"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "space", input: "worker 1", wantErr: true},
+		{name: "non ascii", input: "clé", wantErr: true},
+		{name: "too long", input: strings.Repeat("k", 256), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKey(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && string(got) != tt.input {
+				t.Errorf("ParseKey(%q) = %q", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	body := []byte(`{"positions": []}`)
+	fingerprint := Fingerprint("POST", "/api/v1/positions", body)
+
+	if fingerprint != Fingerprint("POST", "/api/v1/positions", []byte(`{"positions": []}`)) {
+		t.Errorf("Fingerprint() of the same request should be stable")
+	}
+
+	others := []string{
+		Fingerprint("PATCH", "/api/v1/positions", body),
+		Fingerprint("POST", "/api/v1/positions?dry_run=true", body),
+		Fingerprint("POST", "/api/v1/positions", []byte(`{"positions": [{}]}`)),
+	}
+	for i, other := range others {
+		if other == fingerprint {
+			t.Errorf("Fingerprint() of the other request %d should differ", i)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package positions
+
+import (
+	"encoding/json"
+	"time"
+
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/export"
+)
+
+// BuySignalPositionColumns are the columns of a buy signals export, the ones of the v_buy_signals_positions view
+var BuySignalPositionColumns = []string{
+	"pair", "buy_interval", "buy_fullname", "buy_date", "buy_price",
+	"position_fullname", "tp", "sl", "ratio_value", "ratio_date",
+	"buy_metadata", "position_metadata", "buy_id", "position_id",
+}
+
+// BuySignalPosition is a buy signal joined with one of its positions, the position fields are nil for a buy signal without position
+// The metadata are JSON documents, null when missing
+type BuySignalPosition struct {
+	Pair             string          `json:"pair" parquet:"pair,dict"`
+	BuyInterval      string          `json:"buy_interval" parquet:"buy_interval,dict"`
+	BuyFullname      string          `json:"buy_fullname" parquet:"buy_fullname,dict"`
+	BuyDate          time.Time       `json:"buy_date" parquet:"buy_date,timestamp(millisecond)"`
+	BuyPrice         float64         `json:"buy_price" parquet:"buy_price"`
+	PositionFullname *string         `json:"position_fullname" parquet:"position_fullname,optional,dict"`
+	TP               *float64        `json:"tp" parquet:"tp,optional"`
+	SL               *float64        `json:"sl" parquet:"sl,optional"`
+	RatioValue       *float64        `json:"ratio_value" parquet:"ratio_value,optional"`
+	RatioDate        *time.Time      `json:"ratio_date" parquet:"ratio_date,optional"`
+	BuyMetadata      json.RawMessage `json:"buy_metadata" parquet:"buy_metadata,json"`
+	PositionMetadata json.RawMessage `json:"position_metadata" parquet:"position_metadata,json"`
+	BuyID            string          `json:"buy_id" parquet:"buy_id"`
+	PositionID       *string         `json:"position_id" parquet:"position_id,optional"`
+}
+
+func (r BuySignalPosition) Record() []string {
+	record := []string{
+		r.Pair, r.BuyInterval, r.BuyFullname, r.BuyDate.Format(time.RFC3339), export.FormatFloat(r.BuyPrice),
+		"", export.FormatOptionalFloat(r.TP), export.FormatOptionalFloat(r.SL), export.FormatOptionalFloat(r.RatioValue), "",
+		export.FormatJSON(r.BuyMetadata), export.FormatJSON(r.PositionMetadata), r.BuyID, "",
+	}
+
+	if r.PositionFullname != nil {
+		record[5] = *r.PositionFullname
+	}
+
+	if r.RatioDate != nil {
+		record[9] = r.RatioDate.Format(time.RFC3339)
+	}
+
+	if r.PositionID != nil {
+		record[13] = *r.PositionID
+	}
+
+	return record
+}
+
+// ExportFilter selects the buy signals of an export, the empty fields select all, the dates bound the buy date
+type ExportFilter struct {
+	Pair             common.Pair
+	Interval         common.Interval
+	BuyFullname      buySignals.Fullname
+	PositionFullname Fullname
+	StartDate        *time.Time
+	EndDate          *time.Time
+}
@@ -0,0 +1,79 @@
+package positions
+
+import (
+	"fmt"
+	"strconv"
+
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// FibonacciParams configure a TP placed on a fibonacci level of the last swing
+// The swing is the lowest low and the highest high of the Lookback candles preceding the buy signal
+type FibonacciParams struct {
+	// Level is applied from the swing low, eg. 0.618 is a retracement, 1.618 an extension
+	Level    float64 `json:"level"`
+	Lookback int     `json:"lookback"`
+}
+
+var DefaultFibonacciParams = FibonacciParams{
+	Level:    1.618,
+	Lookback: 50,
+}
+
+type fibonacciGenerator struct {
+	params FibonacciParams
+}
+
+func NewFibonacciGenerator(params FibonacciParams) (Generator, error) {
+	if params.Level <= 0 {
+		return nil, fmt.Errorf("level should be greater than 0: %v", params.Level)
+	}
+
+	if params.Lookback <= 1 {
+		return nil, fmt.Errorf("lookback should be greater than 1: %v", params.Lookback)
+	}
+
+	return &fibonacciGenerator{params: params}, nil
+}
+
+func (g *fibonacciGenerator) Name() Name {
+	return FibonacciName
+}
+
+func (g *fibonacciGenerator) Fullname() Fullname {
+	return Fullname(common.BuildFullname(string(FibonacciName), common.FormatParam("l", g.params.Level), "lb"+strconv.Itoa(g.params.Lookback)))
+}
+
+func (g *fibonacciGenerator) Lookback() int {
+	return g.params.Lookback
+}
+
+// Generate returns no position when the fibonacci level is not above the buy price
+func (g *fibonacciGenerator) Generate(bs buySignals.Details, history []candles.Candle, ratios []WinLossRatio) ([]Details, error) {
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no candles found before the buy signal to compute the swing")
+	}
+
+	swingLow, swingHigh := history[0], history[0]
+	for _, c := range history {
+		if c.Low < swingLow.Low {
+			swingLow = c
+		}
+
+		if c.High > swingHigh.High {
+			swingHigh = c
+		}
+	}
+
+	tp := swingLow.Low + (swingHigh.High-swingLow.Low)*g.params.Level
+
+	metadata := paramsToMetadata(g.params)
+	metadata["swing_low"] = swingLow.Low
+	metadata["swing_low_date"] = swingLow.Date.String()
+	metadata["swing_high"] = swingHigh.High
+	metadata["swing_high_date"] = swingHigh.Date.String()
+
+	return newPositions(g, bs, tp, ratios, metadata), nil
+}
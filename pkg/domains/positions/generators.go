@@ -0,0 +1,113 @@
+package positions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// Generator computes the positions of a buy signal, one per win/loss ratio
+// The TP is given by the strategy, the SL is computed from the TP using the win/loss ratio
+type Generator interface {
+	Name() Name
+	// Fullname is the strategy name with its params, it is shared by all generated positions
+	Fullname() Fullname
+	// Lookback is the count of candles preceding the buy signal required to generate positions
+	Lookback() int
+	Generate(bs buySignals.Details, history []candles.Candle, ratios []WinLossRatio) ([]Details, error)
+}
+
+// NewGenerator builds the generator of the given strategy name
+// Params not provided in the metadata keep their default values
+func NewGenerator(name Name, params Metadata) (Generator, error) {
+	switch name {
+	case PercentageName:
+		p := DefaultPercentageParams
+		if err := common.MergeParams(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid generator params: %w", err)
+		}
+
+		return NewPercentageGenerator(p)
+	case FibonacciName:
+		p := DefaultFibonacciParams
+		if err := common.MergeParams(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid generator params: %w", err)
+		}
+
+		return NewFibonacciGenerator(p)
+	}
+
+	return nil, fmt.Errorf("no generator available for position strategy: %q", name)
+}
+
+// ParseWinLossRatios ensures the ratios are available ones, it defaults to AvailableWinLossRatios
+func ParseWinLossRatios(ratios []WinLossRatio) ([]WinLossRatio, error) {
+	if len(ratios) == 0 {
+		return AvailableWinLossRatios, nil
+	}
+
+	errors := []string{}
+	for _, r := range ratios {
+		found := false
+		for _, available := range AvailableWinLossRatios {
+			if math.Abs(float64(r-available)) < 1e-9 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errors = append(errors, strconv.FormatFloat(float64(r), 'f', -1, 64))
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("winloss ratios not allowed: %s", errors)
+	}
+
+	return ratios, nil
+}
+
+// newPositions builds one position per ratio with a positive stoploss
+func newPositions(g Generator, bs buySignals.Details, tp float64, ratios []WinLossRatio, metadata Metadata) []Details {
+	res := make([]Details, 0, len(ratios))
+	if bs.ID == nil || tp <= bs.Price {
+		return res
+	}
+
+	for _, ratio := range ratios {
+		sl := ratio.ComputeStoploss(bs.Price, tp)
+		if sl <= 0 {
+			continue
+		}
+
+		r := ratio
+		res = append(res, Details{
+			Name:         g.Name(),
+			Fullname:     g.Fullname(),
+			BuySignalID:  *bs.ID,
+			TP:           tp,
+			SL:           sl,
+			Metadata:     metadata,
+			WinlossRatio: &r,
+		})
+	}
+
+	return res
+}
+
+func paramsToMetadata(params any) Metadata {
+	metadata := Metadata{}
+	paramsBytes, err := json.Marshal(params)
+	if err != nil {
+		return metadata
+	}
+
+	_ = json.Unmarshal(paramsBytes, &metadata)
+	return metadata
+}
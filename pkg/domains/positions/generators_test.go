@@ -0,0 +1,116 @@
+package positions
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+)
+
+func TestGenerator_Generate(t *testing.T) {
+	bsID := buySignals.ID(uuid.New())
+	bs := buySignals.Details{
+		ID:    &bsID,
+		Date:  buySignals.Date(time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)),
+		Price: 100,
+	}
+
+	history := []candles.Candle{
+		{High: 110, Low: 95},
+		{High: 120, Low: 90},
+		{High: 105, Low: 98},
+	}
+
+	tests := []struct {
+		name         string
+		strategy     Name
+		params       Metadata
+		ratios       []WinLossRatio
+		wantFullname Fullname
+		wantTP       float64
+		wantSLs      []float64
+	}{
+		{
+			name:         "percentage default params",
+			strategy:     PercentageName,
+			ratios:       []WinLossRatio{R11, R12},
+			wantFullname: "percentage_tp5",
+			wantTP:       105,
+			wantSLs:      []float64{95, 97.5},
+		},
+		{
+			name:         "fibonacci extension",
+			strategy:     FibonacciName,
+			params:       Metadata{"level": 1.5, "lookback": 3},
+			ratios:       []WinLossRatio{R12},
+			wantFullname: "fibonacci_l1.5_lb3",
+			wantTP:       135,
+			wantSLs:      []float64{82.5},
+		},
+		{
+			name:         "fibonacci level below the buy price",
+			strategy:     FibonacciName,
+			params:       Metadata{"level": 0.236, "lookback": 3},
+			ratios:       []WinLossRatio{R11},
+			wantFullname: "fibonacci_l0.236_lb3",
+		},
+		{
+			name:         "stoploss below zero is skipped",
+			strategy:     PercentageName,
+			params:       Metadata{"tp_percent": 50},
+			ratios:       []WinLossRatio{R11, R31},
+			wantFullname: "percentage_tp50",
+			wantTP:       150,
+			wantSLs:      []float64{50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGenerator(tt.strategy, tt.params)
+			if err != nil {
+				t.Fatalf("NewGenerator() error = %v", err)
+			}
+
+			if g.Fullname() != tt.wantFullname {
+				t.Errorf("Fullname() = %v, want %v", g.Fullname(), tt.wantFullname)
+			}
+
+			got, err := g.Generate(bs, history, tt.ratios)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			if len(got) != len(tt.wantSLs) {
+				t.Fatalf("Generate() returned %d positions, want %d", len(got), len(tt.wantSLs))
+			}
+
+			for i, pos := range got {
+				if math.Abs(pos.TP-tt.wantTP) > 1e-9 {
+					t.Errorf("Generate()[%d].TP = %v, want %v", i, pos.TP, tt.wantTP)
+				}
+
+				if math.Abs(pos.SL-tt.wantSLs[i]) > 1e-9 {
+					t.Errorf("Generate()[%d].SL = %v, want %v", i, pos.SL, tt.wantSLs[i])
+				}
+
+				if pos.Fullname != tt.wantFullname || pos.BuySignalID != bsID {
+					t.Errorf("Generate()[%d] = %+v", i, pos)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWinLossRatios(t *testing.T) {
+	if got, err := ParseWinLossRatios(nil); err != nil || len(got) != len(AvailableWinLossRatios) {
+		t.Errorf("ParseWinLossRatios(nil) = %v, %v", got, err)
+	}
+
+	if _, err := ParseWinLossRatios([]WinLossRatio{R12, 0.7}); err == nil {
+		t.Errorf("ParseWinLossRatios() expected an error for an unavailable ratio")
+	}
+}
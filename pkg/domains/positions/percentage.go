@@ -0,0 +1,47 @@
+package positions
+
+import (
+	"fmt"
+
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// PercentageParams configure a TP placed at a fixed percentage above the buy price
+type PercentageParams struct {
+	TPPercent float64 `json:"tp_percent"`
+}
+
+var DefaultPercentageParams = PercentageParams{
+	TPPercent: 5,
+}
+
+type percentageGenerator struct {
+	params PercentageParams
+}
+
+func NewPercentageGenerator(params PercentageParams) (Generator, error) {
+	if params.TPPercent <= 0 {
+		return nil, fmt.Errorf("tp_percent should be greater than 0: %v", params.TPPercent)
+	}
+
+	return &percentageGenerator{params: params}, nil
+}
+
+func (g *percentageGenerator) Name() Name {
+	return PercentageName
+}
+
+func (g *percentageGenerator) Fullname() Fullname {
+	return Fullname(common.BuildFullname(string(PercentageName), common.FormatParam("tp", g.params.TPPercent)))
+}
+
+func (g *percentageGenerator) Lookback() int {
+	return 0
+}
+
+func (g *percentageGenerator) Generate(bs buySignals.Details, _ []candles.Candle, ratios []WinLossRatio) ([]Details, error) {
+	tp := bs.Price * (1 + g.params.TPPercent/100)
+	return newPositions(g, bs, tp, ratios, paramsToMetadata(g.params)), nil
+}
@@ -0,0 +1,82 @@
+package positions
+
+import "math"
+
+// Performance summarizes the closed positions of a strategy
+// Returns are computed from the ratio values, a ratio of 1.05 is a 5% win
+type Performance struct {
+	Trades int `json:"trades"`
+	// Open is the count of positions that hit neither the TP nor the SL
+	Open    int     `json:"open"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	WinRate float64 `json:"win_rate"`
+	AvgWin  float64 `json:"avg_win"`
+	AvgLoss float64 `json:"avg_loss"`
+	// Expectancy is the average return per trade
+	Expectancy float64 `json:"expectancy"`
+	// TotalReturn is the compounded return of all trades taken in sequence
+	TotalReturn float64 `json:"total_return"`
+	// ProfitFactor is the sum of wins over the sum of losses, it is 0 when there is no loss
+	ProfitFactor float64 `json:"profit_factor"`
+}
+
+// ComputePerformance computes the performance of the given positions, in the given order
+func ComputePerformance(positions []Details) Performance {
+	ratios := make([]float64, 0, len(positions))
+	open := 0
+	for _, p := range positions {
+		if p.Ratio == nil || p.Ratio.Value == 0 {
+			open++
+			continue
+		}
+
+		ratios = append(ratios, p.Ratio.Value)
+	}
+
+	perf := ComputeRatiosPerformance(ratios)
+	perf.Open = open
+	return perf
+}
+
+// ComputeRatiosPerformance computes the performance of a ratio values series
+func ComputeRatiosPerformance(ratios []float64) Performance {
+	perf := Performance{Trades: len(ratios)}
+	if len(ratios) == 0 {
+		return perf
+	}
+
+	var sumReturns, sumWins, sumLosses float64
+	compounded := 1.0
+	for _, r := range ratios {
+		ret := r - 1
+		sumReturns += ret
+		compounded *= r
+
+		if ret > 0 {
+			perf.Wins++
+			sumWins += ret
+		} else {
+			perf.Losses++
+			sumLosses += ret
+		}
+	}
+
+	perf.WinRate = float64(perf.Wins) / float64(perf.Trades)
+	perf.Expectancy = sumReturns / float64(perf.Trades)
+	perf.TotalReturn = compounded - 1
+
+	if perf.Wins > 0 {
+		perf.AvgWin = sumWins / float64(perf.Wins)
+	}
+
+	if perf.Losses > 0 {
+		perf.AvgLoss = sumLosses / float64(perf.Losses)
+	}
+
+	if sumLosses < 0 {
+		perf.ProfitFactor = sumWins / math.Abs(sumLosses)
+	}
+
+	return perf
+}
@@ -0,0 +1,80 @@
+package positions
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeRatiosPerformance(t *testing.T) {
+	tests := []struct {
+		name   string
+		ratios []float64
+		want   Performance
+	}{
+		{
+			name:   "no trades",
+			ratios: nil,
+			want:   Performance{},
+		},
+		{
+			name:   "wins and losses",
+			ratios: []float64{1.1, 0.95, 1.1, 0.95},
+			want: Performance{
+				Trades:       4,
+				Wins:         2,
+				Losses:       2,
+				WinRate:      0.5,
+				AvgWin:       0.1,
+				AvgLoss:      -0.05,
+				Expectancy:   0.025,
+				TotalReturn:  1.1*0.95*1.1*0.95 - 1,
+				ProfitFactor: 2,
+			},
+		},
+		{
+			name:   "only wins",
+			ratios: []float64{1.2},
+			want: Performance{
+				Trades:      1,
+				Wins:        1,
+				WinRate:     1,
+				AvgWin:      0.2,
+				Expectancy:  0.2,
+				TotalReturn: 0.2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeRatiosPerformance(tt.ratios)
+			if got.Trades != tt.want.Trades || got.Wins != tt.want.Wins || got.Losses != tt.want.Losses {
+				t.Fatalf("ComputeRatiosPerformance() = %+v, want %+v", got, tt.want)
+			}
+
+			for name, values := range map[string][2]float64{
+				"WinRate":      {got.WinRate, tt.want.WinRate},
+				"AvgWin":       {got.AvgWin, tt.want.AvgWin},
+				"AvgLoss":      {got.AvgLoss, tt.want.AvgLoss},
+				"Expectancy":   {got.Expectancy, tt.want.Expectancy},
+				"TotalReturn":  {got.TotalReturn, tt.want.TotalReturn},
+				"ProfitFactor": {got.ProfitFactor, tt.want.ProfitFactor},
+			} {
+				if math.Abs(values[0]-values[1]) > 1e-9 {
+					t.Errorf("ComputeRatiosPerformance().%s = %v, want %v", name, values[0], values[1])
+				}
+			}
+		})
+	}
+}
+
+func TestComputePerformance_CountsOpenPositions(t *testing.T) {
+	got := ComputePerformance([]Details{
+		{Ratio: &Ratio{Value: 1.1}},
+		{},
+	})
+
+	if got.Trades != 1 || got.Open != 1 {
+		t.Errorf("ComputePerformance() = %+v, want 1 trade and 1 open position", got)
+	}
+}
@@ -24,6 +24,8 @@ type Details struct {
 	SL          float64             `json:"sl"`
 	Metadata    map[string]any      `json:"metadata"`
 	Ratio       *Ratio              `json:"ratio,omitempty"`
+	// Stale is a ratio computed on candles corrected since, it is computed again as a missing ratio
+	Stale bool `json:"stale,omitempty"`
 	// WinLoss ratio is used to compute the stoploss
 	// On specific needs, if can be nil if stoploss is manually added
 	WinlossRatio *WinLossRatio `json:"winloss_ratio,omitempty"`
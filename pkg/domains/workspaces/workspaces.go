@@ -0,0 +1,45 @@
+// Package workspaces isolates the buy signals and positions of the teams sharing one instance
+// The workspace is the one of the authenticated API key, it is carried by the context down to the persistences
+package workspaces
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Workspace is the tenant of the buy signals, positions, experiments and walk-forwards, candles are shared
+type Workspace string
+
+// Default is the workspace of the requests without API key, when the authentication is disabled
+const Default Workspace = "default"
+
+const LoggerKeyWorkspace = "workspace"
+
+var workspaceRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Parse returns the workspace if it is a lowercase slug of up to 63 characters
+func Parse(s string) (Workspace, error) {
+	if !workspaceRegexp.MatchString(s) {
+		return "", fmt.Errorf("invalid workspace %q, expected lowercase letters, digits, '-' or '_'", s)
+	}
+
+	return Workspace(s), nil
+}
+
+type ctxKey struct{}
+
+var workspaceCtxKey = &ctxKey{}
+
+func SetWorkspaceToContext(ctx context.Context, workspace Workspace) context.Context {
+	return context.WithValue(ctx, workspaceCtxKey, workspace)
+}
+
+// GetWorkspaceFromContext returns the Default workspace if none is set
+func GetWorkspaceFromContext(ctx context.Context) Workspace {
+	if workspace, ok := ctx.Value(workspaceCtxKey).(Workspace); ok && workspace != "" {
+		return workspace
+	}
+
+	return Default
+}
@@ -0,0 +1,46 @@
+package workspaces
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "default", input: "default"},
+		{name: "slug", input: "team-a_2"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "uppercase", input: "TeamA", wantErr: true},
+		{name: "leading dash", input: "-team", wantErr: true},
+		{name: "space", input: "team a", wantErr: true},
+		{name: "too long", input: "a123456789012345678901234567890123456789012345678901234567890123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && string(got) != tt.input {
+				t.Errorf("Parse(%q) = %q", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestWorkspaceContext(t *testing.T) {
+	if got := GetWorkspaceFromContext(context.Background()); got != Default {
+		t.Errorf("GetWorkspaceFromContext() = %q, want %q", got, Default)
+	}
+
+	ctx := SetWorkspaceToContext(context.Background(), "team-a")
+	if got := GetWorkspaceFromContext(ctx); got != "team-a" {
+		t.Errorf("GetWorkspaceFromContext() = %q, want team-a", got)
+	}
+}
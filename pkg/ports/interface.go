@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"iter"
 	"time"
 
 	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
@@ -27,6 +28,11 @@ type Candles interface {
 	// The candle list is chunked by specified size or defaultChunckSize if set to <= 0
 	CreateCandles(ctx context.Context, candles *[]candles.Candle, chunckSize int) (*[]candles.Candle, error)
 
+	// IngestCandles bulk loads the candles of the sequence, the existing ones are skipped
+	// The HTTP client streams them as NDJSON in a single request, so the sequence is not held in memory
+	// The sequence stops the ingestion on its first error, the candles loaded before it are kept
+	IngestCandles(ctx context.Context, candles iter.Seq2[candles.Candle, error]) (*candles.IngestResult, error)
+
 	// GetCandles returns candles for a given pair and interval
 	// Use startDate and endDate to filter candles by date
 	// If limit param is > 0 use it as max candle count ot return, else use default sdk limit value
@@ -37,6 +43,11 @@ type Candles interface {
 	GetCandlesMinuteClosePriceByDate(ctx context.Context, prices PriceRequest) (*PriceResponse, error)
 	// GetCandlesByLastDate reverse the cursor, the next_cursor has to be used as last_date argument
 	GetCandlesByLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate candles.Date, limit uint) (res *[]candles.Candle, hasMore bool, nextCursor *time.Time, err error)
+	// AllCandles iterates over the candles from startDate, oldest first, fetching the pages on demand
+	// The iteration stops on the first error, eg. a cancelled context
+	AllCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, opts IterOptions) iter.Seq2[candles.Candle, error]
+	// AllCandlesByLastDate iterates over the candles up to lastDate, newest first, fetching the pages on demand
+	AllCandlesByLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate candles.Date, opts IterOptions) iter.Seq2[candles.Candle, error]
 	// GetCandlesByDate returns candles for a given pair and interval and date
 	// UpdateCandleListRSI updates only the RSI for a list of candles
 	// It returns the updated candles
@@ -49,7 +60,9 @@ type Candles interface {
 
 type BuySignals interface {
 	CreateBuySignals(ctx context.Context, buySignal *[]bsDomain.Details) (*[]bsDomain.Details, error)
-	GetBuySignals(context.Context, common.Pair, common.Interval, bsDomain.Name, *time.Time) (res *[]bsDomain.Details, hasMore bool, nextCursor *time.Time, err error)
+	GetBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name bsDomain.Name, firstDate *time.Time, limit uint) (res *[]bsDomain.Details, hasMore bool, nextCursor *time.Time, err error)
+	// AllBuySignals iterates over the buy signals from firstDate, oldest first, fetching the pages on demand
+	AllBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name bsDomain.Name, firstDate *time.Time, opts IterOptions) iter.Seq2[bsDomain.Details, error]
 }
 
 type Positions interface {
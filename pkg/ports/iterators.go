@@ -0,0 +1,217 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+)
+
+// IterOptions configures the pagination iterators
+type IterOptions struct {
+	// PageSize is the limit of each request, the client default limit is used if 0
+	PageSize uint
+	// Prefetch is the count of pages fetched in background while the current one is consumed, 0 disables it
+	Prefetch int
+}
+
+// fetchPage returns the page starting at the cursor, nil is the first page
+type fetchPage[T any] func(ctx context.Context, cursor *time.Time) (items []T, hasMore bool, nextCursor *time.Time, err error)
+
+type page[T any] struct {
+	items []T
+	err   error
+}
+
+// CandlesSeq iterates over all the candles from startDate, oldest first
+func CandlesSeq(ctx context.Context, client Candles, pair common.Pair, interval common.Interval, startDate *time.Time, opts IterOptions) iter.Seq2[candles.Candle, error] {
+	fetch := func(ctx context.Context, cursor *time.Time) ([]candles.Candle, bool, *time.Time, error) {
+		if cursor == nil {
+			cursor = startDate
+		}
+
+		res, hasMore, nextCursor, err := client.GetCandles(ctx, pair, interval, cursor, opts.PageSize)
+		if err != nil || res == nil {
+			return nil, hasMore, nextCursor, err
+		}
+
+		return *res, hasMore, nextCursor, nil
+	}
+
+	return paginate(ctx, fetch, candleKey, false, opts.Prefetch)
+}
+
+// CandlesByLastDateSeq iterates over all the candles up to lastDate, newest first
+func CandlesByLastDateSeq(ctx context.Context, client Candles, pair common.Pair, interval common.Interval, lastDate candles.Date, opts IterOptions) iter.Seq2[candles.Candle, error] {
+	fetch := func(ctx context.Context, cursor *time.Time) ([]candles.Candle, bool, *time.Time, error) {
+		date := lastDate
+		if cursor != nil {
+			date = candles.Date(*cursor)
+		}
+
+		res, hasMore, nextCursor, err := client.GetCandlesByLastDate(ctx, pair, interval, date, opts.PageSize)
+		if err != nil || res == nil {
+			return nil, hasMore, nextCursor, err
+		}
+
+		// Pages are sorted by date, the iterator goes backward
+		items := slices.Clone(*res)
+		slices.Reverse(items)
+		return items, hasMore, nextCursor, nil
+	}
+
+	return paginate(ctx, fetch, candleKey, true, opts.Prefetch)
+}
+
+// BuySignalsSeq iterates over all the buy signals from firstDate, oldest first
+func BuySignalsSeq(ctx context.Context, client BuySignals, pair common.Pair, interval common.Interval, name bsDomain.Name, firstDate *time.Time, opts IterOptions) iter.Seq2[bsDomain.Details, error] {
+	fetch := func(ctx context.Context, cursor *time.Time) ([]bsDomain.Details, bool, *time.Time, error) {
+		if cursor == nil {
+			cursor = firstDate
+		}
+
+		res, hasMore, nextCursor, err := client.GetBuySignals(ctx, pair, interval, name, cursor, opts.PageSize)
+		if err != nil || res == nil {
+			return nil, hasMore, nextCursor, err
+		}
+
+		return *res, hasMore, nextCursor, nil
+	}
+
+	return paginate(ctx, fetch, buySignalKey, false, opts.Prefetch)
+}
+
+// candleKey identifies a candle, a pair and interval have one candle per date
+func candleKey(c candles.Candle) (time.Time, string) {
+	return time.Time(c.Date), ""
+}
+
+// buySignalKey identifies a buy signal, many buy signals can share the same date
+func buySignalKey(bs bsDomain.Details) (time.Time, string) {
+	if bs.ID == nil {
+		return time.Time(bs.Date), ""
+	}
+
+	return time.Time(bs.Date), bs.ID.String()
+}
+
+// paginate flattens the pages and skips the items already yielded
+// The cursor is inclusive, the items of the boundary date can be returned by two pages
+func paginate[T any](ctx context.Context, fetch fetchPage[T], key func(T) (time.Time, string), backward bool, prefetch int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var boundary *time.Time
+		boundaryIDs := map[string]bool{}
+
+		for p := range pages(ctx, fetch, prefetch) {
+			if p.err != nil {
+				var zero T
+				yield(zero, p.err)
+				return
+			}
+
+			for _, item := range p.items {
+				if err := ctx.Err(); err != nil {
+					var zero T
+					yield(zero, err)
+					return
+				}
+
+				date, id := key(item)
+				if boundary != nil {
+					if backward && date.After(*boundary) || !backward && date.Before(*boundary) {
+						continue
+					}
+
+					if date.Equal(*boundary) && boundaryIDs[id] {
+						continue
+					}
+				}
+
+				if boundary == nil || !date.Equal(*boundary) {
+					boundary = &date
+					clear(boundaryIDs)
+				}
+				boundaryIDs[id] = true
+
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pages fetches the pages until the last one, a cancelled context ends with its error
+func pages[T any](ctx context.Context, fetch fetchPage[T], prefetch int) iter.Seq[page[T]] {
+	sequential := func(yield func(page[T]) bool) {
+		var cursor *time.Time
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(page[T]{err: err})
+				return
+			}
+
+			items, hasMore, nextCursor, err := fetch(ctx, cursor)
+			if err != nil {
+				yield(page[T]{err: err})
+				return
+			}
+
+			if !yield(page[T]{items: items}) || !hasMore {
+				return
+			}
+
+			if nextCursor == nil {
+				yield(page[T]{err: fmt.Errorf("unable to fetch the next page: no cursor")})
+				return
+			}
+
+			// The same request would return the same page forever
+			if cursor != nil && nextCursor.Equal(*cursor) {
+				yield(page[T]{err: fmt.Errorf("unable to fetch the next page: cursor %s does not move, use a larger page size", nextCursor)})
+				return
+			}
+
+			cursor = nextCursor
+		}
+	}
+
+	if prefetch <= 0 {
+		return sequential
+	}
+
+	return func(yield func(page[T]) bool) {
+		// The caller cancels ctx once done, it stops the producer
+		buffer := make(chan page[T], prefetch)
+		go func() {
+			defer close(buffer)
+			for p := range sequential {
+				select {
+				case buffer <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case p, ok := <-buffer:
+				if !ok || !yield(p) {
+					return
+				}
+			case <-ctx.Done():
+				yield(page[T]{err: ctx.Err()})
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type item struct {
+	date time.Time
+	id   string
+}
+
+func itemKey(i item) (time.Time, string) {
+	return i.date, i.id
+}
+
+var day = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// overlappingPages returns pages whose first item is the last item of the previous page
+func overlappingPages(count int, pageSize int) fetchPage[item] {
+	all := make([]item, count)
+	for i := range all {
+		all[i] = item{date: day.Add(time.Duration(i) * time.Hour), id: fmt.Sprint(i)}
+	}
+
+	return func(ctx context.Context, cursor *time.Time) ([]item, bool, *time.Time, error) {
+		start := 0
+		if cursor != nil {
+			for start < len(all) && all[start].date.Before(*cursor) {
+				start++
+			}
+		}
+
+		end := min(start+pageSize, len(all))
+		if end == len(all) {
+			return all[start:end], false, nil, nil
+		}
+
+		next := all[end-1].date
+		return all[start:end], true, &next, nil
+	}
+}
+
+func collect(seq func(func(item, error) bool)) ([]item, error) {
+	res := []item{}
+	for i, err := range seq {
+		if err != nil {
+			return res, err
+		}
+		res = append(res, i)
+	}
+
+	return res, nil
+}
+
+func TestPaginate(t *testing.T) {
+	for _, prefetch := range []int{0, 2} {
+		t.Run(fmt.Sprintf("prefetch %d", prefetch), func(t *testing.T) {
+			got, err := collect(paginate(context.Background(), overlappingPages(10, 3), itemKey, false, prefetch))
+			if err != nil {
+				t.Fatalf("paginate() error = %v", err)
+			}
+
+			if len(got) != 10 {
+				t.Fatalf("paginate() returned %d items, want 10: %v", len(got), got)
+			}
+
+			for i := range got {
+				if got[i].id != fmt.Sprint(i) {
+					t.Errorf("paginate() item %d = %v", i, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPaginate_SameDate(t *testing.T) {
+	// Many items share the boundary date, only the ones already yielded are skipped
+	fetch := func(ctx context.Context, cursor *time.Time) ([]item, bool, *time.Time, error) {
+		if cursor == nil {
+			next := day
+			return []item{{date: day, id: "a"}, {date: day, id: "b"}}, true, &next, nil
+		}
+
+		return []item{{date: day, id: "a"}, {date: day, id: "b"}, {date: day, id: "c"}}, false, nil, nil
+	}
+
+	got, err := collect(paginate(context.Background(), fetch, itemKey, false, 0))
+	if err != nil || len(got) != 3 {
+		t.Fatalf("paginate() = %v, %v, want 3 items", got, err)
+	}
+}
+
+func TestPaginate_CursorDoesNotMove(t *testing.T) {
+	fetch := func(ctx context.Context, cursor *time.Time) ([]item, bool, *time.Time, error) {
+		next := day
+		return []item{{date: day, id: "a"}}, true, &next, nil
+	}
+
+	if _, err := collect(paginate(context.Background(), fetch, itemKey, false, 0)); err == nil {
+		t.Errorf("paginate() should fail when the cursor does not move")
+	}
+}
+
+func TestPaginate_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fetched := 0
+	fetch := overlappingPages(100, 2)
+	counting := func(ctx context.Context, cursor *time.Time) ([]item, bool, *time.Time, error) {
+		fetched++
+		return fetch(ctx, cursor)
+	}
+
+	count := 0
+	var gotErr error
+	for _, err := range paginate(ctx, counting, itemKey, false, 0) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+
+		count++
+		if count == 3 {
+			cancel()
+		}
+	}
+
+	if !errors.Is(gotErr, context.Canceled) || count != 3 || fetched != 2 {
+		t.Errorf("paginate() after cancel: error = %v, items = %d, pages = %d", gotErr, count, fetched)
+	}
+}
+
+func TestPaginate_PrefetchBreak(t *testing.T) {
+	for range paginate(context.Background(), overlappingPages(100, 2), itemKey, false, 3) {
+		break
+	}
+	// Breaking the loop cancels the producer, the race detector checks its shutdown
+}
@@ -0,0 +1,514 @@
+// Package portstest runs the same scenarios against any ports.Client implementation
+// so the HTTP and the in-process clients are checked to behave the same
+package portstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	"github.com/sopial42/bifrost/pkg/domains/positions"
+	"github.com/sopial42/bifrost/pkg/ports"
+)
+
+// NewClient returns a client backed by an empty store
+type NewClient func(t *testing.T) ports.Client
+
+var (
+	pair      = common.SOLUSDC
+	interval  = common.H1
+	firstDate = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// RunClientSuite runs every scenario, each one on a new client
+func RunClientSuite(t *testing.T, newClient NewClient) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, client ports.Client)
+	}{
+		{name: "candles pagination", run: testCandlesPagination},
+		{name: "candles by last date pagination", run: testCandlesByLastDatePagination},
+		{name: "candles iterators", run: testCandlesIterators},
+		{name: "candle by date", run: testCandleByDate},
+		{name: "candles already exist", run: testCandlesAlreadyExist},
+		{name: "candles ingest", run: testCandlesIngest},
+		{name: "candles RSI update", run: testCandlesRSIUpdate},
+		{name: "surrounding dates", run: testSurroundingDates},
+		{name: "minute close prices", run: testMinuteClosePrices},
+		{name: "buy signals already exist", run: testBuySignalsAlreadyExist},
+		{name: "buy signals iterator", run: testBuySignalsIterator},
+		{name: "positions already exist", run: testPositionsAlreadyExist},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			client := newClient(t)
+			ctx := logger.SetLoggerToContext(context.Background(), logger.GetDefaultLogger())
+			s.run(t, ctx, client)
+		})
+	}
+}
+
+func hourlyCandles(count int) []candles.Candle {
+	res := make([]candles.Candle, count)
+	for i := range res {
+		price := float64(100 + i)
+		res[i] = candles.Candle{
+			Date:     candles.Date(firstDate.Add(time.Duration(i) * time.Hour)),
+			Pair:     pair,
+			Interval: interval,
+			Open:     price,
+			Close:    price,
+			High:     price + 1,
+			Low:      price - 1,
+		}
+	}
+
+	return res
+}
+
+func createCandles(t *testing.T, ctx context.Context, client ports.Client, newCandles []candles.Candle) {
+	t.Helper()
+
+	created, err := client.CreateCandles(ctx, &newCandles, 2)
+	if err != nil {
+		t.Fatalf("CreateCandles() error = %v", err)
+	}
+
+	if created == nil || len(*created) != len(newCandles) {
+		t.Fatalf("CreateCandles() created %v candles, want %d", created, len(newCandles))
+	}
+}
+
+func assertDates(t *testing.T, got []candles.Candle, want []candles.Candle) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d candles, want %d", len(got), len(want))
+	}
+
+	for i := range got {
+		if !time.Time(got[i].Date).Equal(time.Time(want[i].Date)) {
+			t.Errorf("candle %d date = %s, want %s", i, got[i].Date, want[i].Date)
+		}
+	}
+}
+
+func testCandlesPagination(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(7)
+	createCandles(t, ctx, client, all)
+
+	collected := []candles.Candle{}
+	var cursor *time.Time
+	for page := 0; ; page++ {
+		if page > len(all) {
+			t.Fatalf("GetCandles() pagination does not end")
+		}
+
+		res, hasMore, nextCursor, err := client.GetCandles(ctx, pair, interval, cursor, 3)
+		if err != nil {
+			t.Fatalf("GetCandles() error = %v", err)
+		}
+
+		collected = append(collected, *res...)
+		if !hasMore {
+			if nextCursor != nil {
+				t.Errorf("GetCandles() nextCursor = %v on the last page, want nil", nextCursor)
+			}
+			break
+		}
+
+		if nextCursor == nil {
+			t.Fatalf("GetCandles() nextCursor is nil while hasMore")
+		}
+
+		cursor = nextCursor
+	}
+
+	assertDates(t, collected, all)
+}
+
+func testCandlesByLastDatePagination(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(7)
+	createCandles(t, ctx, client, all)
+
+	collected := []candles.Candle{}
+	cursor := all[len(all)-1].Date
+	for page := 0; ; page++ {
+		if page > len(all) {
+			t.Fatalf("GetCandlesByLastDate() pagination does not end")
+		}
+
+		res, hasMore, nextCursor, err := client.GetCandlesByLastDate(ctx, pair, interval, cursor, 3)
+		if err != nil {
+			t.Fatalf("GetCandlesByLastDate() error = %v", err)
+		}
+
+		// Pages are sorted by date, the first page holds the last candles
+		collected = append(*res, collected...)
+		if !hasMore {
+			break
+		}
+
+		if nextCursor == nil {
+			t.Fatalf("GetCandlesByLastDate() nextCursor is nil while hasMore")
+		}
+
+		cursor = candles.Date(*nextCursor)
+	}
+
+	assertDates(t, collected, all)
+}
+
+func testCandlesIterators(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(7)
+	createCandles(t, ctx, client, all)
+
+	for _, opts := range []ports.IterOptions{{PageSize: 3}, {PageSize: 2, Prefetch: 2}} {
+		collected := []candles.Candle{}
+		for c, err := range client.AllCandles(ctx, pair, interval, nil, opts) {
+			if err != nil {
+				t.Fatalf("AllCandles(%+v) error = %v", opts, err)
+			}
+			collected = append(collected, c)
+		}
+
+		assertDates(t, collected, all)
+
+		collected = []candles.Candle{}
+		for c, err := range client.AllCandlesByLastDate(ctx, pair, interval, all[len(all)-1].Date, opts) {
+			if err != nil {
+				t.Fatalf("AllCandlesByLastDate(%+v) error = %v", opts, err)
+			}
+			collected = append(collected, c)
+		}
+
+		reversed := slices.Clone(all)
+		slices.Reverse(reversed)
+		assertDates(t, collected, reversed)
+	}
+}
+
+func testCandleByDate(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(3)
+	createCandles(t, ctx, client, all)
+
+	got, err := client.GetCandleByDate(ctx, pair, interval, all[1].Date)
+	if err != nil {
+		t.Fatalf("GetCandleByDate() error = %v", err)
+	}
+
+	if got == nil || !time.Time(got.Date).Equal(time.Time(all[1].Date)) || got.Close != all[1].Close {
+		t.Errorf("GetCandleByDate() = %+v, want %+v", got, all[1])
+	}
+
+	got, err = client.GetCandleByDate(ctx, pair, interval, candles.Date(firstDate.Add(24*time.Hour)))
+	if err != nil || got != nil {
+		t.Errorf("GetCandleByDate() after the last candle = %+v, %v, want nil, nil", got, err)
+	}
+}
+
+func testCandlesAlreadyExist(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(3)
+	createCandles(t, ctx, client, all)
+
+	if _, err := client.CreateCandles(ctx, &all, 0); err != nil {
+		t.Fatalf("CreateCandles() of existing candles error = %v, want nil", err)
+	}
+
+	res, _, _, err := client.GetCandles(ctx, pair, interval, nil, 0)
+	if err != nil {
+		t.Fatalf("GetCandles() error = %v", err)
+	}
+
+	assertDates(t, *res, all)
+}
+
+func candlesSeq(all []candles.Candle) iter.Seq2[candles.Candle, error] {
+	return func(yield func(candles.Candle, error) bool) {
+		for _, candle := range all {
+			if !yield(candle, nil) {
+				return
+			}
+		}
+	}
+}
+
+func testCandlesIngest(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(5)
+	createCandles(t, ctx, client, all[:2])
+
+	// The existing candles and the repeated one are skipped
+	stream := append(slices.Clone(all), all[4])
+	res, err := client.IngestCandles(ctx, candlesSeq(stream))
+	if err != nil {
+		t.Fatalf("IngestCandles() error = %v", err)
+	}
+
+	if res.Inserted != 3 || res.Skipped != 3 {
+		t.Errorf("IngestCandles() = %+v, want 3 inserted and 3 skipped", *res)
+	}
+
+	got, _, _, err := client.GetCandles(ctx, pair, interval, nil, 0)
+	if err != nil {
+		t.Fatalf("GetCandles() error = %v", err)
+	}
+
+	assertDates(t, *got, all)
+	if (*got)[4].Close != all[4].Close || (*got)[4].High != all[4].High {
+		t.Errorf("ingested candle = %+v, want %+v", (*got)[4], all[4])
+	}
+
+	// The sequence error stops the ingestion
+	failing := func(yield func(candles.Candle, error) bool) {
+		if yield(hourlyCandles(6)[5], nil) {
+			yield(candles.Candle{}, errors.New("source failure"))
+		}
+	}
+	if _, err := client.IngestCandles(ctx, failing); err == nil || !strings.Contains(err.Error(), "source failure") {
+		t.Errorf("IngestCandles() error = %v, want the source failure", err)
+	}
+}
+
+func testCandlesRSIUpdate(t *testing.T, ctx context.Context, client ports.Client) {
+	createCandles(t, ctx, client, hourlyCandles(3))
+
+	res, _, _, err := client.GetCandles(ctx, pair, interval, nil, 0)
+	if err != nil || len(*res) != 3 {
+		t.Fatalf("GetCandles() = %v, %v", res, err)
+	}
+
+	toUpdate := (*res)[1:]
+	for i := range toUpdate {
+		toUpdate[i].RSI = &candles.RSI{14: candles.RSIValue(50 + i)}
+	}
+
+	updated, err := client.UpdateCandleListRSI(ctx, &toUpdate)
+	if err != nil {
+		t.Fatalf("UpdateCandleListRSI() error = %v", err)
+	}
+
+	if updated == nil || len(*updated) != len(toUpdate) {
+		t.Fatalf("UpdateCandleListRSI() updated %v candles, want %d", updated, len(toUpdate))
+	}
+
+	res, _, _, err = client.GetCandles(ctx, pair, interval, nil, 0)
+	if err != nil {
+		t.Fatalf("GetCandles() error = %v", err)
+	}
+
+	if (*res)[0].RSI != nil {
+		t.Errorf("GetCandles()[0].RSI = %v, want nil", *(*res)[0].RSI)
+	}
+
+	for i, c := range (*res)[1:] {
+		if c.RSI == nil || (*c.RSI)[14] != candles.RSIValue(50+i) {
+			t.Errorf("GetCandles()[%d].RSI = %v, want 14: %d", i+1, c.RSI, 50+i)
+		}
+	}
+}
+
+func testSurroundingDates(t *testing.T, ctx context.Context, client ports.Client) {
+	all := hourlyCandles(4)
+	createCandles(t, ctx, client, all)
+
+	first, last, err := client.QuerySurroundingDates(ctx, pair, interval)
+	if err != nil {
+		t.Fatalf("QuerySurroundingDates() error = %v", err)
+	}
+
+	if first == nil || last == nil || !time.Time(*first).Equal(time.Time(all[0].Date)) || !time.Time(*last).Equal(time.Time(all[3].Date)) {
+		t.Errorf("QuerySurroundingDates() = %v, %v, want %s, %s", first, last, all[0].Date, all[3].Date)
+	}
+
+	_, _, err = client.QuerySurroundingDates(ctx, pair, common.D1)
+	if !errors.Is(err, appErrors.ErrNotFound) {
+		t.Errorf("QuerySurroundingDates() without candles error = %v, want not found", err)
+	}
+}
+
+func testMinuteClosePrices(t *testing.T, ctx context.Context, client ports.Client) {
+	minuteCandles := []candles.Candle{
+		{Date: candles.Date(firstDate), Pair: pair, Interval: common.M1, Open: 10, Close: 11, High: 12, Low: 9},
+		{Date: candles.Date(firstDate.Add(time.Minute)), Pair: pair, Interval: common.M1, Open: 11, Close: 12, High: 13, Low: 10},
+	}
+	createCandles(t, ctx, client, minuteCandles)
+
+	// Dates are rounded to the beginning of their minute
+	inMinute := candles.Date(firstDate.Add(90 * time.Second))
+	prices, err := client.GetCandlesMinuteClosePriceByDate(ctx, ports.PriceRequest{
+		pair: {candles.Date(firstDate), inMinute},
+	})
+	if err != nil {
+		t.Fatalf("GetCandlesMinuteClosePriceByDate() error = %v", err)
+	}
+
+	want := map[ports.PriceRequestDate]float64{
+		ports.PriceRequestDate(candles.Date(firstDate).String()): 11,
+		ports.PriceRequestDate(inMinute.String()):                12,
+	}
+
+	got := (*prices)[pair]
+	for date, price := range want {
+		if got[date] != price {
+			t.Errorf("GetCandlesMinuteClosePriceByDate()[%s] = %v, want %v", date, got[date], price)
+		}
+	}
+}
+
+func newBuySignals() []bsDomain.Details {
+	res := make([]bsDomain.Details, 2)
+	for i := range res {
+		res[i] = bsDomain.Details{
+			BusinessID: bsDomain.BusinessID(fmt.Sprintf("contract-%d", i)),
+			Name:       "morningStar",
+			Fullname:   "morningStar_b0.6_s0.3_p0.5_t3",
+			Pair:       pair,
+			Interval:   interval,
+			Date:       bsDomain.Date(firstDate.Add(time.Duration(i) * time.Hour)),
+			Price:      100,
+		}
+	}
+
+	return res
+}
+
+func createBuySignals(t *testing.T, ctx context.Context, client ports.Client) []bsDomain.Details {
+	t.Helper()
+
+	newBS := newBuySignals()
+	created, err := client.CreateBuySignals(ctx, &newBS)
+	if err != nil {
+		t.Fatalf("CreateBuySignals() error = %v", err)
+	}
+
+	if created == nil || len(*created) != len(newBS) {
+		t.Fatalf("CreateBuySignals() created %v buy signals, want %d", created, len(newBS))
+	}
+
+	for _, bs := range *created {
+		if bs.ID == nil {
+			t.Fatalf("CreateBuySignals() returned a buy signal without ID: %+v", bs)
+		}
+	}
+
+	return *created
+}
+
+func testBuySignalsAlreadyExist(t *testing.T, ctx context.Context, client ports.Client) {
+	createBuySignals(t, ctx, client)
+
+	duplicates := newBuySignals()
+	created, err := client.CreateBuySignals(ctx, &duplicates)
+	if err != nil {
+		t.Fatalf("CreateBuySignals() of existing buy signals error = %v, want nil", err)
+	}
+
+	if created != nil && len(*created) != 0 {
+		t.Errorf("CreateBuySignals() of existing buy signals created %d, want 0", len(*created))
+	}
+
+	// A duplicate does not fail the other buy signals of the batch
+	newBS := newBuySignals()[0]
+	newBS.BusinessID = "contract-new"
+	mixed := append(newBuySignals(), newBS)
+	created, err = client.CreateBuySignals(ctx, &mixed)
+	if err != nil {
+		t.Fatalf("CreateBuySignals() of existing and new buy signals error = %v, want nil", err)
+	}
+
+	if created == nil || len(*created) != 1 || (*created)[0].BusinessID != newBS.BusinessID {
+		t.Errorf("CreateBuySignals() of existing and new buy signals created %v, want %s only", created, newBS.BusinessID)
+	}
+
+	res, hasMore, _, err := client.GetBuySignals(ctx, pair, interval, "morningStar", nil, 0)
+	if err != nil {
+		t.Fatalf("GetBuySignals() error = %v", err)
+	}
+
+	if hasMore || len(*res) != len(duplicates)+1 {
+		t.Errorf("GetBuySignals() = %d buy signals, hasMore %v, want %d", len(*res), hasMore, len(duplicates)+1)
+	}
+}
+
+func testBuySignalsIterator(t *testing.T, ctx context.Context, client ports.Client) {
+	created := createBuySignals(t, ctx, client)
+
+	res, hasMore, _, err := client.GetBuySignals(ctx, pair, interval, "morningStar", nil, 1)
+	if err != nil {
+		t.Fatalf("GetBuySignals() with limit 1 error = %v", err)
+	}
+
+	if len(*res) != 1 || hasMore != (len(created) > 1) {
+		t.Errorf("GetBuySignals() with limit 1 = %d buy signals, hasMore %v", len(*res), hasMore)
+	}
+
+	for _, opts := range []ports.IterOptions{{Prefetch: 1}, {PageSize: 1}, {PageSize: 2, Prefetch: 2}} {
+		count := 0
+		for bs, err := range client.AllBuySignals(ctx, pair, interval, "morningStar", nil, opts) {
+			if err != nil {
+				t.Fatalf("AllBuySignals(%+v) error = %v", opts, err)
+			}
+
+			if bs.ID == nil {
+				t.Errorf("AllBuySignals(%+v) returned a buy signal without ID", opts)
+			}
+			count++
+		}
+
+		if count != len(created) {
+			t.Errorf("AllBuySignals(%+v) returned %d buy signals, want %d", opts, count, len(created))
+		}
+	}
+}
+
+func testPositionsAlreadyExist(t *testing.T, ctx context.Context, client ports.Client) {
+	bs := createBuySignals(t, ctx, client)
+
+	newPositions := func() []positions.Details {
+		res := make([]positions.Details, 0, len(positions.AvailableWinLossRatios[:2]))
+		for _, ratio := range positions.AvailableWinLossRatios[:2] {
+			r := ratio
+			res = append(res, positions.Details{
+				Name:         positions.PercentageName,
+				Fullname:     "percentage_tp5",
+				BuySignalID:  *bs[0].ID,
+				TP:           105,
+				SL:           r.ComputeStoploss(100, 105),
+				WinlossRatio: &r,
+			})
+		}
+
+		return res
+	}
+
+	toCreate := newPositions()
+	created, err := client.CreatePositions(ctx, &toCreate, 0)
+	if err != nil {
+		t.Fatalf("CreatePositions() error = %v", err)
+	}
+
+	if created == nil || len(*created) != len(toCreate) {
+		t.Fatalf("CreatePositions() created %v positions, want %d", created, len(toCreate))
+	}
+
+	duplicates := newPositions()
+	created, err = client.CreatePositions(ctx, &duplicates, 0)
+	if err != nil {
+		t.Fatalf("CreatePositions() of existing positions error = %v, want nil", err)
+	}
+
+	if created != nil && len(*created) != 0 {
+		t.Errorf("CreatePositions() of existing positions created %d, want 0", len(*created))
+	}
+}
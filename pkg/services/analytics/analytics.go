@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	domain "github.com/sopial42/bifrost/pkg/domains/analytics"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
+	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+type analyticsService struct {
+	persistence Persistence
+	candles     candlesSVC.Service
+	positions   positionsSVC.Service
+}
+
+func NewAnalyticsService(persistence Persistence, candles candlesSVC.Service, positions positionsSVC.Service) Service {
+	return &analyticsService{
+		persistence: persistence,
+		candles:     candles,
+		positions:   positions,
+	}
+}
+
+func (a *analyticsService) RunWalkForward(ctx context.Context, request domain.WalkForwardRequest) (*domain.WalkForward, error) {
+	ctx, span := tracing.Start(ctx, "analytics.RunWalkForward")
+	defer span.End()
+
+	log := logger.GetLogger(ctx)
+	if request.InSampleDays <= 0 || request.OutOfSampleDays <= 0 || request.StepDays < 0 {
+		return nil, appErrors.NewInvalidInput("in_sample_days and out_of_sample_days should be greater than 0", nil)
+	}
+
+	if request.StepDays == 0 {
+		request.StepDays = request.OutOfSampleDays
+	}
+
+	if len(request.Metrics) == 0 {
+		request.Metrics = domain.AllAvailableMetrics
+	}
+
+	first, last, err := a.candles.GetSurroundingDates(ctx, request.Pair, request.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get candles history: %w", err)
+	}
+
+	startDate := time.Time(*first)
+	if request.StartDate != nil && request.StartDate.After(startDate) {
+		startDate = *request.StartDate
+	}
+
+	endDate := time.Time(*last)
+	if request.EndDate != nil && request.EndDate.Before(endDate) {
+		endDate = *request.EndDate
+	}
+
+	day := 24 * time.Hour
+	windows, err := domain.BuildWindows(startDate, endDate, time.Duration(request.InSampleDays)*day, time.Duration(request.OutOfSampleDays)*day, time.Duration(request.StepDays)*day, request.Anchored)
+	if err != nil {
+		return nil, appErrors.NewInvalidInput("invalid windows", err)
+	}
+
+	if len(windows) == 0 {
+		return nil, appErrors.NewInvalidInput(fmt.Sprintf("history from %s to %s is too short for a single window", startDate.Format(time.RFC3339), endDate.Format(time.RFC3339)), nil)
+	}
+
+	computed, err := a.positions.GetComputedPositions(ctx, request.Pair, request.Interval, &startDate, &endDate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get computed positions: %w", err)
+	}
+
+	log.Infof("Run walk-forward over %d windows and %d computed positions", len(windows), len(*computed))
+	evaluated := domain.Evaluate(*computed, windows, request.Metrics, request.MinTrades)
+	walkForward, err := a.persistence.InsertWalkForward(ctx, &domain.WalkForward{
+		Request:   request,
+		CreatedAt: time.Now().UTC(),
+		Windows:   evaluated,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to store walk-forward: %w", err)
+	}
+
+	walkForward.Summaries = domain.Summarize(walkForward.Windows, request.Metrics)
+	return walkForward, nil
+}
+
+func (a *analyticsService) GetWalkForward(ctx context.Context, id domain.ID) (*domain.WalkForward, error) {
+	ctx, span := tracing.Start(ctx, "analytics.GetWalkForward")
+	defer span.End()
+
+	walkForward, err := a.persistence.GetWalkForwardByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get walk-forward: %w", err)
+	}
+
+	if walkForward == nil {
+		return nil, appErrors.NewNotFound("walk-forward not found")
+	}
+
+	walkForward.Summaries = domain.Summarize(walkForward.Windows, walkForward.Request.Metrics)
+	return walkForward, nil
+}
+
+func (a *analyticsService) RunMonteCarlo(ctx context.Context, request domain.MonteCarloRequest) (*domain.MonteCarlo, error) {
+	ctx, span := tracing.Start(ctx, "analytics.RunMonteCarlo")
+	defer span.End()
+
+	if request.PositionFullname == "" || request.BuySignalFullname == "" {
+		return nil, appErrors.NewInvalidInput("buy_signal_fullname and position_fullname are required", nil)
+	}
+
+	if request.Method == "" {
+		request.Method = domain.Bootstrap
+	}
+
+	if request.Iterations == 0 {
+		request.Iterations = domain.DefaultIterations
+	}
+
+	if len(request.Percentiles) == 0 {
+		request.Percentiles = domain.DefaultPercentiles
+	}
+
+	computed, err := a.positions.GetComputedPositions(ctx, request.Pair, request.Interval, request.StartDate, request.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get computed positions: %w", err)
+	}
+
+	strategy := domain.Strategy{
+		BuySignalFullname: request.BuySignalFullname,
+		PositionFullname:  request.PositionFullname,
+		WinlossRatio:      request.WinlossRatio,
+	}
+
+	ratios := strategy.Ratios(*computed)
+	if len(ratios) == 0 {
+		return nil, appErrors.NewNotFound("no computed positions found for this strategy")
+	}
+
+	monteCarlo, err := domain.RunMonteCarlo(ratios, request.Method, request.Iterations, request.Seed, request.Percentiles)
+	if err != nil {
+		return nil, appErrors.NewInvalidInput("unable to run monte carlo", err)
+	}
+
+	monteCarlo.Request = request
+	return monteCarlo, nil
+}
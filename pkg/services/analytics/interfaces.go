@@ -0,0 +1,21 @@
+package analytics
+
+import (
+	"context"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/analytics"
+)
+
+type Service interface {
+	// RunWalkForward evaluates, on rolling windows, the out-of-sample performance of the best in-sample strategies
+	// It only uses already computed positions, and stores the evaluation under a new ID
+	RunWalkForward(context.Context, domain.WalkForwardRequest) (*domain.WalkForward, error)
+	GetWalkForward(context.Context, domain.ID) (*domain.WalkForward, error)
+	// RunMonteCarlo resamples the computed ratios of a strategy to show its sequence risk
+	RunMonteCarlo(context.Context, domain.MonteCarloRequest) (*domain.MonteCarlo, error)
+}
+
+type Persistence interface {
+	InsertWalkForward(context.Context, *domain.WalkForward) (*domain.WalkForward, error)
+	GetWalkForwardByID(context.Context, domain.ID) (*domain.WalkForward, error)
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+type authService struct {
+	persistence Persistence
+}
+
+func NewAuthService(persistence Persistence) Service {
+	return &authService{
+		persistence: persistence,
+	}
+}
+
+func (a *authService) CreateAPIKey(ctx context.Context, owner string, workspace workspaces.Workspace, scopes []domain.Scope) (*domain.APIKey, string, error) {
+	ctx, span := tracing.Start(ctx, "auth.CreateAPIKey")
+	defer span.End()
+
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return nil, "", appErrors.NewInvalidInput("owner is required", nil)
+	}
+
+	if _, err := workspaces.Parse(string(workspace)); err != nil {
+		return nil, "", appErrors.NewInvalidInput("invalid workspace", err)
+	}
+
+	args := make([]string, len(scopes))
+	for i, s := range scopes {
+		args[i] = string(s)
+	}
+
+	if _, err := domain.ParseScopes(args); err != nil {
+		return nil, "", appErrors.NewInvalidInput("invalid scopes", err)
+	}
+
+	// Only a global admin can leave its workspace, or grant the same rights
+	if !domain.ManagesAllWorkspaces(ctx) {
+		if current := workspaces.GetWorkspaceFromContext(ctx); workspace != current {
+			return nil, "", appErrors.NewForbidden(fmt.Sprintf("creating an api key out of the workspace %s requires the %s scope", current, domain.ScopeGlobalAdmin), nil)
+		}
+
+		if (domain.APIKey{Scopes: scopes}).IsGlobalAdmin() {
+			return nil, "", appErrors.NewForbidden(fmt.Sprintf("granting the %s scope requires it", domain.ScopeGlobalAdmin), nil)
+		}
+	}
+
+	key, prefix, err := domain.GenerateKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create api key: %w", err)
+	}
+
+	created, err := a.persistence.InsertAPIKey(ctx, &domain.APIKey{
+		Owner:     owner,
+		Workspace: workspace,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+		Hash:      domain.HashKey(key),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to insert api key: %w", err)
+	}
+
+	logger.GetLogger(ctx).WithField(domain.LoggerKeyAPIKeyID, created.ID.String()).Infof("API key created for %s in workspace %s with scopes %v", owner, workspace, scopes)
+	return created, key, nil
+}
+
+func (a *authService) Authenticate(ctx context.Context, key string) (*domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.Authenticate")
+	defer span.End()
+
+	prefix, err := domain.ParseKey(key)
+	if err != nil {
+		return nil, appErrors.NewUnauthorized("invalid api key", err)
+	}
+
+	apiKey, err := a.persistence.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get api key: %w", err)
+	}
+
+	if apiKey == nil || !apiKey.Matches(key) {
+		return nil, appErrors.NewUnauthorized("invalid api key", nil)
+	}
+
+	if apiKey.IsRevoked() {
+		return nil, appErrors.NewUnauthorized("api key revoked", nil)
+	}
+
+	return apiKey, nil
+}
+
+func (a *authService) ListAPIKeys(ctx context.Context) (*[]domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.ListAPIKeys")
+	defer span.End()
+
+	keys, err := a.persistence.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a *authService) RevokeAPIKey(ctx context.Context, id domain.ID) (*domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.RevokeAPIKey")
+	defer span.End()
+
+	revoked, err := a.persistence.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to revoke api key: %w", err)
+	}
+
+	if revoked == nil {
+		return nil, appErrors.NewNotFound(fmt.Sprintf("api key %s not found", id))
+	}
+
+	logger.GetLogger(ctx).WithField(domain.LoggerKeyAPIKeyID, id.String()).Infof("API key revoked")
+	return revoked, nil
+}
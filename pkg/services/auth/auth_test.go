@@ -0,0 +1,104 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	authPersistence "github.com/sopial42/bifrost/pkg/adapters/persistence/auth"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/memory"
+	"github.com/sopial42/bifrost/pkg/adapters/persistence/pgtest"
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+	authSVC "github.com/sopial42/bifrost/pkg/services/auth"
+)
+
+// asKey returns the context of a request authenticated by a key of the workspace
+func asKey(workspace workspaces.Workspace, scopes ...domain.Scope) context.Context {
+	ctx := domain.SetAPIKeyToContext(context.Background(), &domain.APIKey{Owner: "caller", Workspace: workspace, Scopes: scopes})
+	return workspaces.SetWorkspaceToContext(ctx, workspace)
+}
+
+func TestCreateAPIKeyEscalation(t *testing.T) {
+	service := authSVC.NewAuthService(memory.NewAuthPersistence(memory.NewStore()))
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		workspace   workspaces.Workspace
+		scopes      []domain.Scope
+		wantErr     error
+		wantCreated bool
+	}{
+		{name: "admin in its workspace", ctx: asKey("team-a", domain.ScopeAdmin), workspace: "team-a", scopes: []domain.Scope{domain.ScopeAdmin}, wantCreated: true},
+		{name: "admin in another workspace", ctx: asKey("team-a", domain.ScopeAdmin), workspace: "team-b", scopes: []domain.Scope{domain.ScopeRead}, wantErr: appErrors.ErrForbidden},
+		{name: "admin granting global admin", ctx: asKey("team-a", domain.ScopeAdmin), workspace: "team-a", scopes: []domain.Scope{domain.ScopeGlobalAdmin}, wantErr: appErrors.ErrForbidden},
+		{name: "unauthenticated in another workspace", ctx: context.Background(), workspace: "team-b", scopes: []domain.Scope{domain.ScopeRead}, wantErr: appErrors.ErrForbidden},
+		{name: "global admin in another workspace", ctx: asKey("team-a", domain.ScopeGlobalAdmin), workspace: "team-b", scopes: []domain.Scope{domain.ScopeAdmin}, wantCreated: true},
+		{name: "global admin granting global admin", ctx: asKey("team-a", domain.ScopeGlobalAdmin), workspace: "team-b", scopes: []domain.Scope{domain.ScopeGlobalAdmin}, wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, _, err := service.CreateAPIKey(tt.ctx, "bot", tt.workspace, tt.scopes)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateAPIKey() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || created == nil || created.Workspace != tt.workspace {
+				t.Errorf("CreateAPIKey() = %+v, %v, want a key of %s", created, err, tt.workspace)
+			}
+		})
+	}
+}
+
+func TestAPIKeysWorkspaceScoping_Memory(t *testing.T) {
+	testAPIKeysWorkspaceScoping(t, memory.NewAuthPersistence(memory.NewStore()))
+}
+
+func TestAPIKeysWorkspaceScoping_Postgres(t *testing.T) {
+	testAPIKeysWorkspaceScoping(t, authPersistence.NewPersistence(pgtest.NewDB(t)))
+}
+
+func testAPIKeysWorkspaceScoping(t *testing.T, persistence authSVC.Persistence) {
+	service := authSVC.NewAuthService(persistence)
+	global := asKey("ops", domain.ScopeGlobalAdmin)
+
+	keyA, _, err := service.CreateAPIKey(global, "bot-a", "team-a", []domain.Scope{domain.ScopeRead})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	keyB, _, err := service.CreateAPIKey(global, "bot-b", "team-b", []domain.Scope{domain.ScopeRead})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	adminA := asKey("team-a", domain.ScopeAdmin)
+	keys, err := service.ListAPIKeys(adminA)
+	if err != nil || len(*keys) != 1 || (*keys)[0].Owner != "bot-a" {
+		t.Errorf("ListAPIKeys() of a team-a admin = %+v, %v, want the team-a key only", keys, err)
+	}
+
+	keys, err = service.ListAPIKeys(global)
+	if err != nil || len(*keys) != 2 {
+		t.Errorf("ListAPIKeys() of a global admin = %+v, %v, want the keys of all the workspaces", keys, err)
+	}
+
+	if _, err := service.RevokeAPIKey(adminA, *keyB.ID); !errors.Is(err, appErrors.ErrNotFound) {
+		t.Errorf("RevokeAPIKey() of a team-b key by a team-a admin error = %v, want not found", err)
+	}
+
+	if revoked, err := service.RevokeAPIKey(adminA, *keyA.ID); err != nil || !revoked.IsRevoked() {
+		t.Errorf("RevokeAPIKey() of a team-a key by a team-a admin = %+v, %v, want it revoked", revoked, err)
+	}
+
+	if revoked, err := service.RevokeAPIKey(global, *keyB.ID); err != nil || !revoked.IsRevoked() {
+		t.Errorf("RevokeAPIKey() of a team-b key by a global admin = %+v, %v, want it revoked", revoked, err)
+	}
+}
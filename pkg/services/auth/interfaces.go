@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"context"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/auth"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+type Service interface {
+	// CreateAPIKey returns the new key of the workspace with its plain value, the plain value can not be retrieved later
+	// A key of another workspace than the request one, or with the global admin scope, requires a global admin key
+	CreateAPIKey(ctx context.Context, owner string, workspace workspaces.Workspace, scopes []domain.Scope) (*domain.APIKey, string, error)
+	// Authenticate returns the key matching the plain value, an unauthorized error if unknown or revoked
+	Authenticate(ctx context.Context, key string) (*domain.APIKey, error)
+	// ListAPIKeys and RevokeAPIKey are limited to the keys of the request workspace, unless the request key is a global admin one
+	ListAPIKeys(ctx context.Context) (*[]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id domain.ID) (*domain.APIKey, error)
+}
+
+type Persistence interface {
+	InsertAPIKey(context.Context, *domain.APIKey) (*domain.APIKey, error)
+	// GetAPIKeyByPrefix returns nil if no key has the prefix
+	GetAPIKeyByPrefix(context.Context, string) (*domain.APIKey, error)
+	ListAPIKeys(context.Context) (*[]domain.APIKey, error)
+	// RevokeAPIKey returns nil if no key of the workspace has the ID
+	RevokeAPIKey(context.Context, domain.ID) (*domain.APIKey, error)
+}
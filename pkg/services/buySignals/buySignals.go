@@ -5,30 +5,66 @@ import (
 	"fmt"
 	"time"
 
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
 	domain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	candlesDomain "github.com/sopial42/bifrost/pkg/domains/candles"
 	"github.com/sopial42/bifrost/pkg/domains/common"
+	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
 )
 
 type buySignalsService struct {
 	persistence Persistence
+	candles     candlesSVC.Service
 }
 
-func NewBuySignalsService(persistence Persistence) Service {
+func NewBuySignalsService(persistence Persistence, candles candlesSVC.Service) Service {
 	return &buySignalsService{
 		persistence: persistence,
+		candles:     candles,
 	}
 }
 
-func (b *buySignalsService) CreateBuySignals(ctx context.Context, buySignals *[]domain.Details) (*[]domain.Details, error) {
-	bs, err := b.persistence.InsertBuySignals(ctx, buySignals)
+func (b *buySignalsService) GetBuySignalsByIDs(ctx context.Context, ids []domain.ID) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "buySignals.GetBuySignalsByIDs")
+	defer span.End()
+
+	bs, err := b.persistence.QueryBuySignalsByIDs(ctx, ids)
 	if err != nil {
-		return &[]domain.Details{}, fmt.Errorf("unable to create buy signals: %w", err)
+		return &[]domain.Details{}, fmt.Errorf("unable to get buy signals by IDs: %w", err)
 	}
 
 	return bs, nil
 }
 
+type DetectRequest struct {
+	Name      domain.Name
+	Params    domain.Metadata
+	Pair      common.Pair
+	Interval  common.Interval
+	StartDate *time.Time
+	EndDate   *time.Time
+	// Persist upserts the detected signals, their business ID ensures a new detection does not duplicate them
+	Persist bool
+}
+
+func (b *buySignalsService) CreateBuySignals(ctx context.Context, buySignals *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error) {
+	ctx, span := tracing.Start(ctx, "buySignals.CreateBuySignals")
+	defer span.End()
+
+	bs, results, err := b.persistence.InsertBuySignals(ctx, buySignals)
+	if err != nil {
+		return &[]domain.Details{}, nil, fmt.Errorf("unable to create buy signals: %w", err)
+	}
+
+	return bs, results, nil
+}
+
 func (b *buySignalsService) GetBuySignals(ctx context.Context, pair common.Pair, interval common.Interval, name domain.Name, date *time.Time, limit int) (*[]domain.Details, bool, *time.Time, error) {
+	ctx, span := tracing.Start(ctx, "buySignals.GetBuySignals")
+	defer span.End()
+
 	bs, hasMore, nextCursor, err := b.persistence.QueryBuySignals(ctx, pair, interval, name, date, limit)
 	if err != nil {
 		return &[]domain.Details{}, false, nil, fmt.Errorf("unable to get buy signals: %w", err)
@@ -38,6 +74,9 @@ func (b *buySignalsService) GetBuySignals(ctx context.Context, pair common.Pair,
 }
 
 func (b *buySignalsService) UpsertBuySignals(ctx context.Context, buySignal domain.Details) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "buySignals.UpsertBuySignals")
+	defer span.End()
+
 	bs, err := b.persistence.UpsertBuySignals(ctx, buySignal)
 	if err != nil {
 		return &[]domain.Details{}, fmt.Errorf("unable to upsert buy signals: %w", err)
@@ -45,3 +84,75 @@ func (b *buySignalsService) UpsertBuySignals(ctx context.Context, buySignal doma
 
 	return bs, nil
 }
+
+func (b *buySignalsService) DetectBuySignals(ctx context.Context, request DetectRequest) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "buySignals.DetectBuySignals")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(map[string]interface{}{
+		common.PairLoggerKey:     request.Pair,
+		common.IntervalLoggerKey: request.Interval,
+		domain.LoggerKeyName:     request.Name,
+	})
+
+	detector, err := domain.NewDetector(request.Name, request.Params)
+	if err != nil {
+		return &[]domain.Details{}, appErrors.NewInvalidInput("unable to build detector", err)
+	}
+
+	candles, _, _, err := b.candles.GetCandles(ctx, request.Pair, request.Interval, request.StartDate, request.EndDate, 0)
+	if err != nil {
+		return &[]domain.Details{}, fmt.Errorf("unable to get candles to detect buy signals: %w", err)
+	}
+
+	if candles == nil || len(*candles) == 0 {
+		return &[]domain.Details{}, nil
+	}
+
+	// The candles before the range warm the detector up, so a signal gets the same metadata whatever the requested range
+	candleList, err := b.withWarmup(ctx, request, detector.Warmup(), *candles)
+	if err != nil {
+		return &[]domain.Details{}, err
+	}
+
+	detected := domain.DetectSince(detector, candleList, time.Time((*candles)[0].Date))
+	log.WithField(domain.LoggerKeyFullname, detector.Fullname()).Infof("Detected %d buy signals over %d candles", len(detected), len(*candles))
+	if !request.Persist || len(detected) == 0 {
+		return &detected, nil
+	}
+
+	persisted := make([]domain.Details, 0, len(detected))
+	for _, bs := range detected {
+		newBS, err := b.persistence.UpsertBuySignals(ctx, bs)
+		if err != nil {
+			return &[]domain.Details{}, fmt.Errorf("unable to persist detected buy signal: %w", err)
+		}
+
+		persisted = append(persisted, *newBS...)
+	}
+
+	return &persisted, nil
+}
+
+// withWarmup prepends the warmup candles preceding the first candle of the range
+func (b *buySignalsService) withWarmup(ctx context.Context, request DetectRequest, warmup int, candleList []candlesDomain.Candle) ([]candlesDomain.Candle, error) {
+	if warmup <= 0 {
+		return candleList, nil
+	}
+
+	// The last date is inclusive, the first candle of the range is fetched again and dropped
+	lastDate := time.Time(candleList[0].Date)
+	previous, _, _, err := b.candles.GetCandlesFromLastDate(ctx, request.Pair, request.Interval, &lastDate, warmup+1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get warmup candles to detect buy signals: %w", err)
+	}
+
+	res := make([]candlesDomain.Candle, 0, warmup+len(candleList))
+	for _, c := range *previous {
+		if time.Time(c.Date).Before(lastDate) {
+			res = append(res, c)
+		}
+	}
+
+	return append(res, candleList...), nil
+}
@@ -9,13 +9,21 @@ import (
 )
 
 type Service interface {
-	CreateBuySignals(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// CreateBuySignals creates the buy signals, the existing ones are ignored
+	// Returns the created buy signals, and the outcome of each buy signal in input order
+	CreateBuySignals(context.Context, *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error)
 	GetBuySignals(context.Context, common.Pair, common.Interval, domain.Name, *time.Time, int) (*[]domain.Details, bool, *time.Time, error)
 	UpsertBuySignals(context.Context, domain.Details) (*[]domain.Details, error)
+	GetBuySignalsByIDs(context.Context, []domain.ID) (*[]domain.Details, error)
+	// DetectBuySignals runs a detector over the stored candles and optionally persists the detected signals
+	DetectBuySignals(context.Context, DetectRequest) (*[]domain.Details, error)
 }
 
 type Persistence interface {
-	InsertBuySignals(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// InsertBuySignals inserts the buy signals with ON CONFLICT DO NOTHING semantics
+	// A duplicate result holds the ID of the existing buy signal
+	InsertBuySignals(context.Context, *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error)
 	UpsertBuySignals(context.Context, domain.Details) (*[]domain.Details, error)
 	QueryBuySignals(context.Context, common.Pair, common.Interval, domain.Name, *time.Time, int) (*[]domain.Details, bool, *time.Time, error)
+	QueryBuySignalsByIDs(context.Context, []domain.ID) (*[]domain.Details, error)
 }
@@ -3,25 +3,69 @@ package candles
 import (
 	"context"
 	"fmt"
+	"iter"
 	"time"
 
 	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
 	domain "github.com/sopial42/bifrost/pkg/domains/candles"
 	"github.com/sopial42/bifrost/pkg/domains/common"
 )
 
+// ingestChunkSize is the count of candles loaded at once by IngestCandles, it bounds the memory of a stream
+const ingestChunkSize = 50000
+
 type candlesService struct {
 	persistence Persistence
+	validation  domain.Validation
 }
 
-func NewCandlesService(persistence Persistence) Service {
+func NewCandlesService(persistence Persistence, validation domain.Validation) Service {
 	return &candlesService{
 		persistence: persistence,
+		validation:  validation,
+	}
+}
+
+// check sets the issues of the candle, and adds their violations unless the validation flags them
+func (p *candlesService) check(candle *domain.Candle, violations *appErrors.FieldViolations, index int) {
+	candleViolations := appErrors.FieldViolations{}
+	candle.Issues = candle.Check(&candleViolations, appErrors.Pointer("candles", index))
+	if p.validation != domain.ValidationFlag {
+		*violations = append(*violations, candleViolations...)
 	}
 }
 
 func (p *candlesService) CreateCandles(ctx context.Context, candles *[]domain.Candle) (*[]domain.Candle, error) {
-	candles, err := p.persistence.InsertCandles(ctx, candles)
+	ctx, span := tracing.Start(ctx, "candles.CreateCandles")
+	defer span.End()
+
+	if candles == nil {
+		return &[]domain.Candle{}, nil
+	}
+
+	checked := make([]domain.Candle, len(*candles))
+	violations := appErrors.FieldViolations{}
+	flagged := 0
+	for i, candle := range *candles {
+		p.check(&candle, &violations, i)
+		if len(candle.Issues) > 0 {
+			flagged++
+		}
+
+		checked[i] = candle
+	}
+
+	if err := violations.Err("inconsistent candles"); err != nil {
+		return &[]domain.Candle{}, err
+	}
+
+	if flagged > 0 {
+		logger.GetLogger(ctx).Warnf("Flagged %d inconsistent candles out of %d", flagged, len(checked))
+	}
+
+	candles, err := p.persistence.InsertCandles(ctx, &checked)
 	if err != nil {
 		return &[]domain.Candle{}, fmt.Errorf("unable to insert candles: %w", err)
 	}
@@ -29,7 +73,106 @@ func (p *candlesService) CreateCandles(ctx context.Context, candles *[]domain.Ca
 	return candles, nil
 }
 
+func (p *candlesService) IngestCandles(ctx context.Context, candles iter.Seq2[domain.Candle, error]) (*domain.IngestResult, error) {
+	ctx, span := tracing.Start(ctx, "candles.IngestCandles")
+	defer span.End()
+
+	log := logger.GetLogger(ctx)
+	result := &domain.IngestResult{}
+	chunk := make([]domain.Candle, 0, ingestChunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		inserted, err := p.persistence.CopyCandles(ctx, &chunk)
+		if err != nil {
+			return fmt.Errorf("unable to copy candles: %w", err)
+		}
+
+		result.Inserted += inserted
+		result.Skipped += len(chunk) - inserted
+		chunk = chunk[:0]
+		log.Debugf("Ingested %d candles, %d skipped", result.Inserted, result.Skipped)
+		return nil
+	}
+
+	index := 0
+	for candle, err := range candles {
+		if err != nil {
+			return result, fmt.Errorf("unable to read candles: %w", err)
+		}
+
+		violations := appErrors.FieldViolations{}
+		p.check(&candle, &violations, index)
+		if err := violations.Err("inconsistent candles"); err != nil {
+			return result, err
+		}
+
+		if len(candle.Issues) > 0 {
+			result.Flagged++
+		}
+
+		index++
+		chunk = append(chunk, candle)
+		if len(chunk) == ingestChunkSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (p *candlesService) CorrectCandles(ctx context.Context, correction domain.Correction) (*domain.CorrectionResult, error) {
+	ctx, span := tracing.Start(ctx, "candles.CorrectCandles")
+	defer span.End()
+
+	checked := make([]domain.Candle, len(correction.Candles))
+	violations := appErrors.FieldViolations{}
+	for i, candle := range correction.Candles {
+		p.check(&candle, &violations, i)
+		checked[i] = candle
+	}
+
+	if err := violations.Err("inconsistent candles"); err != nil {
+		return nil, err
+	}
+
+	result, err := p.persistence.CorrectCandles(ctx, correction.Source, &checked)
+	if err != nil {
+		return nil, fmt.Errorf("unable to correct candles: %w", err)
+	}
+
+	logger.GetLogger(ctx).Infof("Corrected %d candles from %s, %d inserted, %d unchanged, %d stale positions", result.Corrected, correction.Source, result.Inserted, result.Unchanged, result.StalePositions)
+	return result, nil
+}
+
+func (p *candlesService) GetCandleRevisions(ctx context.Context, id domain.ID) (*[]domain.Revision, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetCandleRevisions")
+	defer span.End()
+
+	revisions, err := p.persistence.QueryRevisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get candle revisions: %w", err)
+	}
+
+	if revisions == nil {
+		return nil, appErrors.NewNotFound("candle not found")
+	}
+
+	return revisions, nil
+}
+
 func (p *candlesService) GetSurroundingDates(ctx context.Context, pair common.Pair, interval common.Interval) (*domain.Date, *domain.Date, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetSurroundingDates")
+	defer span.End()
+
 	firstDate, lastDate, err := p.persistence.QuerySurroundingDates(ctx, pair, interval)
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to get surrounding dates: %w", err)
@@ -42,6 +185,9 @@ func (p *candlesService) GetSurroundingDates(ctx context.Context, pair common.Pa
 }
 
 func (p *candlesService) GetCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, lastDate *time.Time, limit int) (*[]domain.Candle, bool, *time.Time, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetCandles")
+	defer span.End()
+
 	candles, hasMore, nextCursor, err := p.persistence.QueryCandles(ctx, pair, interval, startDate, lastDate, limit)
 	if err != nil {
 		return nil, false, nil, fmt.Errorf("unable to get candles: %w", err)
@@ -51,6 +197,9 @@ func (p *candlesService) GetCandles(ctx context.Context, pair common.Pair, inter
 }
 
 func (p *candlesService) GetCandlesFromLastDate(ctx context.Context, pair common.Pair, interval common.Interval, lastDate *time.Time, limit int) (*[]domain.Candle, bool, *time.Time, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetCandlesFromLastDate")
+	defer span.End()
+
 	candles, hasMore, nextCursor, err := p.persistence.QueryCandlesFromLastDate(ctx, pair, interval, lastDate, limit)
 	if err != nil {
 		return nil, false, nil, fmt.Errorf("unable to get candles: %w", err)
@@ -59,7 +208,42 @@ func (p *candlesService) GetCandlesFromLastDate(ctx context.Context, pair common
 	return candles, hasMore, nextCursor, nil
 }
 
+func (p *candlesService) ExportCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time, fn func(domain.Candle) error) error {
+	ctx, span := tracing.Start(ctx, "candles.ExportCandles")
+	defer span.End()
+
+	if err := p.persistence.StreamCandles(ctx, pair, interval, startDate, endDate, fn); err != nil {
+		return fmt.Errorf("unable to export candles: %w", err)
+	}
+
+	return nil
+}
+
+func (p *candlesService) GetQualityReport(ctx context.Context, filter domain.QualityFilter, limit int) (*domain.QualityReport, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetQualityReport")
+	defer span.End()
+
+	report := &domain.QualityReport{Issues: map[domain.Issue]int{}, Candles: []domain.Candle{}}
+	err := p.persistence.StreamSuspiciousCandles(ctx, filter, func(candle domain.Candle) error {
+		// The stored issues are the ones of the check at creation, the candle is checked again as the older candles were not
+		candle.Issues = candle.Check(&appErrors.FieldViolations{}, "")
+		if len(candle.Issues) > 0 {
+			report.Add(candle, limit)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to check candles: %w", err)
+	}
+
+	return report, nil
+}
+
 func (p *candlesService) GetCandlesThatHitTPOrSL(ctx context.Context, pair common.Pair, buyDate domain.Date, tp float64, sl float64) (*domain.Candle, *domain.Candle, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetCandlesThatHitTPOrSL")
+	defer span.End()
+
 	tpCandle, slCandle, err := p.persistence.QueryCandlesThatHitTPOrSL(ctx, pair, buyDate, tp, sl)
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to get candles that hit the TP or the SL: %w", err)
@@ -69,6 +253,9 @@ func (p *candlesService) GetCandlesThatHitTPOrSL(ctx context.Context, pair commo
 }
 
 func (p *candlesService) UpdateCandlesRSI(ctx context.Context, candles *[]domain.Candle) (*[]domain.Candle, error) {
+	ctx, span := tracing.Start(ctx, "candles.UpdateCandlesRSI")
+	defer span.End()
+
 	candles, err := p.persistence.UpdateCandlesRSI(ctx, candles)
 	if err != nil {
 		return &[]domain.Candle{}, fmt.Errorf("unable to update candles: %w", err)
@@ -83,6 +270,9 @@ type PriceResponse map[common.Pair]map[PriceRequestDate]float64
 type PriceRequestDate string
 
 func (p *candlesService) GetCandlesMinuteClosePricesByDate(ctx context.Context, pricesRequest PriceRequest) (PriceResponse, error) {
+	ctx, span := tracing.Start(ctx, "candles.GetCandlesMinuteClosePricesByDate")
+	defer span.End()
+
 	response := make(PriceResponse)
 	for pair, dates := range pricesRequest {
 		for _, date := range dates {
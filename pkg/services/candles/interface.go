@@ -2,6 +2,7 @@ package candles
 
 import (
 	"context"
+	"iter"
 	"time"
 
 	domain "github.com/sopial42/bifrost/pkg/domains/candles"
@@ -9,11 +10,27 @@ import (
 )
 
 type Service interface {
+	// CreateCandles checks the integrity of the candles, see domain.Candle.Check, those with issues are refused or flagged after the validation
+	// The violations fields are /candles/<index>/<field>
 	CreateCandles(context.Context, *[]domain.Candle) (*[]domain.Candle, error)
+	// IngestCandles bulk loads the candles by chunks, the existing ones are skipped
+	// The chunks loaded before an error are kept, the result counts them
+	// The candles are checked as by CreateCandles, the index of a violation is the one of the candle in the stream
+	IngestCandles(context.Context, iter.Seq2[domain.Candle, error]) (*domain.IngestResult, error)
+	// CorrectCandles overwrites the prices of the existing candles and inserts the missing ones, the candles are checked as by CreateCandles
+	// The replaced versions are kept as revisions, and the positions computed on a revised 1m candle are marked stale
+	CorrectCandles(context.Context, domain.Correction) (*domain.CorrectionResult, error)
+	// GetCandleRevisions returns the replaced versions of the candle, newest first
+	GetCandleRevisions(context.Context, domain.ID) (*[]domain.Revision, error)
 	GetSurroundingDates(context.Context, common.Pair, common.Interval) (*domain.Date, *domain.Date, error)
 	GetCandles(context.Context, common.Pair, common.Interval, *time.Time, *time.Time, int) (*[]domain.Candle, bool, *time.Time, error)
 	// GetCandlesFromLastDate reverse the cursor, the next_cursor has to be used as last_date argument
 	GetCandlesFromLastDate(context.Context, common.Pair, common.Interval, *time.Time, int) (candles *[]domain.Candle, hasMore bool, nextCursor *time.Time, err error)
+	// ExportCandles calls fn on the candles of the range oldest first, without holding them in memory
+	// It stops on the first error of fn
+	ExportCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time, fn func(domain.Candle) error) error
+	// GetQualityReport checks the stored candles of the filter, the report lists up to limit candles with issues
+	GetQualityReport(ctx context.Context, filter domain.QualityFilter, limit int) (*domain.QualityReport, error)
 	GetCandlesThatHitTPOrSL(ctx context.Context, pair common.Pair, buyDate domain.Date, tp float64, sl float64) (*domain.Candle, *domain.Candle, error)
 	UpdateCandlesRSI(context.Context, *[]domain.Candle) (*[]domain.Candle, error)
 	GetCandlesMinuteClosePricesByDate(context.Context, PriceRequest) (PriceResponse, error)
@@ -21,8 +38,20 @@ type Service interface {
 
 type Persistence interface {
 	InsertCandles(context.Context, *[]domain.Candle) (*[]domain.Candle, error)
+	// CopyCandles bulk loads the candles, the existing ones are skipped, it returns the count of inserted candles
+	CopyCandles(context.Context, *[]domain.Candle) (int, error)
 	UpdateCandlesRSI(context.Context, *[]domain.Candle) (*[]domain.Candle, error)
+	// CorrectCandles upserts the candles at once, the changed ones keep their replaced version with the source of the correction
+	// The computed positions of all the workspaces whose ratio may depend on an inserted or a changed 1m candle are marked stale
+	CorrectCandles(ctx context.Context, source string, candles *[]domain.Candle) (*domain.CorrectionResult, error)
+	// QueryRevisions returns the revisions of the candle newest first, nil when the candle does not exist
+	QueryRevisions(context.Context, domain.ID) (*[]domain.Revision, error)
 	QueryCandles(context.Context, common.Pair, common.Interval, *time.Time, *time.Time, int) (*[]domain.Candle, bool, *time.Time, error)
+	// StreamCandles calls fn on the candles of the range oldest first, one row at a time
+	StreamCandles(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time, fn func(domain.Candle) error) error
+	// StreamSuspiciousCandles calls fn on the candles of the filter that are flagged or may have issues, ordered by pair, interval and date
+	// It may call fn on candles without issue, the caller checks them
+	StreamSuspiciousCandles(ctx context.Context, filter domain.QualityFilter, fn func(domain.Candle) error) error
 	QueryCandlesFromLastDate(context.Context, common.Pair, common.Interval, *time.Time, int) (*[]domain.Candle, bool, *time.Time, error)
 	QueryCandlesPriceByDate(context.Context, common.Pair, domain.Date) (float64, error)
 	QueryCandlesThatHitTPOrSL(context.Context, common.Pair, domain.Date, float64, float64) (*domain.Candle, *domain.Candle, error)
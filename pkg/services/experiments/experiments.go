@@ -0,0 +1,347 @@
+package experiments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	domain "github.com/sopial42/bifrost/pkg/domains/experiments"
+	posDomain "github.com/sopial42/bifrost/pkg/domains/positions"
+	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
+	positionsSVC "github.com/sopial42/bifrost/pkg/services/positions"
+)
+
+type experimentsService struct {
+	persistence Persistence
+	buySignals  buySignalsSVC.Service
+	positions   positionsSVC.Service
+	// running tracks the experiments run in the background, to wait for them on shutdown
+	running sync.WaitGroup
+	// interrupt cancels the experiments run in the background, once the shutdown stops waiting for them
+	interrupted context.Context
+	interrupt   context.CancelFunc
+}
+
+// interruptTimeout bounds the recording of the interrupted experiments, their runs stop on the cancellation
+const interruptTimeout = 5 * time.Second
+
+func NewExperimentsService(persistence Persistence, buySignals buySignalsSVC.Service, positions positionsSVC.Service) Service {
+	interrupted, interrupt := context.WithCancel(context.Background())
+	return &experimentsService{
+		persistence: persistence,
+		buySignals:  buySignals,
+		positions:   positions,
+		interrupted: interrupted,
+		interrupt:   interrupt,
+	}
+}
+
+// grid is the request with its param ranges expanded
+type grid struct {
+	request         domain.Request
+	buySignalParams map[bsDomain.Name][]map[string]any
+	positionParams  map[posDomain.Name][]map[string]any
+	ratios          []posDomain.WinLossRatio
+}
+
+func (e *experimentsService) RunExperiment(ctx context.Context, request domain.Request) (*domain.Experiment, error) {
+	ctx, span := tracing.Start(ctx, "experiments.RunExperiment")
+	defer span.End()
+
+	g, err := expandRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	experiment, err := e.persistence.InsertExperiment(ctx, &domain.Experiment{
+		Status:    domain.StatusRunning,
+		Request:   request,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create experiment: %w", err)
+	}
+
+	log := logger.GetLogger(ctx).WithField(domain.LoggerKeyID, experiment.ID.String())
+	ctx = logger.SetLoggerToContext(ctx, log)
+
+	// The grid outlives the request, it keeps its workspace and logger but not its cancellation, it is cancelled by the shutdown instead
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(e.interrupted, cancel)
+	running := *experiment
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		defer stop()
+		defer cancel()
+		e.runInBackground(runCtx, g, &running)
+	}()
+
+	log.Infof("Experiment started")
+	return experiment, nil
+}
+
+// runInBackground runs the grid and stores the ranked results, or the error, on the experiment
+// An interrupted run is stored as failed, with a context not cancelled so the update still reaches the database
+func (e *experimentsService) runInBackground(ctx context.Context, g *grid, experiment *domain.Experiment) {
+	ctx, span := tracing.Start(ctx, "experiments.runInBackground")
+	defer span.End()
+
+	log := logger.GetLogger(ctx)
+	results, runErr := e.run(ctx, g)
+	finishedAt := time.Now().UTC()
+	experiment.FinishedAt = &finishedAt
+
+	if runErr == nil {
+		domain.RankResults(results)
+		runErr = e.persistence.InsertResults(ctx, *experiment.ID, &results)
+	}
+
+	if ctx.Err() != nil {
+		if runErr != nil {
+			runErr = errors.New(domain.ErrorInterrupted)
+		}
+
+		updateCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), interruptTimeout)
+		defer cancel()
+		ctx = updateCtx
+	}
+
+	if runErr != nil {
+		log.Errorf("Experiment failed: %v", runErr)
+		experiment.Status = domain.StatusFailed
+		experiment.Error = runErr.Error()
+		if err := e.persistence.UpdateExperiment(ctx, experiment); err != nil {
+			log.Errorf("unable to set experiment as failed: %v", err)
+		}
+
+		return
+	}
+
+	experiment.Status = domain.StatusDone
+	experiment.Results = results
+	if err := e.persistence.UpdateExperiment(ctx, experiment); err != nil {
+		log.Errorf("unable to set experiment as done: %v", err)
+		return
+	}
+
+	log.Infof("Experiment done with %d results", len(results))
+}
+
+func (e *experimentsService) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	// The runs stop on the cancellation, and record their experiment as failed
+	e.interrupt()
+	select {
+	case <-done:
+		return fmt.Errorf("experiments interrupted: %w", ctx.Err())
+	case <-time.After(interruptTimeout + time.Second):
+		return fmt.Errorf("experiments interrupted and not recorded as failed: %w", ctx.Err())
+	}
+}
+
+func (e *experimentsService) FailInterrupted(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "experiments.FailInterrupted")
+	defer span.End()
+
+	count, err := e.persistence.FailRunningExperiments(ctx, domain.ErrorInterrupted, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("unable to fail the interrupted experiments: %w", err)
+	}
+
+	if count > 0 {
+		logger.GetLogger(ctx).Warnf("%d experiments interrupted by the previous shutdown set as failed", count)
+	}
+
+	return nil
+}
+
+func (e *experimentsService) GetExperiment(ctx context.Context, id domain.ID) (*domain.Experiment, error) {
+	ctx, span := tracing.Start(ctx, "experiments.GetExperiment")
+	defer span.End()
+
+	experiment, err := e.persistence.GetExperimentByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get experiment: %w", err)
+	}
+
+	if experiment == nil {
+		return nil, appErrors.NewNotFound("experiment not found")
+	}
+
+	return experiment, nil
+}
+
+func expandRequest(request domain.Request) (*grid, error) {
+	if len(request.Pairs) == 0 || len(request.Intervals) == 0 || len(request.BuySignalNames) == 0 || len(request.PositionNames) == 0 {
+		return nil, appErrors.NewInvalidInput("pairs, intervals, buy_signal_names and position_names are required", nil)
+	}
+
+	g := &grid{
+		request:         request,
+		buySignalParams: make(map[bsDomain.Name][]map[string]any),
+		positionParams:  make(map[posDomain.Name][]map[string]any),
+	}
+
+	for _, name := range request.BuySignalNames {
+		sets, err := request.BuySignalParams[name].Expand()
+		if err != nil {
+			return nil, appErrors.NewInvalidInput(fmt.Sprintf("invalid %s params", name), err)
+		}
+
+		for _, set := range sets {
+			if _, err := bsDomain.NewDetector(name, set); err != nil {
+				return nil, appErrors.NewInvalidInput(fmt.Sprintf("invalid %s params", name), err)
+			}
+		}
+
+		g.buySignalParams[name] = sets
+	}
+
+	for _, name := range request.PositionNames {
+		sets, err := request.PositionParams[name].Expand()
+		if err != nil {
+			return nil, appErrors.NewInvalidInput(fmt.Sprintf("invalid %s params", name), err)
+		}
+
+		for _, set := range sets {
+			if _, err := posDomain.NewGenerator(name, set); err != nil {
+				return nil, appErrors.NewInvalidInput(fmt.Sprintf("invalid %s params", name), err)
+			}
+		}
+
+		g.positionParams[name] = sets
+	}
+
+	ratios, err := posDomain.ParseWinLossRatios(request.WinLossRatios)
+	if err != nil {
+		return nil, appErrors.NewInvalidInput("invalid winloss ratios", err)
+	}
+
+	g.ratios = ratios
+	return g, nil
+}
+
+func (e *experimentsService) run(ctx context.Context, g *grid) ([]domain.Result, error) {
+	log := logger.GetLogger(ctx)
+	results := make([]domain.Result, 0)
+	scenarios := posDomain.GetScenarios(g.request.Pairs, g.request.Intervals, g.request.BuySignalNames, g.request.PositionNames)
+
+	log.Infof("Run experiment over %d scenarios", len(*scenarios))
+	for _, scenario := range *scenarios {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, bsParams := range g.buySignalParams[scenario.BuySignalName] {
+			detected, err := e.buySignals.DetectBuySignals(ctx, buySignalsSVC.DetectRequest{
+				Name:      scenario.BuySignalName,
+				Params:    bsParams,
+				Pair:      scenario.Pair,
+				Interval:  scenario.Interval,
+				StartDate: g.request.StartDate,
+				EndDate:   g.request.EndDate,
+				Persist:   true,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("unable to detect buy signals: %w", err)
+			}
+
+			if detected == nil || len(*detected) == 0 {
+				log.Debugf("no buy signal detected for scenario %+v and params %v", scenario, bsParams)
+				continue
+			}
+
+			ids := make([]bsDomain.ID, len(*detected))
+			for i, bs := range *detected {
+				ids[i] = *bs.ID
+			}
+
+			for _, positionName := range scenario.PositionNames {
+				for _, posParams := range g.positionParams[positionName] {
+					scenarioResults, err := e.runPositionStrategy(ctx, g, ids, positionName, posParams)
+					if err != nil {
+						return nil, err
+					}
+
+					for i := range scenarioResults {
+						scenarioResults[i].Pair = scenario.Pair
+						scenarioResults[i].Interval = scenario.Interval
+						scenarioResults[i].BuySignalFullname = (*detected)[0].Fullname
+					}
+
+					results = append(results, scenarioResults...)
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// runPositionStrategy generates and computes the positions of the buy signals
+// It returns one result per winloss ratio
+func (e *experimentsService) runPositionStrategy(ctx context.Context, g *grid, ids []bsDomain.ID, name posDomain.Name, params map[string]any) ([]domain.Result, error) {
+	generator, err := posDomain.NewGenerator(name, params)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build position generator: %w", err)
+	}
+
+	// The positions generated by a previous experiment are skipped by the insert, and all of them are fetched back below
+	_, err = e.positions.GeneratePositions(ctx, positionsSVC.GenerateRequest{
+		BuySignalIDs:  ids,
+		Name:          name,
+		Params:        params,
+		WinLossRatios: g.ratios,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate positions: %w", err)
+	}
+
+	positions, err := e.positions.GetPositionsByBuySignals(ctx, ids, generator.Fullname())
+	if err != nil {
+		return nil, err
+	}
+
+	computed, err := e.positions.ComputeRatios(ctx, positions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute positions ratios: %w", err)
+	}
+
+	results := make([]domain.Result, len(g.ratios))
+	for i, ratio := range g.ratios {
+		ratioPositions := make([]posDomain.Details, 0)
+		for _, p := range *computed {
+			if p.WinlossRatio != nil && math.Abs(float64(*p.WinlossRatio-ratio)) < 1e-9 {
+				ratioPositions = append(ratioPositions, p)
+			}
+		}
+
+		r := ratio
+		results[i] = domain.Result{
+			PositionFullname: generator.Fullname(),
+			WinlossRatio:     &r,
+			Performance:      posDomain.ComputePerformance(ratioPositions),
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,132 @@
+package experiments
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	bsDomain "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
+	domain "github.com/sopial42/bifrost/pkg/domains/experiments"
+	posDomain "github.com/sopial42/bifrost/pkg/domains/positions"
+	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
+)
+
+// fakePersistence keeps the experiments in memory
+type fakePersistence struct {
+	mu          sync.Mutex
+	experiments map[domain.ID]domain.Experiment
+}
+
+func (p *fakePersistence) InsertExperiment(ctx context.Context, experiment *domain.Experiment) (*domain.Experiment, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := domain.ID(uuid.New())
+	stored := *experiment
+	stored.ID = &id
+	p.experiments[id] = stored
+	return &stored, nil
+}
+
+func (p *fakePersistence) UpdateExperiment(ctx context.Context, experiment *domain.Experiment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.experiments[*experiment.ID] = *experiment
+	return nil
+}
+
+func (p *fakePersistence) InsertResults(ctx context.Context, id domain.ID, results *[]domain.Result) error {
+	return nil
+}
+
+func (p *fakePersistence) GetExperimentByID(ctx context.Context, id domain.ID) (*domain.Experiment, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	experiment, found := p.experiments[id]
+	if !found {
+		return nil, nil
+	}
+
+	return &experiment, nil
+}
+
+func (p *fakePersistence) FailRunningExperiments(ctx context.Context, reason string, finishedAt time.Time) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count := 0
+	for id, experiment := range p.experiments {
+		if experiment.Status == domain.StatusRunning {
+			experiment.Status, experiment.Error, experiment.FinishedAt = domain.StatusFailed, reason, &finishedAt
+			p.experiments[id] = experiment
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// blockingBuySignals detects until its context is cancelled
+type blockingBuySignals struct {
+	buySignalsSVC.Service
+}
+
+func (b blockingBuySignals) DetectBuySignals(ctx context.Context, request buySignalsSVC.DetectRequest) (*[]bsDomain.Details, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWaitInterruptsTheRunningExperiments(t *testing.T) {
+	persistence := &fakePersistence{experiments: map[domain.ID]domain.Experiment{}}
+	service := NewExperimentsService(persistence, blockingBuySignals{}, nil)
+
+	// The request is cancelled once answered, the experiment keeps running
+	ctx, cancel := context.WithCancel(context.Background())
+	experiment, err := service.RunExperiment(ctx, domain.Request{
+		Pairs:          []common.Pair{common.BTCUSDC},
+		Intervals:      []common.Interval{common.M1},
+		BuySignalNames: []bsDomain.Name{bsDomain.MorningStarName},
+		PositionNames:  []posDomain.Name{posDomain.PercentageName},
+	})
+	cancel()
+	if err != nil || experiment.Status != domain.StatusRunning {
+		t.Fatalf("RunExperiment() = %+v, %v, want a running experiment", experiment, err)
+	}
+
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWait()
+	if err := service.Wait(waitCtx); err == nil {
+		t.Errorf("Wait() error = nil, want the experiments interrupted")
+	}
+
+	stored, _ := persistence.GetExperimentByID(context.Background(), *experiment.ID)
+	if stored.Status != domain.StatusFailed || stored.Error != domain.ErrorInterrupted || stored.FinishedAt == nil {
+		t.Errorf("experiment = %+v, want it failed as interrupted", stored)
+	}
+}
+
+func TestFailInterrupted(t *testing.T) {
+	id := domain.ID(uuid.New())
+	persistence := &fakePersistence{experiments: map[domain.ID]domain.Experiment{
+		id: {ID: &id, Status: domain.StatusRunning},
+	}}
+
+	if err := NewExperimentsService(persistence, nil, nil).FailInterrupted(context.Background()); err != nil {
+		t.Fatalf("FailInterrupted() error = %v", err)
+	}
+
+	stored, _ := persistence.GetExperimentByID(context.Background(), id)
+	if stored.Status != domain.StatusFailed || stored.Error != domain.ErrorInterrupted {
+		t.Errorf("experiment = %+v, want the experiment left running set as failed", stored)
+	}
+}
@@ -0,0 +1,31 @@
+package experiments
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/experiments"
+)
+
+type Service interface {
+	// RunExperiment validates the grid and returns the running experiment, the grid is run in the background
+	// It generates buy signals and positions for every scenario, computes their ratios and stores the ranked results
+	RunExperiment(context.Context, domain.Request) (*domain.Experiment, error)
+	// GetExperiment returns the experiment, with its ranked results once done
+	GetExperiment(context.Context, domain.ID) (*domain.Experiment, error)
+	// Wait waits for the experiments running in the background until the context is done, then interrupts them
+	// The interrupted experiments are set as failed
+	Wait(context.Context) error
+	// FailInterrupted sets the experiments left running by a previous process as failed, it is called on start
+	// A process only runs its own experiments, so the instances are expected to share no database
+	FailInterrupted(context.Context) error
+}
+
+type Persistence interface {
+	InsertExperiment(context.Context, *domain.Experiment) (*domain.Experiment, error)
+	UpdateExperiment(context.Context, *domain.Experiment) error
+	InsertResults(context.Context, domain.ID, *[]domain.Result) error
+	GetExperimentByID(context.Context, domain.ID) (*domain.Experiment, error)
+	// FailRunningExperiments sets the running experiments of all the workspaces as failed with the error, and returns their count
+	FailRunningExperiments(ctx context.Context, reason string, finishedAt time.Time) (int, error)
+}
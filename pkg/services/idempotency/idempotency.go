@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
+	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+	"github.com/sopial42/bifrost/pkg/domains/workspaces"
+)
+
+// purgeInterval is the minimal delay between two deletions of the expired records
+const purgeInterval = time.Minute
+
+type idempotencyService struct {
+	persistence Persistence
+	ttl         time.Duration
+	// lockTimeout bounds a request in progress, the key of a request that never completed, eg. on a crashed instance, can then be reused
+	lockTimeout time.Duration
+	// lastPurge is the unix nano time of the last deletion of the expired records
+	lastPurge atomic.Int64
+}
+
+// NewIdempotencyService replays the completed requests during the ttl, the requests in progress hold their key up to the lockTimeout
+func NewIdempotencyService(persistence Persistence, ttl time.Duration, lockTimeout time.Duration) Service {
+	return &idempotencyService{
+		persistence: persistence,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+	}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, key domain.Key, fingerprint string) (*domain.Record, bool, error) {
+	ctx, span := tracing.Start(ctx, "idempotency.Begin")
+	defer span.End()
+
+	now := time.Now().UTC()
+	s.purgeExpired(ctx, now)
+
+	record, reserved, err := s.persistence.ReserveKey(ctx, &domain.Record{
+		Workspace:   workspaces.GetWorkspaceFromContext(ctx),
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		Token:       uuid.New(),
+		ExpiresAt:   now.Add(s.lockTimeout),
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to reserve idempotency key: %w", err)
+	}
+
+	if reserved {
+		return record, true, nil
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, false, appErrors.NewInvalidInput("idempotency key already used by another request", nil)
+	}
+
+	if !record.IsCompleted() {
+		return nil, false, appErrors.NewAlreadyExists("a request with the idempotency key is in progress")
+	}
+
+	logger.GetLogger(ctx).WithField(domain.LoggerKeyIdempotencyKey, key).Debugf("Replaying the %d response of %s", record.Status, record.CreatedAt)
+	return record, false, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, reservation *domain.Record, status int, contentType string, body []byte) error {
+	ctx, span := tracing.Start(ctx, "idempotency.Complete")
+	defer span.End()
+
+	stored, err := s.persistence.CompleteKey(ctx, &domain.Record{
+		Workspace:   reservation.Workspace,
+		Key:         reservation.Key,
+		Status:      status,
+		ContentType: contentType,
+		Body:        body,
+		Token:       reservation.Token,
+		ExpiresAt:   time.Now().UTC().Add(s.ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to complete idempotency key: %w", err)
+	}
+
+	if !stored {
+		logger.GetLogger(ctx).WithField(domain.LoggerKeyIdempotencyKey, reservation.Key).Warnf("The request outlived its %s lock and the key was reserved again, its %d response is not stored", s.lockTimeout, status)
+	}
+
+	return nil
+}
+
+func (s *idempotencyService) Release(ctx context.Context, reservation *domain.Record) error {
+	ctx, span := tracing.Start(ctx, "idempotency.Release")
+	defer span.End()
+
+	if err := s.persistence.DeleteKey(ctx, reservation); err != nil {
+		return fmt.Errorf("unable to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// purgeExpired deletes the expired records at most once per purgeInterval, a failure only delays it
+func (s *idempotencyService) purgeExpired(ctx context.Context, now time.Time) {
+	last := s.lastPurge.Load()
+	if now.UnixNano()-last < int64(purgeInterval) || !s.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	log := logger.GetLogger(ctx)
+	count, err := s.persistence.DeleteExpiredKeys(ctx, now)
+	if err != nil {
+		log.Warnf("unable to delete the expired idempotency keys: %v", err)
+		return
+	}
+
+	if count > 0 {
+		log.Debugf("Deleted %d expired idempotency keys", count)
+	}
+}
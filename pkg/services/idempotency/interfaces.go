@@ -0,0 +1,33 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/sopial42/bifrost/pkg/domains/idempotency"
+)
+
+type Service interface {
+	// Begin reserves the key of the workspace for the request, and returns the record of the key and whether it is reserved
+	// The record of a completed request with the same fingerprint is returned not reserved, to be replayed
+	// A request in progress with the key is an already exists error, another request with the key an invalid input error
+	Begin(ctx context.Context, key domain.Key, fingerprint string) (*domain.Record, bool, error)
+	// Complete stores the response of the reservation, it is replayed until the key expires
+	// The response is dropped if the lock expired and the key was reserved again by a retry
+	Complete(ctx context.Context, reservation *domain.Record, status int, contentType string, body []byte) error
+	// Release forgets the reservation so the request can be retried, eg. after a server error
+	Release(ctx context.Context, reservation *domain.Record) error
+}
+
+type Persistence interface {
+	// ReserveKey inserts the record unless an unexpired record of the workspace has its key
+	// Returns the stored record of the key, and whether it is the given one
+	ReserveKey(context.Context, *domain.Record) (*domain.Record, bool, error)
+	// CompleteKey stores the response and the new expiration of the record if its token still holds the key
+	// Returns whether the record was stored
+	CompleteKey(context.Context, *domain.Record) (bool, error)
+	// DeleteKey deletes the record if its token still holds the key
+	DeleteKey(context.Context, *domain.Record) error
+	// DeleteExpiredKeys deletes the records of all the workspaces expired at the date, and returns their count
+	DeleteExpiredKeys(context.Context, time.Time) (int, error)
+}
@@ -2,22 +2,52 @@ package positions
 
 import (
 	"context"
+	"time"
 
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
+	"github.com/sopial42/bifrost/pkg/domains/common"
 	domain "github.com/sopial42/bifrost/pkg/domains/positions"
 )
 
 type Service interface {
-	CreatePositions(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// CreatePositions creates the positions, the existing ones are ignored
+	// Returns the created positions, and the outcome of each position in input order
+	// A position of an unknown buy signal is invalid, its violations are relative to the position
+	CreatePositions(context.Context, *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error)
 	ComputeAllRatios(context.Context) (int, error)
 	ComputeRatio(context.Context, domain.ID) (*domain.Details, error)
 	CreatePositionsWithBuySignals(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// GeneratePositions computes the positions of a position strategy for a set of buy signals
+	// All generated positions are created at once, the violations fields of the unknown or failed buy signals are /buy_signal_ids/<index>
+	GeneratePositions(context.Context, GenerateRequest) (*[]domain.Details, error)
+	// GetPositionsByBuySignals returns the positions of the given buy signals, with their buy signal
+	// An empty fullname returns all the positions of the buy signals
+	GetPositionsByBuySignals(context.Context, []buySignals.ID, domain.Fullname) (*[]domain.Details, error)
+	// ComputeRatios computes and stores the ratio of the positions that have none, or a stale one
+	// Positions that hit neither the TP nor the SL are returned without ratio
+	ComputeRatios(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// GetComputedPositions returns the positions with a ratio whose buy signal is in the given range, sorted by buy signal date
+	GetComputedPositions(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time) (*[]domain.Details, error)
+	// ExportBuySignalsPositions calls fn on the buy signals joined with their positions, sorted by buy date, without holding them in memory
+	// It stops on the first error of fn
+	ExportBuySignalsPositions(ctx context.Context, filter domain.ExportFilter, fn func(domain.BuySignalPosition) error) error
 }
 
 type Persistence interface {
-	InsertPositions(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// InsertPositions inserts the positions with ON CONFLICT DO NOTHING semantics
+	// A duplicate result holds the ID of the existing position
+	InsertPositions(context.Context, *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error)
+	// InsertRatios updates the ratio and the stale flag of the positions
 	InsertRatios(context.Context, *[]domain.Details) (*[]domain.Details, error)
+	// GetPositionsWithNoRatio returns the positions to compute, without ratio or with a stale one
 	GetPositionsWithNoRatio(ctx context.Context, cursor *int64, limit int) (positions *[]domain.Details, hasMore bool, nextCursor *int64, err error)
 	GetPositionsWithNoRatioCount(ctx context.Context) (count int, err error)
 	GetPositionByID(ctx context.Context, id domain.ID) (*domain.Details, error)
+	// UpsertPosition updates the TP and SL of the position with the same buy signal, fullname and winloss ratio, or inserts it
+	// A position without winloss ratio never conflicts, it is always inserted
 	UpsertPosition(ctx context.Context, position *domain.Details) (*domain.Details, error)
+	QueryPositionsByBuySignalIDs(ctx context.Context, ids []buySignals.ID, fullname domain.Fullname) (*[]domain.Details, error)
+	QueryComputedPositions(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time) (*[]domain.Details, error)
+	// StreamBuySignalsPositions calls fn on the rows of the v_buy_signals_positions view of the workspace, one row at a time
+	StreamBuySignalsPositions(ctx context.Context, filter domain.ExportFilter, fn func(domain.BuySignalPosition) error) error
 }
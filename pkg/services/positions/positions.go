@@ -3,9 +3,15 @@ package positions
 import (
 	"context"
 	"fmt"
+	"time"
 
+	appErrors "github.com/sopial42/bifrost/pkg/common/errors"
 	"github.com/sopial42/bifrost/pkg/common/logger"
+	"github.com/sopial42/bifrost/pkg/common/metrics"
+	"github.com/sopial42/bifrost/pkg/common/tracing"
+	buySignals "github.com/sopial42/bifrost/pkg/domains/buySignals"
 	"github.com/sopial42/bifrost/pkg/domains/candles"
+	"github.com/sopial42/bifrost/pkg/domains/common"
 	domain "github.com/sopial42/bifrost/pkg/domains/positions"
 	buySignalsSVC "github.com/sopial42/bifrost/pkg/services/buySignals"
 	candlesSVC "github.com/sopial42/bifrost/pkg/services/candles"
@@ -25,16 +31,68 @@ func NewPositionsService(persistence Persistence, candles candlesSVC.Service, bu
 	}
 }
 
-func (p *positionsService) CreatePositions(ctx context.Context, positions *[]domain.Details) (*[]domain.Details, error) {
-	pos, err := p.persistence.InsertPositions(ctx, positions)
+func (p *positionsService) CreatePositions(ctx context.Context, positions *[]domain.Details) (*[]domain.Details, []common.ItemResult[domain.ID], error) {
+	ctx, span := tracing.Start(ctx, "positions.CreatePositions")
+	defer span.End()
+
+	if positions == nil || len(*positions) == 0 {
+		return &[]domain.Details{}, nil, nil
+	}
+
+	ids := make([]buySignals.ID, len(*positions))
+	for i, position := range *positions {
+		ids[i] = position.BuySignalID
+	}
+
+	bsList, err := p.buySignals.GetBuySignalsByIDs(ctx, ids)
 	if err != nil {
-		return &[]domain.Details{}, err
+		return &[]domain.Details{}, nil, fmt.Errorf("unable to get buy signals: %w", err)
+	}
+
+	knownBuySignals := make(map[buySignals.ID]bool, len(*bsList))
+	for _, bs := range *bsList {
+		knownBuySignals[*bs.ID] = true
+	}
+
+	// The positions of unknown buy signals are invalid instead of failing the insert on the foreign key
+	results := make([]common.ItemResult[domain.ID], len(*positions))
+	toInsert := make([]domain.Details, 0, len(*positions))
+	toInsertIndexes := make([]int, 0, len(*positions))
+	for i, position := range *positions {
+		if !knownBuySignals[position.BuySignalID] {
+			results[i] = common.ItemResult[domain.ID]{Index: i, Outcome: common.OutcomeInvalid, Violations: []appErrors.FieldViolation{{
+				Field:   appErrors.Pointer("buy_signal_id"),
+				Code:    appErrors.ViolationInvalidValue,
+				Message: fmt.Sprintf("buy signal %s not found", position.BuySignalID),
+			}}}
+			continue
+		}
+
+		toInsert = append(toInsert, position)
+		toInsertIndexes = append(toInsertIndexes, i)
+	}
+
+	if len(toInsert) == 0 {
+		return &[]domain.Details{}, results, nil
 	}
 
-	return pos, nil
+	pos, inserted, err := p.persistence.InsertPositions(ctx, &toInsert)
+	if err != nil {
+		return &[]domain.Details{}, nil, err
+	}
+
+	for _, res := range inserted {
+		res.Index = toInsertIndexes[res.Index]
+		results[res.Index] = res
+	}
+
+	return pos, results, nil
 }
 
 func (p *positionsService) ComputeRatio(ctx context.Context, id domain.ID) (*domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "positions.ComputeRatio")
+	defer span.End()
+
 	position, err := p.persistence.GetPositionByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get position by ID: %w", err)
@@ -50,6 +108,9 @@ func (p *positionsService) ComputeRatio(ctx context.Context, id domain.ID) (*dom
 }
 
 func (p *positionsService) ComputeAllRatios(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "positions.ComputeAllRatios")
+	defer span.End()
+
 	var err error
 	var positions *[]domain.Details
 	hasMore := true
@@ -63,6 +124,10 @@ func (p *positionsService) ComputeAllRatios(ctx context.Context) (int, error) {
 	}
 
 	log.Infof("Positions with no ratio count: %d", positionsCount)
+	pending := positionsCount
+	metrics.SetPositionsPending(pending)
+	defer metrics.SetPositionsPending(0)
+
 	for hasMore {
 		positions, hasMore, nextCursor, err = p.persistence.GetPositionsWithNoRatio(ctx, nextCursor, 100)
 		if err != nil {
@@ -79,6 +144,7 @@ func (p *positionsService) ComputeAllRatios(ctx context.Context) (int, error) {
 			// get candle that hit the TP or the SL
 			if position.BuySignal == nil {
 				log.Errorf("no buy signal found for position ID: %v", position.ID)
+				metrics.IncPositionsComputed(metrics.ComputeResultFailed)
 				continue
 			}
 
@@ -87,14 +153,22 @@ func (p *positionsService) ComputeAllRatios(ctx context.Context) (int, error) {
 				return 0, fmt.Errorf("unable to compute position: %w", err)
 			}
 
-			if ratio == nil || ratio.Value == 0 {
+			// A stale position that hits neither the TP nor the SL anymore loses its ratio
+			if (ratio == nil || ratio.Value == 0) && !position.Stale {
 				continue
 			}
 
-			position.Ratio = ratio
+			position.Ratio, position.Stale = nil, false
+			if ratio != nil && ratio.Value != 0 {
+				position.Ratio = ratio
+			}
+
 			positionsWithRatios = append(positionsWithRatios, position)
 		}
 
+		pending = max(pending-len(*positions), 0)
+		metrics.SetPositionsPending(pending)
+
 		if len(positionsWithRatios) == 0 {
 			log.Infof("No positions with ratios found")
 			continue
@@ -114,7 +188,13 @@ func (p *positionsService) ComputeAllRatios(ctx context.Context) (int, error) {
 	return updatedPositionsCount, nil
 }
 
-func (p *positionsService) computeRatio(ctx context.Context, position *domain.Details) (*domain.Ratio, error) {
+func (p *positionsService) computeRatio(ctx context.Context, position *domain.Details) (ratio *domain.Ratio, err error) {
+	ctx, span := tracing.Start(ctx, "positions.computeRatio")
+	defer func() {
+		metrics.IncPositionsComputed(computeResult(ratio, err))
+		tracing.End(span, err)
+	}()
+
 	result := domain.Ratio{}
 	log := logger.GetLogger(ctx)
 
@@ -151,7 +231,23 @@ func (p *positionsService) computeRatio(ctx context.Context, position *domain.De
 	return &result, nil
 }
 
+// computeResult returns open when neither the TP nor the SL has been hit yet
+func computeResult(ratio *domain.Ratio, err error) metrics.ComputeResult {
+	if err != nil {
+		return metrics.ComputeResultFailed
+	}
+
+	if ratio == nil {
+		return metrics.ComputeResultOpen
+	}
+
+	return metrics.ComputeResultClosed
+}
+
 func (p *positionsService) CreatePositionsWithBuySignals(ctx context.Context, positions *[]domain.Details) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "positions.CreatePositionsWithBuySignals")
+	defer span.End()
+
 	addedPositions := make([]domain.Details, 0)
 	for _, position := range *positions {
 		if position.BuySignal == nil {
@@ -192,3 +288,182 @@ func (p *positionsService) CreatePositionsWithBuySignals(ctx context.Context, po
 
 	return &addedPositions, nil
 }
+
+type GenerateRequest struct {
+	BuySignalIDs []buySignals.ID
+	Name         domain.Name
+	Params       domain.Metadata
+	// WinLossRatios defaults to domain.AvailableWinLossRatios when empty
+	WinLossRatios []domain.WinLossRatio
+}
+
+func (p *positionsService) GeneratePositions(ctx context.Context, request GenerateRequest) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "positions.GeneratePositions")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithField(domain.LoggerKeyName, request.Name)
+
+	generator, err := domain.NewGenerator(request.Name, request.Params)
+	if err != nil {
+		return &[]domain.Details{}, appErrors.NewInvalidInput("unable to build position generator", err)
+	}
+
+	ratios, err := domain.ParseWinLossRatios(request.WinLossRatios)
+	if err != nil {
+		return &[]domain.Details{}, appErrors.NewInvalidInput("invalid winloss ratios", err)
+	}
+
+	ids := make([]buySignals.ID, 0, len(request.BuySignalIDs))
+	seen := make(map[buySignals.ID]bool, len(request.BuySignalIDs))
+	for _, id := range request.BuySignalIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	bsList, err := p.buySignals.GetBuySignalsByIDs(ctx, ids)
+	if err != nil {
+		return &[]domain.Details{}, fmt.Errorf("unable to get buy signals: %w", err)
+	}
+
+	// The violations point to the buy signal in the request, the generation is all or nothing
+	indexes := make(map[buySignals.ID]int, len(request.BuySignalIDs))
+	for i := len(request.BuySignalIDs) - 1; i >= 0; i-- {
+		indexes[request.BuySignalIDs[i]] = i
+	}
+
+	violations := appErrors.FieldViolations{}
+	if len(*bsList) != len(ids) {
+		found := make(map[buySignals.ID]bool, len(*bsList))
+		for _, bs := range *bsList {
+			found[*bs.ID] = true
+		}
+
+		for _, id := range ids {
+			if !found[id] {
+				violations.Add(appErrors.Pointer("buy_signal_ids", indexes[id]), appErrors.ViolationInvalidValue, fmt.Sprintf("buy signal not found: %s", id))
+			}
+		}
+
+		return &[]domain.Details{}, violations.Err("unable to generate positions for unknown buy signals")
+	}
+
+	newPositions := make([]domain.Details, 0)
+	for _, bs := range *bsList {
+		var history []candles.Candle
+		if generator.Lookback() > 0 {
+			// Only candles closed before the buy date can be used
+			lastDate := time.Time(bs.Date).Add(-time.Second)
+			candleList, _, _, err := p.candles.GetCandlesFromLastDate(ctx, bs.Pair, bs.Interval, &lastDate, generator.Lookback())
+			if err != nil {
+				return &[]domain.Details{}, fmt.Errorf("unable to get candles before buy signal %s: %w", bs.ID, err)
+			}
+
+			if candleList != nil {
+				history = *candleList
+			}
+		}
+
+		positions, err := generator.Generate(bs, history, ratios)
+		if err != nil {
+			violations.Add(appErrors.Pointer("buy_signal_ids", indexes[*bs.ID]), appErrors.ViolationInvalidValue, fmt.Sprintf("unable to generate positions: %v", err))
+			continue
+		}
+
+		newPositions = append(newPositions, positions...)
+	}
+
+	if err := violations.Err("unable to generate positions for some buy signals"); err != nil {
+		return &[]domain.Details{}, err
+	}
+
+	log.WithField(domain.LoggerKeyFullname, generator.Fullname()).Infof("Generated %d positions for %d buy signals", len(newPositions), len(*bsList))
+	if len(newPositions) == 0 {
+		return &newPositions, nil
+	}
+
+	created, _, err := p.CreatePositions(ctx, &newPositions)
+	return created, err
+}
+
+func (p *positionsService) GetPositionsByBuySignals(ctx context.Context, ids []buySignals.ID, fullname domain.Fullname) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "positions.GetPositionsByBuySignals")
+	defer span.End()
+
+	positions, err := p.persistence.QueryPositionsByBuySignalIDs(ctx, ids, fullname)
+	if err != nil {
+		return &[]domain.Details{}, fmt.Errorf("unable to get positions by buy signals: %w", err)
+	}
+
+	return positions, nil
+}
+
+func (p *positionsService) GetComputedPositions(ctx context.Context, pair common.Pair, interval common.Interval, startDate *time.Time, endDate *time.Time) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "positions.GetComputedPositions")
+	defer span.End()
+
+	positions, err := p.persistence.QueryComputedPositions(ctx, pair, interval, startDate, endDate)
+	if err != nil {
+		return &[]domain.Details{}, fmt.Errorf("unable to get computed positions: %w", err)
+	}
+
+	return positions, nil
+}
+
+func (p *positionsService) ExportBuySignalsPositions(ctx context.Context, filter domain.ExportFilter, fn func(domain.BuySignalPosition) error) error {
+	ctx, span := tracing.Start(ctx, "positions.ExportBuySignalsPositions")
+	defer span.End()
+
+	if err := p.persistence.StreamBuySignalsPositions(ctx, filter, fn); err != nil {
+		return fmt.Errorf("unable to export buy signals positions: %w", err)
+	}
+
+	return nil
+}
+
+func (p *positionsService) ComputeRatios(ctx context.Context, positions *[]domain.Details) (*[]domain.Details, error) {
+	ctx, span := tracing.Start(ctx, "positions.ComputeRatios")
+	defer span.End()
+
+	log := logger.GetLogger(ctx)
+	if positions == nil {
+		return &[]domain.Details{}, nil
+	}
+
+	res := make([]domain.Details, len(*positions))
+	positionsWithRatios := make([]domain.Details, 0)
+	for i, position := range *positions {
+		res[i] = position
+		if (position.Ratio != nil && !position.Stale) || position.BuySignal == nil {
+			continue
+		}
+
+		ratio, err := p.computeRatio(ctx, &position)
+		if err != nil {
+			log.Warnf("unable to compute ratio of position %s: %v", position.ID, err)
+			continue
+		}
+
+		if (ratio == nil || ratio.Value == 0) && !position.Stale {
+			continue
+		}
+
+		res[i].Ratio, res[i].Stale = nil, false
+		if ratio != nil && ratio.Value != 0 {
+			res[i].Ratio = ratio
+		}
+
+		positionsWithRatios = append(positionsWithRatios, res[i])
+	}
+
+	if len(positionsWithRatios) == 0 {
+		return &res, nil
+	}
+
+	if _, err := p.persistence.InsertRatios(ctx, &positionsWithRatios); err != nil {
+		return &[]domain.Details{}, fmt.Errorf("unable to insert ratios: %w", err)
+	}
+
+	return &res, nil
+}